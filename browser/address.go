@@ -0,0 +1,138 @@
+package browser
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// serverAddressSize is the size of a single server address in the master server's server list:
+// 16 bytes for the IP and 2 bytes for the port
+const serverAddressSize = 18
+
+// ipv4Prefix marks IPv4 addresses that are mapped into the 16 bytes of an IPv6 address.
+var ipv4Prefix = []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xFF, 0xFF}
+
+// ServerAddress is the address of a game server as it is listed by the master servers.
+type ServerAddress struct {
+	IP   net.IP
+	Port uint16
+}
+
+// String returns the address as ip:port, or [ip]:port for IPv6 addresses.
+func (a ServerAddress) String() string {
+	return net.JoinHostPort(a.IP.String(), strconv.Itoa(int(a.Port)))
+}
+
+// ParseServerAddress parses the address of a game server, which is an IP address that is optionally
+// followed by a port, e.g. "127.0.0.1", "127.0.0.1:8303", "::1", "[::1]" or "[::1]:8303".
+// If the port is omitted, DefaultGamePort is used. An IPv6 address with a port must be enclosed in brackets,
+// "::1:8303" is the IPv6 address ::1:8303 without a port. Surrounding whitespace is ignored.
+// Hostnames are not resolved and are rejected with ErrInvalidIP, invalid ports and port 0 with ErrInvalidPort.
+func ParseServerAddress(s string) (ServerAddress, error) {
+	host, port, err := splitHostPort(s, DefaultGamePort)
+	if err != nil {
+		return ServerAddress{}, fmt.Errorf("%w: %q", err, s)
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return ServerAddress{}, fmt.Errorf("%w: %q", ErrInvalidIP, s)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	return ServerAddress{IP: ip, Port: uint16(port)}, nil
+}
+
+// splitHostPort splits s into its host, which may be an IP address or a hostname, and its port.
+// It accepts the same forms as ParseServerAddress and uses defaultPort if the port is omitted.
+func splitHostPort(s string, defaultPort int) (host string, port int, err error) {
+	s = strings.TrimSpace(s)
+
+	host, portStr, err := net.SplitHostPort(s)
+	switch {
+	case err == nil:
+	case net.ParseIP(s) != nil:
+		// IPv6 without brackets and port
+		host, portStr = s, strconv.Itoa(defaultPort)
+	case strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]"):
+		host, portStr = s[1:len(s)-1], strconv.Itoa(defaultPort)
+	case !strings.ContainsAny(s, ":[]"):
+		host, portStr = s, strconv.Itoa(defaultPort)
+	default:
+		return "", 0, ErrInvalidIP
+	}
+
+	if host == "" || strings.ContainsAny(host, "[] ") {
+		return "", 0, ErrInvalidIP
+	}
+
+	port, err = strconv.Atoi(portStr)
+	if err != nil || port < 1 || math.MaxUint16 < port {
+		return "", 0, ErrInvalidPort
+	}
+	return host, port, nil
+}
+
+// resolveUDPAddr resolves the address of a master or game server, which may contain a hostname
+// instead of an IP address, see splitHostPort.
+func resolveUDPAddr(s string, defaultPort int) (*net.UDPAddr, error) {
+	host, port, err := splitHostPort(s, defaultPort)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", err, s)
+	}
+	return net.ResolveUDPAddr("udp", net.JoinHostPort(host, strconv.Itoa(port)))
+}
+
+// udpAddrs converts the addresses into the UDP addresses that the game servers are queried at.
+func udpAddrs(addrs []ServerAddress) ServerList {
+	servers := make(ServerList, 0, len(addrs))
+	for _, addr := range addrs {
+		servers = append(servers, &net.UDPAddr{IP: addr.IP, Port: int(addr.Port)})
+	}
+	return servers
+}
+
+// ServerAddress returns the address the server info was queried from.
+func (s *ServerInfo) ServerAddress() (ServerAddress, error) {
+	return ParseServerAddress(s.Address)
+}
+
+// MarshalBinary encodes the address in the master server's 18 byte format.
+// IPv4 addresses are mapped into the 16 bytes of an IPv6 address.
+func (a ServerAddress) MarshalBinary() (data []byte, err error) {
+	ip := a.IP.To16()
+	if ip == nil {
+		return nil, ErrInvalidIP
+	}
+
+	data = make([]byte, 0, serverAddressSize)
+	data = append(data, ip...)
+	data = append(data, byte(a.Port>>8), byte(a.Port))
+	return data, nil
+}
+
+// UnmarshalBinary decodes an address in the master server's 18 byte format.
+// IPv4-mapped addresses are decoded as 4 byte IPv4 addresses.
+func (a *ServerAddress) UnmarshalBinary(data []byte) error {
+	if len(data) != serverAddressSize {
+		return ErrInvalidHeaderLength
+	}
+
+	var ip net.IP
+	if bytes.Equal(data[:12], ipv4Prefix) {
+		ip = make(net.IP, net.IPv4len)
+		copy(ip, data[12:16])
+	} else {
+		ip = make(net.IP, net.IPv6len)
+		copy(ip, data[:16])
+	}
+
+	a.IP = ip
+	a.Port = uint16(data[16])<<8 | uint16(data[17])
+	return nil
+}
@@ -0,0 +1,168 @@
+package browser
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestServerAddress_MarshalBinary(t *testing.T) {
+	tests := []struct {
+		name    string
+		addr    ServerAddress
+		want    []byte
+		wantStr string
+	}{
+		{
+			"ipv4",
+			ServerAddress{IP: net.IPv4(192, 168, 0, 1).To4(), Port: DefaultGamePort},
+			[]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff, 192, 168, 0, 1, 0x20, 0x6f},
+			"192.168.0.1:8303",
+		},
+		{
+			"ipv4 in 16 bytes",
+			ServerAddress{IP: net.IPv4(10, 0, 0, 2), Port: 1},
+			[]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff, 10, 0, 0, 2, 0x00, 0x01},
+			"10.0.0.2:1",
+		},
+		{
+			"ipv6",
+			ServerAddress{IP: net.ParseIP("2001:db8::1"), Port: 65535},
+			[]byte{0x20, 0x01, 0x0d, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0xff, 0xff},
+			"[2001:db8::1]:65535",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.addr.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, tt.want) {
+				t.Fatalf("ServerAddress.MarshalBinary() = %v, want %v", data, tt.want)
+			}
+
+			var got ServerAddress
+			if err := got.UnmarshalBinary(data); err != nil {
+				t.Fatal(err)
+			}
+			if !got.IP.Equal(tt.addr.IP) || got.Port != tt.addr.Port {
+				t.Errorf("ServerAddress.UnmarshalBinary() = %v, want %v", got, tt.addr)
+			}
+			if got.String() != tt.wantStr {
+				t.Errorf("ServerAddress.String() = %s, want %s", got, tt.wantStr)
+			}
+		})
+	}
+
+	if _, err := (ServerAddress{}).MarshalBinary(); err != ErrInvalidIP {
+		t.Errorf("expected %v, got %v", ErrInvalidIP, err)
+	}
+
+	var addr ServerAddress
+	if err := addr.UnmarshalBinary(make([]byte, serverAddressSize-1)); err != ErrInvalidHeaderLength {
+		t.Errorf("expected %v, got %v", ErrInvalidHeaderLength, err)
+	}
+}
+
+func TestServerInfo_ServerAddress(t *testing.T) {
+	gs := newFakeGameServer(t, ServerInfo{Version: "0.7.5", Name: "server"})
+	defer gs.Close()
+
+	info, err := GetServerInfoWithTimeout(gs.Addr().IP.String(), gs.Addr().Port, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Address != gs.Addr().String() {
+		t.Fatalf("ServerInfo.Address = %q, want %q", info.Address, gs.Addr().String())
+	}
+
+	addr, err := info.ServerAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !addr.IP.Equal(gs.Addr().IP) || int(addr.Port) != gs.Addr().Port {
+		t.Errorf("ServerInfo.ServerAddress() = %s, want %s", addr, gs.Addr())
+	}
+
+	info.Address = ""
+	if _, err := info.ServerAddress(); err == nil {
+		t.Error("expected an error for a server info without address")
+	}
+}
+
+func TestParseServerAddress(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr error
+	}{
+		{"127.0.0.1", "127.0.0.1:8303", nil},
+		{"127.0.0.1:8305", "127.0.0.1:8305", nil},
+		{" 127.0.0.1:8305\n", "127.0.0.1:8305", nil},
+		{"[127.0.0.1]:8305", "127.0.0.1:8305", nil},
+		{"::ffff:127.0.0.1", "127.0.0.1:8303", nil},
+		{"::1", "[::1]:8303", nil},
+		{"[::1]", "[::1]:8303", nil},
+		{"[::1]:8305", "[::1]:8305", nil},
+		{"2001:db8::1", "[2001:db8::1]:8303", nil},
+		{"[2001:db8::1]:65535", "[2001:db8::1]:65535", nil},
+		// without brackets the last group is part of the IPv6 address
+		{"::1:8305", "[::1:8305]:8303", nil},
+
+		{"", "", ErrInvalidIP},
+		{"   ", "", ErrInvalidIP},
+		{"localhost", "", ErrInvalidIP},
+		{"teeworlds.example:8303", "", ErrInvalidIP},
+		{"256.0.0.1", "", ErrInvalidIP},
+		{"[::1", "", ErrInvalidIP},
+		{"::1]", "", ErrInvalidIP},
+		{"[]:8303", "", ErrInvalidIP},
+		{":8303", "", ErrInvalidIP},
+		{"1.2.3.4:8303:8303", "", ErrInvalidIP},
+		{"127.0.0.1:", "", ErrInvalidPort},
+		{"127.0.0.1:0", "", ErrInvalidPort},
+		{"127.0.0.1:-1", "", ErrInvalidPort},
+		{"127.0.0.1:65536", "", ErrInvalidPort},
+		{"127.0.0.1:port", "", ErrInvalidPort},
+		{"[::1]:99999", "", ErrInvalidPort},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseServerAddress(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseServerAddress(%q) error = %v, want %v", tt.input, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.String() != tt.want {
+				t.Errorf("ParseServerAddress(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+			if len(got.IP) != net.IPv4len && got.IP.To4() != nil {
+				t.Errorf("ParseServerAddress(%q) returned the IPv4 address in %d bytes", tt.input, len(got.IP))
+			}
+		})
+	}
+}
+
+func TestNewMasterServerDefaultPort(t *testing.T) {
+	ms, err := NewMasterServer("127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ms.Close()
+
+	if ms.Addr().Port != DefaultMasterPort {
+		t.Errorf("NewMasterServer() port = %d, want %d", ms.Addr().Port, DefaultMasterPort)
+	}
+
+	if _, err := NewMasterServer("127.0.0.1:port"); !errors.Is(err, ErrInvalidPort) {
+		t.Errorf("expected %v, got %v", ErrInvalidPort, err)
+	}
+}
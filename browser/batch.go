@@ -0,0 +1,45 @@
+package browser
+
+import (
+	"context"
+)
+
+// BatchQueryInfoStream queries every server of addrs for its info concurrently and sends every result
+// to the returned channel as soon as it arrives, e.g. in order to render a server list while it is
+// being queried. A server that fails to respond is sent as a ServerInfo whose Online field is false
+// and whose Err is the error of its query. The options are the same that ListServersWithInfo accepts,
+// WithIncludeOffline has no effect.
+// The channel is closed once every server has been queried or ctx is done, servers whose queries
+// are interrupted by ctx are not sent. The caller must keep receiving until the channel is closed
+// or ctx is done, otherwise the queries block.
+func BatchQueryInfoStream(ctx context.Context, addrs ServerList, opts ...Option) <-chan ServerInfo {
+	o := newOptions(opts...)
+	infos := make(chan ServerInfo)
+
+	go func() {
+		defer close(infos)
+
+		pool := newWorkerPool(limitWorkers(o.concurrency, len(addrs)))
+		for _, srv := range addrs {
+			srv := srv
+			submitted := pool.submit(ctx, func() {
+				info, err := queryServerInfo(ctx, srv, o)
+				if ctx.Err() != nil {
+					return
+				} else if err != nil {
+					info = ServerInfo{Address: srv.String(), Err: err}
+				}
+
+				select {
+				case infos <- info:
+				case <-ctx.Done():
+				}
+			})
+			if !submitted {
+				break
+			}
+		}
+		pool.wait()
+	}()
+	return infos
+}
@@ -0,0 +1,73 @@
+package browser
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBatchQueryInfoStream(t *testing.T) {
+	fast := newFakeGameServer(t, ServerInfo{Version: "0.7.5", Name: "fast"})
+	defer fast.Close()
+
+	slow := newFakeServer(t)
+	slow.info = &ServerInfo{Version: "0.7.5", Name: "slow"}
+	slow.delay = 300 * time.Millisecond
+	slow.start()
+	defer slow.Close()
+
+	// a server that sends its token, but never its info
+	silent := newFakeServer(t)
+	silent.start()
+	defer silent.Close()
+
+	const queryTimeout = 2 * time.Second
+	begin := time.Now()
+	infos := BatchQueryInfoStream(context.Background(), ServerList{slow.Addr(), silent.Addr(), fast.Addr()},
+		WithQueryTimeout(queryTimeout),
+	)
+
+	first := <-infos
+	if first.Name != "fast" || !first.Online {
+		t.Errorf("expected the fast server first, got %s", first.String())
+	}
+	if elapsed := time.Since(begin); elapsed >= queryTimeout/2 {
+		t.Errorf("expected the first info before the query timeout, it took %v", elapsed)
+	}
+
+	second := <-infos
+	if second.Name != "slow" {
+		t.Errorf("expected the slow server second, got %s", second.String())
+	}
+	if elapsed := time.Since(begin); elapsed >= queryTimeout {
+		t.Errorf("expected the second info before the query timeout, it took %v", elapsed)
+	}
+
+	third, ok := <-infos
+	if !ok || third.Online || third.Address != silent.Addr().String() || third.Err == nil {
+		t.Errorf("expected the silent server with an error, got %s: %v", third.String(), third.Err)
+	}
+
+	if _, ok := <-infos; ok {
+		t.Error("expected the channel to be closed")
+	}
+}
+
+func TestBatchQueryInfoStreamCancel(t *testing.T) {
+	silent := newFakeServer(t)
+	silent.start()
+	defer silent.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	infos := BatchQueryInfoStream(ctx, ServerList{silent.Addr(), silent.Addr()}, WithQueryTimeout(10*time.Second))
+
+	time.AfterFunc(50*time.Millisecond, cancel)
+	select {
+	case info, ok := <-infos:
+		if ok {
+			t.Errorf("expected no info after the cancellation, got %s", info.String())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the channel has not been closed after the cancellation")
+	}
+}
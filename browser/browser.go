@@ -8,11 +8,20 @@ import (
 	"io"
 	"log"
 	"net"
+	"strconv"
 	"time"
 
 	"github.com/jxsl13/twapi/compression"
 )
 
+const (
+	// DefaultMasterPort is the port master servers listen on.
+	DefaultMasterPort = 8283
+
+	// DefaultGamePort is the port game servers listen on, unless configured otherwise with sv_port.
+	DefaultGamePort = 8303
+)
+
 const (
 	// Used for the masterserver
 	requestServerList  = "\xff\xff\xff\xffreq2"
@@ -21,8 +30,8 @@ const (
 	sendServerCount    = "\xff\xff\xff\xffsiz2"
 
 	// Used for the gameserver
-	requestInfo = "\xff\xff\xff\xffgie3\x00" // need explicitly the trailing \x00
-	sendInfo    = "\xff\xff\xff\xffinf3\x00"
+	requestInfo = "\xff\xff\xff\xffgie3" // followed by the packed extra token
+	sendInfo    = "\xff\xff\xff\xffinf3" // followed by the echoed extra token
 
 	minHeaderLength = 8 // length of the shortest header
 	maxHeaderLength = 9 // length of the longest header
@@ -34,6 +43,7 @@ const (
 	maxPrefixLength = tokenPrefixSize + maxHeaderLength
 
 	maxBufferSize             = 1500
+	maxPacketSize             = 1400 // protocol maximum, larger packets are not sent by Teeworlds
 	maxChunks                 = 16
 	maxServersPerMasterServer = 75
 
@@ -50,11 +60,15 @@ var (
 	// TimeoutServers is also used by ServerInfos as a alue that drops few packets
 	TimeoutServers = TokenExpirationDuration
 
+	// MaxServerInfoPlayers is the maximum number of players that are parsed from a server info.
+	// Servers cannot have more than 64 clients, which is why any additional players are ignored.
+	MaxServerInfoPlayers = 64
+
 	// ErrInvalidIP is returned if the passed IP to some function is not a valid IP address
 	ErrInvalidIP = errors.New("invalid IP error, passed")
 
 	// ErrInvalidPort is returned if the passed port is either negative or an invalid value above 65536.
-	ErrInvalidPort = errors.New("invalid IP error, passed")
+	ErrInvalidPort = errors.New("invalid port")
 
 	// ErrTokenExpired is returned when a request packet is being constructed with an expired token
 	ErrTokenExpired = errors.New("token expired")
@@ -84,6 +98,26 @@ var (
 	// ErrRequestResponseMismatch is returned by functions that request and receive data, but the received data does not match the requested data.
 	ErrRequestResponseMismatch = errors.New("request response mismatch")
 
+	// ErrServerUnreachable is returned if the operating system reports that nothing is listening
+	// at the queried address, e.g. due to an ICMP port unreachable message.
+	ErrServerUnreachable = errors.New("server unreachable")
+
+	// ErrPacketTooLarge is returned if a received packet exceeds the protocol's maximum packet size.
+	// Such packets cannot have been sent by Teeworlds.
+	ErrPacketTooLarge = errors.New("packet too large")
+
+	// ErrDialTimeout is returned if resolving a hostname takes longer than the dial timeout.
+	ErrDialTimeout = errors.New("dial timeout")
+
+	// ErrInvalidInterval is returned if a polling interval is not positive.
+	ErrInvalidInterval = errors.New("invalid interval")
+
+	// ErrNoMasterServer is returned if none of the master servers responded.
+	ErrNoMasterServer = errors.New("no master server responded")
+
+	// ErrNoServerList is returned if none of the master servers responded with a server list.
+	ErrNoServerList = errors.New("no server list received")
+
 	// TokenExpirationDuration sets the protocol expiration time of a token
 	// This variable can be changed
 	TokenExpirationDuration = time.Second * 16
@@ -96,11 +130,11 @@ var (
 	sendInfoRaw           = []byte(sendInfo)
 	delimiter             = []byte("\x00")
 
-	masterServerHostnameAddresses = []string{
-		"master1.teeworlds.com:8283",
-		"master2.teeworlds.com:8283",
-		"master3.teeworlds.com:8283",
-		"master4.teeworlds.com:8283",
+	masterServerHostnames = []string{
+		"master1.teeworlds.com",
+		"master2.teeworlds.com",
+		"master3.teeworlds.com",
+		"master4.teeworlds.com",
 	}
 
 	// MasterServerAddresses contains the resolved addresses as ip:port
@@ -113,9 +147,10 @@ func init() {
 		log.Println("Initializing twapi package...")
 	}
 
-	MasterServerAddresses = make([]*net.UDPAddr, 0, len(masterServerHostnameAddresses))
+	MasterServerAddresses = make([]*net.UDPAddr, 0, len(masterServerHostnames))
 
-	for _, ms := range masterServerHostnameAddresses {
+	for _, hostname := range masterServerHostnames {
+		ms := net.JoinHostPort(hostname, strconv.Itoa(DefaultMasterPort))
 		srv, err := net.ResolveUDPAddr("udp", ms)
 		if err != nil {
 			if Logging {
@@ -192,19 +227,50 @@ type ServerList []*net.UDPAddr
 
 // ServerInfo contains the server's general information
 type ServerInfo struct {
-	Address     string       `json:"address"`
-	Version     string       `json:"version"`
-	Name        string       `json:"name"`
-	Hostname    string       `json:"hostname,omitempty"`
-	Map         string       `json:"map"`
-	GameType    string       `json:"gametype"`
-	ServerFlags int          `json:"server_flags"`
-	SkillLevel  int          `json:"skill_level"`
-	NumPlayers  int          `json:"num_players"`
-	MaxPlayers  int          `json:"max_players"`
-	NumClients  int          `json:"num_clients"`
-	MaxClients  int          `json:"max_clients"`
-	Players     []PlayerInfo `json:"players"`
+	// Address is the ip:port address the info was queried from.
+	Address     string      `json:"address"`
+	Version     string      `json:"version"`
+	Name        string      `json:"name"`
+	Hostname    string      `json:"hostname,omitempty"`
+	Map         string      `json:"map"`
+	GameType    string      `json:"gametype"`
+	ServerFlags ServerFlags `json:"server_flags"`
+	SkillLevel  int         `json:"skill_level"`
+
+	// NumPlayers and MaxPlayers count the players that are in the game, excluding spectators.
+	NumPlayers int `json:"num_players"`
+	MaxPlayers int `json:"max_players"`
+
+	// NumClients and MaxClients count all connected clients, including spectators.
+	// Responses that only contain the player counts are mirrored, see ParseLegacyServerInfo.
+	NumClients int          `json:"num_clients"`
+	MaxClients int          `json:"max_clients"`
+	Players    []PlayerInfo `json:"players"`
+
+	// Ping is the round-trip time of the info request, which is measured from the last info request
+	// that was sent after the token handshake to the response. It excludes the handshake and the retries.
+	// It is zero if the info was not queried by ListServersWithInfo or a similar function.
+	// As it differs with every query, it is not compared by Equal.
+	Ping time.Duration `json:"ping,omitempty"`
+
+	// Online is true if the info was received from the server. It is false for the offline servers
+	// that are included with WithIncludeOffline, in that case Err is the error of the failed query.
+	// Neither is compared by Equal.
+	Online bool  `json:"online"`
+	Err    error `json:"-"`
+
+	// Truncated is true if some of the packets of a DDNet extended server info did not arrive in time,
+	// in that case Players only contains the players that have been received, see GetServerInfoExtended.
+	// It is not compared by Equal.
+	Truncated bool `json:"truncated,omitempty"`
+
+	// The following fields are only reported by DDNet servers and are empty otherwise,
+	// see ParseLegacyServerInfo and ParseDDNetServerInfo.
+	MapCRC      uint32    `json:"map_crc,omitempty"`
+	MapSize     int       `json:"map_size,omitempty"`
+	MapSHA256   string    `json:"map_sha256,omitempty"`
+	ScoreKind   ScoreKind `json:"score_kind,omitempty"`
+	CommunityID string    `json:"community_id,omitempty"`
 }
 
 // Empty returns true if the whole struct does not contain any data at all
@@ -221,7 +287,16 @@ func (s *ServerInfo) Empty() bool {
 		s.MaxPlayers == 0 &&
 		s.NumClients == 0 &&
 		s.MaxClients == 0 &&
-		len(s.Players) == 0
+		len(s.Players) == 0 &&
+		s.Ping == 0 &&
+		!s.Online &&
+		s.Err == nil &&
+		!s.Truncated &&
+		s.MapCRC == 0 &&
+		s.MapSize == 0 &&
+		s.MapSHA256 == "" &&
+		s.ScoreKind == ScoreKindUnspecified &&
+		s.CommunityID == ""
 }
 
 // fix synchronizes the length of playerInfo with its struct field
@@ -229,22 +304,34 @@ func (s *ServerInfo) fix() {
 	s.NumClients = len(s.Players)
 }
 
-// Equal compares two instances of ServerInfo and returns true if they are equal
+// Equal compares two instances of ServerInfo and returns true if they are equal.
+// The order of the players is not taken into account, as servers do not send them in a fixed order.
 func (s *ServerInfo) Equal(other ServerInfo) bool {
 	s.fix()
 	other.fix()
-	equalData := s.Address == other.Address && s.Version == other.Version && s.Name == other.Name && s.Hostname == other.Hostname && s.Map == other.Map && s.GameType == other.GameType && s.ServerFlags == other.ServerFlags && s.SkillLevel == other.SkillLevel && s.NumPlayers == other.NumPlayers && s.MaxPlayers == other.MaxPlayers && s.NumClients == other.NumClients && s.MaxClients == other.MaxClients
+	equalData := s.Address == other.Address && s.Version == other.Version && s.Name == other.Name && s.Hostname == other.Hostname && s.Map == other.Map && s.GameType == other.GameType && s.ServerFlags == other.ServerFlags && s.SkillLevel == other.SkillLevel && s.NumPlayers == other.NumPlayers && s.MaxPlayers == other.MaxPlayers && s.NumClients == other.NumClients && s.MaxClients == other.MaxClients &&
+		s.MapCRC == other.MapCRC && s.MapSize == other.MapSize && s.MapSHA256 == other.MapSHA256 && s.ScoreKind == other.ScoreKind && s.CommunityID == other.CommunityID
+	if !equalData {
+		return false
+	}
 
 	// equal Players
 	if len(s.Players) != len(other.Players) {
 		return false
 	}
-	for idx, p := range s.Players {
-		if !p.Equal(other.Players[idx]) {
+
+	// the same players might occur multiple times
+	players := make(map[PlayerInfo]int, len(s.Players))
+	for _, p := range s.Players {
+		players[p]++
+	}
+	for _, p := range other.Players {
+		if players[p] == 0 {
 			return false
 		}
+		players[p]--
 	}
-	return equalData
+	return true
 }
 
 func (s *ServerInfo) String() string {
@@ -309,25 +396,28 @@ func (s *ServerInfo) UnmarshalBinary(data []byte) (err error) {
 	s.GameType = string(slots[4])
 
 	data = slots[5] // get next raw data chunk
+	if len(data) < 2 {
+		return fmt.Errorf("%w : expected server flags and skill level", ErrMalformedResponseData)
+	}
 
-	s.ServerFlags = int(data[0])
+	s.ServerFlags = ServerFlags(data[0])
 	s.SkillLevel = int(data[1])
 
-	data = data[2:] // skip first two already evaluated bytes
-	v := compression.NewVarIntFrom(data)
-	s.NumPlayers, err = v.Unpack()
+	u := compression.NewUnpacker(data[2:], false) // skip first two already evaluated bytes
+	s.NumPlayers, err = u.NextInt()
 	if err != nil {
 		return
 	}
-	s.MaxPlayers, err = v.Unpack()
+	s.MaxPlayers, err = u.NextInt()
 	if err != nil {
 		return
 	}
-	s.NumClients, err = v.Unpack()
+	// a hostile server could send an absurd number of clients
+	s.NumClients, err = u.NextIntClamped(0, MaxServerInfoPlayers)
 	if err != nil {
 		return
 	}
-	s.MaxClients, err = v.Unpack()
+	s.MaxClients, err = u.NextInt()
 	if err != nil {
 		return
 	}
@@ -335,36 +425,23 @@ func (s *ServerInfo) UnmarshalBinary(data []byte) (err error) {
 	// preallocate space for player pointers
 	s.Players = make([]PlayerInfo, 0, s.NumClients)
 
-	v.Bytes() // return the not yet used remaining data
-
-	for i := 0; i < s.NumClients; i++ {
-		player := PlayerInfo{}
-
-		slots := bytes.SplitN(v.Bytes(), delimiter, 3) // create 3 slots
-		if len(slots) != 3 {
-			return fmt.Errorf("%w : expected slots: 3 got: %d", ErrMalformedResponseData, len(slots))
-		}
-
-		player.Name = string(slots[0])
-		player.Clan = string(slots[1])
-
-		v = compression.NewVarIntFrom(slots[2])
-		player.Country, err = v.Unpack()
-		if err != nil {
-			return
-		}
-		player.Score, err = v.Unpack()
-		if err != nil {
-			return
-		}
-		player.Type, err = v.Unpack()
-		if err != nil {
-			return
+	// a truncated packet contains fewer players than announced, the players
+	// that have been received completely are kept.
+	u.Accumulate = true
+	for len(s.Players) < s.NumClients && u.Size() > 0 {
+		var player PlayerInfo
+		player.Name, _ = u.NextString()
+		player.Clan, _ = u.NextString()
+		player.Country, _ = u.NextInt()
+		player.Score, _ = u.NextInt()
+		player.Type, _ = u.NextInt()
+		if u.Err() != nil {
+			break
 		}
 
 		s.Players = append(s.Players, player)
 	}
-	return
+	return nil
 }
 
 // PlayerInfo contains a players externally visible information
@@ -428,6 +505,13 @@ func (ts *Token) Equal(t Token) bool {
 	return bytes.Equal(ts.Payload, t.Payload)
 }
 
+// IsResponseToken returns true if the connectionless response message is addressed to
+// the client that requested this token.
+func (ts *Token) IsResponseToken(responseMessage []byte) bool {
+	tokenClient, _, ok := StripConnless(responseMessage)
+	return ok && tokenClient == ts.client
+}
+
 // String implements the Stringer interface and returns a stringrepresentation of the token
 func (ts *Token) String() string {
 	return fmt.Sprintf("Token(%d): Client: %d Server: %d Expires: %s", len(ts.Payload), ts.client, ts.server, ts.expiresAt.String())
@@ -1,6 +1,12 @@
 package browser
 
-import "testing"
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jxsl13/twapi/compression"
+)
 
 func TestServerInfo_Equal(t *testing.T) {
 	type fields struct {
@@ -10,7 +16,7 @@ func TestServerInfo_Equal(t *testing.T) {
 		Hostname    string
 		Map         string
 		GameType    string
-		ServerFlags int
+		ServerFlags ServerFlags
 		SkillLevel  int
 		NumPlayers  int
 		MaxPlayers  int
@@ -30,6 +36,9 @@ func TestServerInfo_Equal(t *testing.T) {
 		{"equal empty", fields{}, args{ServerInfo{}}, true},
 		{"not equal", fields{Address: "127.0.0.1:8303", Version: "0.7.4", Name: "Simply zCatch", MaxClients: 64, Players: []PlayerInfo{{Name: "player"}}}, args{ServerInfo{}}, false},
 		{"equal", fields{Address: "127.0.0.1:8303", Version: "0.7.4", Name: "Simply zCatch", MaxClients: 64, Players: []PlayerInfo{{Name: "player1"}, {Name: "player2", Clan: "clan2"}}}, args{ServerInfo{Address: "127.0.0.1:8303", Version: "0.7.4", Name: "Simply zCatch", MaxClients: 64, Players: []PlayerInfo{{Name: "player1"}, {Name: "player2", Clan: "clan2"}}}}, true},
+		{"different player order", fields{Address: "127.0.0.1:8303", Version: "0.7.4", Name: "Simply zCatch", MaxClients: 64, Players: []PlayerInfo{{Name: "player1", Score: 3}, {Name: "player2", Clan: "clan2"}}}, args{ServerInfo{Address: "127.0.0.1:8303", Version: "0.7.4", Name: "Simply zCatch", MaxClients: 64, Players: []PlayerInfo{{Name: "player2", Clan: "clan2"}, {Name: "player1", Score: 3}}}}, true},
+		{"different score", fields{Address: "127.0.0.1:8303", Version: "0.7.4", Name: "Simply zCatch", MaxClients: 64, Players: []PlayerInfo{{Name: "player1", Score: 3}, {Name: "player2", Clan: "clan2"}}}, args{ServerInfo{Address: "127.0.0.1:8303", Version: "0.7.4", Name: "Simply zCatch", MaxClients: 64, Players: []PlayerInfo{{Name: "player2", Clan: "clan2"}, {Name: "player1", Score: 4}}}}, false},
+		{"duplicate players", fields{Players: []PlayerInfo{{Name: "player1"}, {Name: "player1"}, {Name: "player2"}}}, args{ServerInfo{Players: []PlayerInfo{{Name: "player1"}, {Name: "player2"}, {Name: "player2"}}}}, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -85,7 +94,8 @@ func TestServerInfo_Equal(t *testing.T) {
 		data[j] = sendInfoRaw[i]
 		i++
 	}
-	// add encoded server info with players
+	// add the echoed extra token and the encoded server info with players
+	data = append(data, 0)
 	data = append(data, serverData...)
 
 	// parse the constructed data back into a server info
@@ -101,3 +111,97 @@ func TestServerInfo_Equal(t *testing.T) {
 		t.Fatalf("Wanted= %s, Parsed=%s", info.String(), parsedInfo.String())
 	}
 }
+
+func TestDefaultPorts(t *testing.T) {
+	if DefaultMasterPort != 8283 {
+		t.Errorf("DefaultMasterPort = %d, want %d", DefaultMasterPort, 8283)
+	}
+	if DefaultGamePort != 8303 {
+		t.Errorf("DefaultGamePort = %d, want %d", DefaultGamePort, 8303)
+	}
+}
+
+func TestServerInfo_UnmarshalBinaryMaxPlayers(t *testing.T) {
+	info := ServerInfo{Version: "0.7.5", Name: "crowded", MaxClients: 64}
+	for i := 0; i < MaxServerInfoPlayers+10; i++ {
+		info.Players = append(info.Players, PlayerInfo{Name: fmt.Sprintf("player%d", i)})
+	}
+
+	data, err := info.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parsed ServerInfo
+	if err := parsed.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Players) != MaxServerInfoPlayers {
+		t.Errorf("parsed %d players, want %d", len(parsed.Players), MaxServerInfoPlayers)
+	}
+
+	// an absurd number of clients, but a single player only
+	var v compression.VarInt
+	v.Pack(1)
+	v.Pack(64)
+	v.Pack(1 << 30)
+	v.Pack(64)
+	absurd := append([]byte("0.7.5\x00name\x00host\x00map\x00gametype\x00\x00\x00"), v.Bytes()...)
+	absurd = append(absurd, "player\x00clan\x00\x00\x00\x00"...)
+
+	parsed = ServerInfo{}
+	if err := parsed.UnmarshalBinary(absurd); err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Players) != 1 {
+		t.Errorf("parsed %d players, want 1", len(parsed.Players))
+	}
+	if cap(parsed.Players) > MaxServerInfoPlayers {
+		t.Errorf("allocated space for %d players, want at most %d", cap(parsed.Players), MaxServerInfoPlayers)
+	}
+
+	// missing server flags and skill level
+	parsed = ServerInfo{}
+	if err := parsed.UnmarshalBinary([]byte("0.7.5\x00name\x00host\x00map\x00gametype\x00")); !errors.Is(err, ErrMalformedResponseData) {
+		t.Errorf("expected %v, got %v", ErrMalformedResponseData, err)
+	}
+}
+
+func TestServerInfo_UnmarshalBinaryTruncatedPlayers(t *testing.T) {
+	info := ServerInfo{
+		Version:    "0.7.5",
+		Name:       "truncated",
+		NumPlayers: 3,
+		MaxPlayers: 8,
+		NumClients: 3,
+		MaxClients: 8,
+		Players: []PlayerInfo{
+			{Name: "first", Clan: "clan", Country: -1, Score: 10},
+			{Name: "second", Country: 276, Score: -3, Type: 1},
+			{Name: "third", Clan: "clan", Score: 5},
+		},
+	}
+	data, err := info.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the last player is missing completely, cut off within its name and within its score
+	for _, cut := range []int{len("third\x00clan\x00\x00\x05\x00"), len("hird\x00clan\x00\x00\x05\x00"), len("\x05\x00")} {
+		var parsed ServerInfo
+		if err := parsed.UnmarshalBinary(data[:len(data)-cut]); err != nil {
+			t.Fatalf("cut %d bytes: %v", cut, err)
+		}
+		if len(parsed.Players) != 2 {
+			t.Fatalf("cut %d bytes: parsed %d players, want 2", cut, len(parsed.Players))
+		}
+		for idx, p := range parsed.Players {
+			if !p.Equal(info.Players[idx]) {
+				t.Errorf("cut %d bytes: player %d = %v, want %v", cut, idx, p, info.Players[idx])
+			}
+		}
+		if parsed.NumClients != 3 {
+			t.Errorf("cut %d bytes: NumClients = %d, want the announced 3", cut, parsed.NumClients)
+		}
+	}
+}
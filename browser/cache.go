@@ -55,6 +55,24 @@ func (cm *ConcurrentMap) Add(si ServerInfo, expiresIn time.Duration) {
 	cm.Unlock()
 }
 
+// Update adds the server info to the map like Add does and reports whether it differs from
+// the previously stored info of the same server. New servers are reported as changed.
+// This allows to only react to actual changes when polling servers repeatedly.
+func (cm *ConcurrentMap) Update(si ServerInfo, expiresIn time.Duration) (changed bool) {
+	esi := ExpiringServerInfo{ServerInfo: si}
+
+	if expiresIn > 0 {
+		esi.ExpiresAt = time.Now().Add(expiresIn)
+	}
+
+	cm.Lock()
+	defer cm.Unlock()
+
+	previous, ok := cm.Map[esi.Address]
+	cm.Map[esi.Address] = esi
+	return !ok || !previous.Equal(si)
+}
+
 // Get retrieves the ServerInfo
 func (cm *ConcurrentMap) Get(key string) (si ServerInfo, ok bool) {
 	cm.RLock()
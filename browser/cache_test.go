@@ -22,3 +22,33 @@ func TestExpiringServerInfo_Expired(t *testing.T) {
 		t.Fatal("didn't expire, even tho it should have expired.")
 	}
 }
+
+func TestConcurrentMap_Update(t *testing.T) {
+	cm := NewConcurrentMap(1)
+
+	info := ServerInfo{
+		Address: "127.0.0.1:8303",
+		Name:    "server",
+		Players: []PlayerInfo{{Name: "player1"}, {Name: "player2", Score: 1}},
+	}
+
+	if !cm.Update(info, 0) {
+		t.Error("new server must be reported as changed")
+	}
+
+	reordered := info
+	reordered.Players = []PlayerInfo{{Name: "player2", Score: 1}, {Name: "player1"}}
+	if cm.Update(reordered, 0) {
+		t.Error("reordered players must not be reported as changed")
+	}
+
+	scored := info
+	scored.Players = []PlayerInfo{{Name: "player1"}, {Name: "player2", Score: 2}}
+	if !cm.Update(scored, 0) {
+		t.Error("changed score must be reported as changed")
+	}
+
+	if got, _ := cm.Get(info.Address); !got.Equal(scored) {
+		t.Errorf("ConcurrentMap.Get() = %s, want %s", got.String(), scored.String())
+	}
+}
@@ -2,16 +2,27 @@ package browser
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"math"
 	"net"
+	"strconv"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 )
 
 // RequestToken writes the payload to w
 func RequestToken(w io.Writer) (err error) {
-	tokenReq := NewTokenRequestPacket()
+	return requestToken(w, GenerateClientToken())
+}
+
+// requestToken writes a token request with the given client token to w.
+func requestToken(w io.Writer, clientToken int) (err error) {
+	tokenReq := newTokenRequestPacket(clientToken)
 	n, err := w.Write(tokenReq)
 	if err != nil {
 		return
@@ -24,7 +35,9 @@ func RequestToken(w io.Writer) (err error) {
 
 // ReceiveToken reads the token payload from the reader r
 func ReceiveToken(r io.Reader) (response []byte, err error) {
-	response = make([]byte, tokenResponseSize)
+	// the buffer must be able to hold any response, otherwise a larger, left over
+	// response would be truncated and mistaken for a token response.
+	response = make([]byte, maxBufferSize)
 	read, err := r.Read(response)
 	if err != nil {
 		return nil, err
@@ -38,47 +51,57 @@ func ReceiveToken(r io.Reader) (response []byte, err error) {
 }
 
 // FetchToken tries to fetch a token from the server for a specific duration at most. a timeout below 35 ms will be set to 35 ms
+// Every request of a call uses the same client token, responses that do not echo it are ignored.
 func FetchToken(rwd ReadWriteDeadliner, timeout time.Duration) (response []byte, err error) {
+	return fetchToken(rwd, timeout, 0, TimeoutWait{}, GenerateClientToken())
+}
+
+// fetchToken is FetchToken with every retry interval being decided by the wait strategy and randomized
+// by the jitter fraction. The client token is passed by the caller.
+func fetchToken(rwd ReadWriteDeadliner, timeout time.Duration, jitter float64, wait WaitStrategy, clientToken int) (response []byte, err error) {
 	if timeout < minTimeout {
 		timeout = minTimeout
 	}
 
 	begin := time.Now()
-	timeLeft := timeout
-	currentTimeout := minTimeout
 	writeBurst := 1.0
 
-	for {
-		timeLeft = timeout - time.Since(begin)
-		rwd.SetReadDeadline(time.Now().Add(currentTimeout))
-
-		if timeLeft <= 0 {
+	for attempt := 0; ; attempt++ {
+		currentTimeout := wait.Wait(attempt, time.Since(begin), timeout)
+		if currentTimeout <= 0 {
 			// early return, because timed out
 			err = ErrTimeout
 			return
 		}
+		sent := time.Now()
+		rwd.SetReadDeadline(sent.Add(jitterDuration(currentTimeout, jitter)))
 
 		// send multiple requests
 		for i := 0.0; i < writeBurst; i += 1.0 {
-			err = RequestToken(rwd)
+			err = requestToken(rwd, clientToken)
 			if err != nil {
-				return
+				return nil, unreachableError(err)
 			}
 		}
 
 		// wait for response
 		response, err = ReceiveToken(rwd)
 		if err == nil {
+			// a response to a different handshake or a spoofed response
+			var responseToken int
+			responseToken, _, err = unpackTokenResponse(response)
+			if err == nil && responseToken != clientToken {
+				err = ErrRequestResponseMismatch
+			}
+		}
+		if err == nil {
+			observeRTT(wait, time.Since(sent))
 			return
+		} else if isUnreachable(err) {
+			return nil, unreachableError(err)
 		}
 
-		// increase time & request burst
-		timeLeft = timeout - time.Since(begin)
-		if timeLeft <= currentTimeout {
-			currentTimeout = timeLeft
-		} else {
-			currentTimeout *= 2
-		}
+		// increase request burst
 		writeBurst *= 1.2
 	}
 }
@@ -126,6 +149,8 @@ func Receive(packet string, r io.Reader) (response []byte, err error) {
 
 	if read == 0 {
 		return response, ErrInvalidResponseMessage
+	} else if read > maxPacketSize {
+		return nil, ErrPacketTooLarge
 	}
 
 	match, err := MatchResponse(response)
@@ -139,52 +164,95 @@ func Receive(packet string, r io.Reader) (response []byte, err error) {
 	return response, err
 }
 
+// receiveWithToken receives responses until a response is addressed to the token's client.
+// As the requests are connectionless, anyone can send responses to us, which is why
+// responses that do not echo our token are discarded, as are oversized packets.
+func receiveWithToken(packet string, token Token, r io.Reader) (response []byte, err error) {
+	for {
+		response, err = Receive(packet, r)
+		if err == ErrPacketTooLarge {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if token.IsResponseToken(response) {
+			return response, nil
+		}
+	}
+}
+
 // FetchWithToken is the same as Fetch, but it retries fetching data for a specific time.
 func FetchWithToken(packet string, token Token, rwd ReadWriteDeadliner, timeout time.Duration) (response []byte, err error) {
+	response, _, err = fetchWithToken(packet, token, rwd, timeout, 0, TimeoutWait{})
+	return
+}
+
+// fetchWithToken is FetchWithToken with every retry interval being decided by the wait strategy
+// and randomized by the jitter fraction. The round-trip time is measured from the last request burst
+// to the response.
+func fetchWithToken(packet string, token Token, rwd ReadWriteDeadliner, timeout time.Duration, jitter float64, wait WaitStrategy) (response []byte, rtt time.Duration, err error) {
 	if timeout < minTimeout {
 		timeout = minTimeout
 	}
 
 	begin := time.Now()
-	timeLeft := timeout
-	currentTimeout := minTimeout
 	writeBurst := 1
 
-	for {
-		timeLeft = timeout - time.Since(begin)
-		rwd.SetReadDeadline(time.Now().Add(currentTimeout))
-
-		if timeLeft <= 0 {
+	for attempt := 0; ; attempt++ {
+		currentTimeout := wait.Wait(attempt, time.Since(begin), timeout)
+		if currentTimeout <= 0 {
 			// early return, because timed out
 			err = ErrTimeout
 			return
 		}
+		sent := time.Now()
+		rwd.SetReadDeadline(sent.Add(jitterDuration(currentTimeout, jitter)))
 
 		// send multiple requests
 		for i := 0; i < writeBurst; i++ {
 			err = Request(packet, token, rwd)
 			if err != nil {
-				return
+				return nil, 0, unreachableError(err)
 			}
 		}
 
 		// wait for response
-		response, err = Receive(packet, rwd)
+		response, err = receiveWithToken(packet, token, rwd)
 		if err == nil {
+			rtt = time.Since(sent)
+			observeRTT(wait, rtt)
 			return
+		} else if isUnreachable(err) {
+			return nil, 0, unreachableError(err)
 		}
 
-		// increase time & request burst
-		timeLeft = timeout - time.Since(begin)
-		if timeLeft <= currentTimeout {
-			currentTimeout = timeLeft
-		} else {
-			currentTimeout *= 2
-		}
+		// increase request burst
 		writeBurst *= 2
 	}
 }
 
+// isUnreachable returns true if the error reports that nothing is listening at the remote address.
+// Depending on the platform, ICMP port unreachable messages are reported as connection refused
+// errors when reading from or writing to a connected UDP socket.
+func isUnreachable(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// isTimeout returns true if err reports that a read deadline or the deadline of a context was exceeded.
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout() || errors.Is(err, context.DeadlineExceeded)
+}
+
+// unreachableError wraps err with ErrServerUnreachable if the server is unreachable.
+func unreachableError(err error) error {
+	if isUnreachable(err) {
+		return fmt.Errorf("%w: %v", ErrServerUnreachable, err)
+	}
+	return err
+}
+
 // MatchResponse matches a respnse to a specific string
 // "", ErrInvalidResponseMessage -> if response message contains invalid data
 // "", ErrInvalidHeaderLength -> if response message is too short
@@ -211,8 +279,16 @@ func MatchResponse(responseMessage []byte) (string, error) {
 
 // Fetch sends the token, retrieves the response and sends the follow up packet request in order to receive the data response.
 func Fetch(packet string, rwd ReadWriteDeadliner, timeout time.Duration) (response []byte, err error) {
+	response, _, err = fetch(packet, rwd, timeout, 0, TimeoutWait{}, GenerateClientToken())
+	return
+}
+
+// fetch is Fetch with every retry interval being decided by the wait strategy and randomized
+// by the jitter fraction. The client token of the handshake is passed by the caller.
+// The returned round-trip time is the one of the packet request, which excludes the token handshake.
+func fetch(packet string, rwd ReadWriteDeadliner, timeout time.Duration, jitter float64, wait WaitStrategy, clientToken int) (response []byte, rtt time.Duration, err error) {
 	begin := time.Now()
-	resp, err := FetchToken(rwd, timeout)
+	resp, err := fetchToken(rwd, timeout, jitter, wait, clientToken)
 	if err != nil {
 		return
 	}
@@ -221,13 +297,7 @@ func Fetch(packet string, rwd ReadWriteDeadliner, timeout time.Duration) (respon
 		return
 	}
 	timeLeft := timeout - time.Since(begin)
-	resp, err = FetchWithToken(packet, token, rwd, timeLeft)
-	if err != nil {
-		return
-	}
-
-	response = resp
-	return
+	return fetchWithToken(packet, token, rwd, timeLeft, jitter, wait)
 }
 
 // ServerInfos is a wrapper for ServerInfosWithTimeouts with prefedined parameters that have been deemed to work
@@ -242,23 +312,22 @@ func ServerInfos() (infos []ServerInfo) {
 func GetServerInfoWithTimeout(ip string, port int, timeout time.Duration) (ServerInfo, error) {
 	info := ServerInfo{}
 
-	ipAddr := net.ParseIP(ip)
-
-	if ipAddr == nil {
-		return info, ErrInvalidIP
-	}
-
 	if port < 0 || math.MaxUint16 < port {
 		return info, ErrInvalidPort
 	}
 
+	addr, err := ParseServerAddress(net.JoinHostPort(strings.Trim(ip, "[]"), strconv.Itoa(port)))
+	if err != nil {
+		return info, err
+	}
+
 	if timeout < minTimeout {
 		timeout = minTimeout
 	}
 
 	srv := &net.UDPAddr{
-		IP:   ipAddr,
-		Port: port,
+		IP:   addr.IP,
+		Port: int(addr.Port),
 	}
 
 	conn, err := net.DialUDP("udp", nil, srv)
@@ -291,6 +360,18 @@ func GetServerInfo(ip string, port int) (ServerInfo, error) {
 	return GetServerInfoWithTimeout(ip, port, TimeoutServers)
 }
 
+// GetServerInfoContext fetches the server info of the game server at addr, e.g. "127.0.0.1:8303" or "[::1]:8303".
+// If the port is omitted, DefaultGamePort is used, hostnames are not resolved, see QueryServerInfoHost for those.
+// The query waits at most until the deadline of ctx or the query timeout, which is TimeoutServers by default.
+// If ctx is done before the info has been received, ctx.Err() is returned.
+func GetServerInfoContext(ctx context.Context, addr string, opts ...Option) (ServerInfo, error) {
+	srv, err := ParseServerAddress(addr)
+	if err != nil {
+		return ServerInfo{}, err
+	}
+	return queryServerInfo(ctx, &net.UDPAddr{IP: srv.IP, Port: int(srv.Port)}, newOptions(opts...))
+}
+
 // ServerInfosWithTimeouts retrieves the full serverlist with all of the server's infos from the masterservers as well as the individual servers
 // it is possible to set the masterserver and the per server timeouts manually.
 func ServerInfosWithTimeouts(timeoutMasterServer, timeoutServer time.Duration) (infos []ServerInfo) {
@@ -365,3 +446,207 @@ func fetchServerInfoFromServerAddress(srv *net.UDPAddr, timeout time.Duration, c
 
 	cm.Add(info, 0)
 }
+
+// ListServersWithInfo fetches the server lists of all master servers and queries
+// every listed server for its info concurrently.
+// Servers that do not respond in time are left out of the result, unless WithIncludeOffline is passed,
+// as are master servers that fail to respond. Only if no master server responded at all, an error is returned.
+// If ctx is cancelled, all open sockets are closed and the function waits for every
+// started query to stop. The infos that have been retrieved up to that point are returned
+// alongside with the context's error.
+func ListServersWithInfo(ctx context.Context, opts ...Option) ([]ServerInfo, error) {
+	o := newOptions(opts...)
+
+	servers, err := fetchServerLists(ctx, o)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu    sync.Mutex
+		infos = make([]ServerInfo, 0, len(servers))
+		pool  = newWorkerPool(limitWorkers(o.concurrency, len(servers)))
+	)
+
+	for _, srv := range servers {
+		srv := srv
+		submitted := pool.submit(ctx, func() {
+			info, err := queryServerInfo(ctx, srv, o)
+			if err != nil {
+				if !o.includeOffline || ctx.Err() != nil {
+					return
+				}
+				info = ServerInfo{Address: srv.String(), Err: err}
+			}
+
+			mu.Lock()
+			infos = append(infos, info)
+			mu.Unlock()
+		})
+		if !submitted {
+			break
+		}
+	}
+	pool.wait()
+
+	return infos, ctx.Err()
+}
+
+// filterServers leaves out the servers that are excluded by IPv4Only and IPv6Only.
+func filterServers(servers []ServerAddress, family AddressFamily) []ServerAddress {
+	if family != IPv4Only && family != IPv6Only {
+		return servers
+	}
+
+	filtered := make([]ServerAddress, 0, len(servers))
+	for _, srv := range servers {
+		if (srv.IP.To4() != nil) == (family == IPv4Only) {
+			filtered = append(filtered, srv)
+		}
+	}
+	return filtered
+}
+
+// queryServerInfo fetches the server info of srv with the query options o
+// and notifies the configured metrics.
+func queryServerInfo(ctx context.Context, srv *net.UDPAddr, o options) (ServerInfo, error) {
+	o.metrics.QuerySent()
+
+	resp, rtt, err := fetchContext(ctx, "serverinfo", srv, jitterDuration(o.queryTimeout, o.jitter), o)
+	if err != nil {
+		if errors.Is(err, ErrTimeout) {
+			o.metrics.Timeout()
+		}
+		return ServerInfo{}, err
+	}
+	o.metrics.ResponseReceived(rtt)
+
+	info, err := ParseServerInfo(resp, srv.String())
+	if err != nil {
+		o.metrics.ParseError()
+		return ServerInfo{}, err
+	}
+	info.Ping = rtt
+	return info, nil
+}
+
+// fetchServerLists concurrently fetches the server lists of all configured master servers
+// and returns the list of unique server addresses. Addresses are unique by ip:port, servers that share
+// an IP but listen on different ports are distinct. At most masterConcurrency master servers are
+// queried at the same time, the master server timeout starts once a master server is queried.
+// With WithFastestMaster only the fastest master server is queried, unless it fails.
+// The servers that are excluded by the address family are left out.
+func fetchServerLists(ctx context.Context, o options) (ServerList, error) {
+	o.resolveMasterServers()
+
+	if o.fastestMasterInterval > 0 {
+		servers, err := fetchFastestServerList(ctx, o)
+		if err == nil {
+			return udpAddrs(filterServers(servers, o.addressFamily)), nil
+		} else if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	servers, errs := collectServerLists(ctx, o)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(errs) == len(o.masterServers) {
+		if len(errs) > 0 {
+			return nil, fmt.Errorf("%w: %v", ErrNoServerList, errs[0])
+		}
+		return nil, ErrNoServerList
+	}
+	return udpAddrs(filterServers(servers, o.addressFamily)), nil
+}
+
+// collectServerLists fetches the server lists of all master servers concurrently and merges them
+// into a list without duplicates. The errors of the master servers that failed are returned in the order
+// of the master servers.
+func collectServerLists(ctx context.Context, o options) ([]ServerAddress, MasterServerErrors) {
+	lists := make([][]ServerAddress, len(o.masterServers))
+	errs := make([]error, len(o.masterServers))
+
+	pool := newWorkerPool(limitWorkers(o.masterConcurrency, len(o.masterServers)))
+	for idx, ms := range o.masterServers {
+		idx, ms := idx, ms
+		submitted := pool.submit(ctx, func() {
+			master, err := newMasterServer(ms, o)
+			if err != nil {
+				errs[idx] = err
+				return
+			}
+			defer master.Close()
+
+			ctx, cancel := context.WithTimeout(ctx, o.masterServerTimeout)
+			defer cancel()
+
+			lists[idx], errs[idx] = master.getServerList(ctx, -1)
+		})
+		if !submitted {
+			errs[idx] = ctx.Err()
+		}
+	}
+	pool.wait()
+
+	var failed MasterServerErrors
+	unique := make(map[string]bool, maxServersPerMasterServer*len(lists))
+	servers := make([]ServerAddress, 0, maxServersPerMasterServer*len(lists))
+	for idx, list := range lists {
+		if errs[idx] != nil {
+			failed = append(failed, &MasterServerError{Addr: o.masterServers[idx].String(), Err: errs[idx]})
+			continue
+		}
+
+		for _, srv := range list {
+			key := srv.String()
+			if unique[key] {
+				continue
+			}
+			unique[key] = true
+			servers = append(servers, srv)
+		}
+	}
+	return servers, failed
+}
+
+// fetchContext dials addr and fetches the packet response like Fetch does.
+// The timeout is shortened to the context's deadline and the connection is closed
+// as soon as the context is done. The connection is dialed with the options' dial function
+// and the retry intervals are randomized by the options' jitter fraction.
+// The round-trip time of the packet request is returned along with the response.
+func fetchContext(ctx context.Context, packet string, addr *net.UDPAddr, timeout time.Duration, o options) ([]byte, time.Duration, error) {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < timeout {
+		timeout = time.Until(deadline)
+	}
+
+	conn, err := o.dial(ctx, addr)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer conn.Close()
+
+	// increase the buffer for the request bursts, unless it has been configured with WithSocketBuffers
+	if udp, ok := conn.(*net.UDPConn); ok && o.writeBuffer < 1 {
+		udp.SetWriteBuffer(int(maxBufferSize * timeout.Seconds()))
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			// unblocks any pending read
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	resp, rtt, err := fetch(packet, conn, timeout, o.jitter, o.wait, o.clientToken())
+	if ctx.Err() != nil {
+		return nil, 0, ctx.Err()
+	}
+	return resp, rtt, err
+}
@@ -1,13 +1,18 @@
 package browser
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
+	"reflect"
+	"runtime"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/jxsl13/twapi/internal/mockserver"
 )
 
 type asyncCounter int64
@@ -223,3 +228,456 @@ func TestServerInfo(t *testing.T) {
 		})
 	}
 }
+
+func TestListServersWithInfo(t *testing.T) {
+	infos := []ServerInfo{
+		{Version: "0.7.5", Name: "first", Map: "ctf5", GameType: "CTF", MaxPlayers: 16, MaxClients: 16, Players: []PlayerInfo{{Name: "player"}}},
+		{Version: "0.7.5", Name: "second", Map: "dm1", GameType: "DM", MaxPlayers: 8, MaxClients: 8},
+	}
+
+	servers := make(ServerList, 0, len(infos)+1)
+	for _, info := range infos {
+		gs := newFakeGameServer(t, info)
+		defer gs.Close()
+		servers = append(servers, gs.Addr())
+	}
+
+	// a server that never responds
+	offline := newFakeServer(t)
+	servers = append(servers, offline.Addr())
+	offline.Close()
+
+	master := newFakeMasterServer(t, servers)
+	defer master.Close()
+
+	// the same server list twice, duplicates must be removed
+	secondMaster := newFakeMasterServer(t, servers)
+	defer secondMaster.Close()
+
+	got, err := ListServersWithInfo(context.Background(),
+		WithMasterServers(master.Addr(), secondMaster.Addr()),
+		WithMasterServerTimeout(time.Second),
+		WithQueryTimeout(500*time.Millisecond),
+		WithConcurrency(2),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != len(infos) {
+		t.Fatalf("expected %d infos, got %d: %v", len(infos), len(got), got)
+	}
+
+	for _, info := range got {
+		found := false
+		for _, want := range infos {
+			want.Address = info.Address
+			if info.Equal(want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("unexpected server info: %s", info.String())
+		}
+	}
+}
+
+func TestFetchServerListsMasterConcurrency(t *testing.T) {
+	var active, maxActive int32
+	observe := func(fs *fakeServer, addr *net.UDPAddr) {
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			max := atomic.LoadInt32(&maxActive)
+			if n <= max || atomic.CompareAndSwapInt32(&maxActive, max, n) {
+				break
+			}
+		}
+		// keep the master server busy, so that concurrent queries overlap
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	addrs := make([]*net.UDPAddr, 0, 4)
+	for i := 0; i < 4; i++ {
+		fs := newFakeServer(t)
+		fs.servers = newServerList(i + 1)
+		fs.beforeResponse = observe
+		fs.start()
+		defer fs.Close()
+		addrs = append(addrs, fs.Addr())
+	}
+
+	tests := []struct {
+		name        string
+		concurrency int
+		want        int32
+	}{
+		{"one at a time", 1, 1},
+		{"two at a time", 2, 2},
+		{"default", 0, 4},
+	}
+	for _, tt := range tests {
+		atomic.StoreInt32(&maxActive, 0)
+
+		servers, err := fetchServerLists(context.Background(), newOptions(
+			WithMasterServers(addrs...),
+			WithMasterServerTimeout(time.Second),
+			WithMasterConcurrency(tt.concurrency),
+		))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(servers) != 4 {
+			t.Errorf("%s: fetchServerLists() returned %d servers, want 4", tt.name, len(servers))
+		}
+		if got := atomic.LoadInt32(&maxActive); got != tt.want {
+			t.Errorf("%s: %d master servers were queried at the same time, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestListServersWithInfoNoMasterServer(t *testing.T) {
+	offline := newFakeServer(t)
+	offline.Close()
+
+	_, err := ListServersWithInfo(context.Background(),
+		WithMasterServers(offline.Addr()),
+		WithMasterServerTimeout(100*time.Millisecond),
+	)
+	if !errors.Is(err, ErrNoServerList) {
+		t.Fatalf("expected %v, got %v", ErrNoServerList, err)
+	}
+}
+
+func TestFetchWithTokenSpoofedResponse(t *testing.T) {
+	gs := newFakeServer(t)
+	gs.info = &ServerInfo{Version: "0.7.5", Name: "real server"}
+	gs.beforeResponse = func(fs *fakeServer, addr *net.UDPAddr) {
+		// response that is not addressed to our token
+		spoofed := ServerInfo{Version: "0.7.5", Name: "spoofed server"}
+		data, _ := spoofed.MarshalBinary()
+		prefix := packToken(fs.serverToken, 0x0badc0de)
+		fs.WriteTo(packInfoResponse(prefix, 0, data), addr)
+	}
+	gs.start()
+	defer gs.Close()
+
+	conn, err := net.DialUDP("udp", nil, gs.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	resp, err := Fetch("serverinfo", conn, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := ParseServerInfo(resp, gs.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Name != "real server" {
+		t.Fatalf("expected response of the real server, got: %s", info.String())
+	}
+}
+
+func TestFetchWithTokenOversizedPacket(t *testing.T) {
+	gs := newFakeServer(t)
+	gs.info = &ServerInfo{Version: "0.7.5", Name: "real server"}
+	gs.beforeResponse = func(fs *fakeServer, addr *net.UDPAddr) {
+		fs.WriteTo(make([]byte, 2000), addr)
+	}
+	gs.start()
+	defer gs.Close()
+
+	conn, err := net.DialUDP("udp", nil, gs.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	resp, err := Fetch("serverinfo", conn, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := ParseServerInfo(resp, gs.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Name != "real server" {
+		t.Fatalf("expected response of the real server, got: %s", info.String())
+	}
+
+	// a single oversized packet is rejected with a typed error
+	gs.WriteTo(make([]byte, 2000), conn.LocalAddr().(*net.UDPAddr))
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := Receive("serverinfo", conn); err != ErrPacketTooLarge {
+		t.Errorf("expected %v, got %v", ErrPacketTooLarge, err)
+	}
+}
+
+func TestGetServerInfoUnreachable(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("connection refused errors on UDP sockets are only reliably reported on linux")
+	}
+
+	// nothing is listening at the address after closing the connection
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := conn.LocalAddr().(*net.UDPAddr)
+	conn.Close()
+
+	begin := time.Now()
+	_, err = GetServerInfoWithTimeout(addr.IP.String(), addr.Port, 5*time.Second)
+	if !errors.Is(err, ErrServerUnreachable) {
+		t.Fatalf("expected %v, got %v", ErrServerUnreachable, err)
+	}
+
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Errorf("GetServerInfoWithTimeout() returned after %s, want it to return right away", elapsed)
+	}
+}
+
+func TestGetServerInfoContext(t *testing.T) {
+	want := ServerInfo{Version: "0.7.5", Name: "context", Map: "ctf5", GameType: "CTF", ServerFlags: ServerFlagPassword, NumPlayers: 1, MaxPlayers: 8, NumClients: 1, MaxClients: 8, Players: []PlayerInfo{{Name: "player"}}}
+	gs := newFakeGameServer(t, want)
+	defer gs.Close()
+
+	info, err := GetServerInfoContext(context.Background(), gs.Addr().String(), WithQueryTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want.Address = gs.Addr().String()
+	if !info.Equal(want) {
+		t.Errorf("GetServerInfoContext() = %v, want %v", info, want)
+	}
+
+	// answers token requests, but never sends its info
+	silent := newFakeServer(t)
+	silent.start()
+	defer silent.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	begin := time.Now()
+	if _, err := GetServerInfoContext(ctx, silent.Addr().String()); err != context.Canceled {
+		t.Errorf("GetServerInfoContext() error = %v, want %v", err, context.Canceled)
+	}
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Errorf("GetServerInfoContext() returned after %s, want it to return right after cancellation", elapsed)
+	}
+
+	if _, err := GetServerInfoContext(context.Background(), "example.com:8303"); !errors.Is(err, ErrInvalidIP) {
+		t.Errorf("expected %v, got %v", ErrInvalidIP, err)
+	}
+}
+
+func TestGetServerInfoPing(t *testing.T) {
+	gs := newFakeGameServer(t, ServerInfo{Version: "0.7.5", Name: "ping"})
+	defer gs.Close()
+
+	// the first info request is dropped, which is why the info is received after a retry
+	gs.QueueResponse(mockserver.RequestInfo)
+	retryInterval := 200 * time.Millisecond
+
+	begin := time.Now()
+	info, err := GetServerInfoContext(context.Background(), gs.Addr().String(),
+		WithQueryTimeout(2*time.Second), WithWaitStrategy(ExponentialWait{Initial: retryInterval}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	elapsed := time.Since(begin)
+
+	if elapsed < retryInterval {
+		t.Fatalf("GetServerInfoContext() returned after %s, want it to retry after %s", elapsed, retryInterval)
+	}
+	if info.Ping <= 0 || info.Ping >= retryInterval {
+		t.Errorf("Ping = %s, want the round-trip time of the retried request below %s", info.Ping, retryInterval)
+	}
+}
+
+func TestListServersWithInfoCancel(t *testing.T) {
+	goroutines := runtime.NumGoroutine()
+
+	// servers that never respond
+	servers := make(ServerList, 0, 20)
+	for i := 0; i < cap(servers); i++ {
+		silent := newFakeServer(t)
+		defer silent.Close()
+		servers = append(servers, silent.Addr())
+	}
+
+	master := newFakeMasterServer(t, servers)
+	defer master.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	type result struct {
+		infos []ServerInfo
+		err   error
+	}
+	done := make(chan result)
+	go func() {
+		infos, err := ListServersWithInfo(ctx, WithMasterServers(master.Addr()), WithQueryTimeout(time.Minute))
+		done <- result{infos, err}
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+
+	select {
+	case r := <-done:
+		if !errors.Is(r.err, context.Canceled) {
+			t.Fatalf("expected %v, got %v", context.Canceled, r.err)
+		}
+		if len(r.infos) != 0 {
+			t.Fatalf("expected no infos, got %d", len(r.infos))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ListServersWithInfo did not return after the context was cancelled")
+	}
+
+	// every started goroutine must have exited, the fake master server accounts for one goroutine.
+	for i := 0; i < 100; i++ {
+		if runtime.NumGoroutine() <= goroutines+1 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("goroutines leaked: before %d after %d", goroutines, runtime.NumGoroutine())
+}
+
+func TestFetchTokenSpoofedResponse(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	go func() {
+		buf := make([]byte, maxBufferSize)
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err != nil || n < tokenResponseSize {
+			return
+		}
+		// a response with a guessed client token arrives first
+		conn.WriteToUDP(packTokenResponse(0x0badc0de, 0x11111111), addr)
+		conn.WriteToUDP(packTokenResponse(unpackInt(buf[8:12]), 0x12345678), addr)
+	}()
+
+	client, err := net.DialUDP("udp", nil, conn.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	resp, err := fetchToken(client, time.Second, 0, TimeoutWait{}, 0x0c0ffee0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := ParseToken(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.client != 0x0c0ffee0 || token.server != 0x12345678 {
+		t.Errorf("expected the token of the real response, got client %#x and server %#x", token.client, token.server)
+	}
+}
+
+func TestFetchServerListsSameIP(t *testing.T) {
+	ip := net.IPv4(10, 0, 0, 1).To4()
+
+	master1 := newFakeServer(t)
+	master1.servers = ServerList{
+		{IP: ip, Port: 8303},
+		{IP: ip, Port: 8304},
+	}
+	master1.start()
+	defer master1.Close()
+
+	// the same servers are listed again, one of them in 16 bytes, and a third port is added
+	master2 := newFakeServer(t)
+	master2.servers = ServerList{
+		{IP: ip, Port: 8303},
+		{IP: net.IPv4(10, 0, 0, 1), Port: 8304},
+		{IP: ip, Port: 8305},
+	}
+	master2.start()
+	defer master2.Close()
+
+	servers, err := fetchServerLists(context.Background(), newOptions(
+		WithMasterServers(master1.Addr(), master2.Addr()),
+		WithMasterServerTimeout(time.Second),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]int, len(servers))
+	for _, srv := range servers {
+		got[srv.String()]++
+	}
+	want := map[string]int{"10.0.0.1:8303": 1, "10.0.0.1:8304": 1, "10.0.0.1:8305": 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fetchServerLists() = %v, want %v", got, want)
+	}
+}
+
+func TestListServersWithInfoIncludeOffline(t *testing.T) {
+	gs := newFakeGameServer(t, ServerInfo{Version: "0.7.5", Name: "online"})
+	defer gs.Close()
+
+	// a server that sends its token, but never its info
+	silent := newFakeServer(t)
+	silent.start()
+	defer silent.Close()
+
+	master := newFakeMasterServer(t, ServerList{gs.Addr(), silent.Addr()})
+	defer master.Close()
+
+	for _, include := range []bool{false, true} {
+		got, err := ListServersWithInfo(context.Background(),
+			WithMasterServers(master.Addr()),
+			WithMasterServerTimeout(time.Second),
+			WithQueryTimeout(300*time.Millisecond),
+			WithIncludeOffline(include),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var online, offline []ServerInfo
+		for _, info := range got {
+			if info.Online {
+				online = append(online, info)
+			} else {
+				offline = append(offline, info)
+			}
+		}
+
+		if len(online) != 1 || online[0].Name != "online" || online[0].Err != nil {
+			t.Errorf("include %v: expected the online server, got %v", include, online)
+		}
+		if !include {
+			if len(offline) != 0 {
+				t.Errorf("expected no offline servers, got %v", offline)
+			}
+			continue
+		}
+		if len(offline) != 1 {
+			t.Fatalf("expected one offline server, got %v", offline)
+		}
+		if offline[0].Address != silent.Addr().String() || !errors.Is(offline[0].Err, ErrTimeout) {
+			t.Errorf("expected an offline record of %s with a timeout, got %s: %v", silent.Addr(), offline[0].Address, offline[0].Err)
+		}
+	}
+}
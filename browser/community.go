@@ -0,0 +1,88 @@
+package browser
+
+import (
+	"strings"
+	"sync"
+)
+
+// the communities that are recognized by the built-in rules
+const (
+	CommunityDDNet     = "DDNet"
+	CommunityGores     = "Gores"
+	CommunityFNG       = "FNG"
+	CommunityInfection = "Infection"
+	CommunityRace      = "Race"
+	CommunityVanilla   = "Vanilla"
+)
+
+// CommunityRule classifies a server. It returns the community of the server and true
+// if the rule applies to the server, otherwise false.
+type CommunityRule func(si *ServerInfo) (community string, ok bool)
+
+var (
+	communityMu    sync.RWMutex
+	communityRules []CommunityRule
+
+	// the order matters, e.g. gores servers usually run the DDNet modification.
+	builtinCommunityRules = []CommunityRule{
+		gameTypeRule(CommunityGores, "gores"),
+		gameTypeRule(CommunityFNG, "fng"),
+		gameTypeRule(CommunityInfection, "infclass", "infection", "zcatch"),
+		gameTypeRule(CommunityDDNet, "ddrace", "ddnet", "block"),
+		gameTypeRule(CommunityRace, "race"),
+		vanillaRule,
+	}
+)
+
+// RegisterCommunityRule adds a custom rule that is applied by ServerInfo.Community.
+// Custom rules are applied in the order of their registration before the built-in rules.
+// It is safe to register rules concurrently.
+func RegisterCommunityRule(rule CommunityRule) {
+	communityMu.Lock()
+	defer communityMu.Unlock()
+	communityRules = append(communityRules, rule)
+}
+
+// Community classifies the server based on its name, map and gametype, e.g. in order to group the servers
+// by their community. The result of the first matching custom rule that was registered with RegisterCommunityRule
+// is returned, otherwise the result of the first matching built-in rule.
+// An empty string is returned if no rule applies.
+func (s *ServerInfo) Community() string {
+	communityMu.RLock()
+	rules := communityRules
+	communityMu.RUnlock()
+
+	for _, rules := range [][]CommunityRule{rules, builtinCommunityRules} {
+		for _, rule := range rules {
+			if community, ok := rule(s); ok {
+				return community
+			}
+		}
+	}
+	return ""
+}
+
+// gameTypeRule returns a rule that matches servers whose gametype contains one of the keywords.
+// The map and the server name are checked as well, because modifications are often run with a vanilla gametype.
+func gameTypeRule(community string, keywords ...string) CommunityRule {
+	return func(si *ServerInfo) (string, bool) {
+		gameType := strings.ToLower(si.GameType)
+		mapName := strings.ToLower(si.Map)
+		name := strings.ToLower(si.Name)
+		for _, keyword := range keywords {
+			if strings.Contains(gameType, keyword) || strings.HasPrefix(mapName, keyword) || strings.Contains(name, keyword) {
+				return community, true
+			}
+		}
+		return "", false
+	}
+}
+
+// vanillaRule matches the gametypes of the unmodified game.
+func vanillaRule(si *ServerInfo) (string, bool) {
+	switch strings.ToLower(si.GameType) {
+	case "dm", "tdm", "ctf", "lms", "lts":
+		return CommunityVanilla, true
+	}
+	return "", false
+}
@@ -0,0 +1,53 @@
+package browser
+
+import "testing"
+
+func TestServerInfo_Community(t *testing.T) {
+	tests := []struct {
+		name string
+		info ServerInfo
+		want string
+	}{
+		{"ddnet", ServerInfo{Name: "DDNet GER10 - Novice", Map: "Multeasymap", GameType: "DDraceNetwork"}, CommunityDDNet},
+		{"block", ServerInfo{Name: "ChillerDragon's Block [1]", Map: "BlmapChill", GameType: "Block"}, CommunityDDNet},
+		{"gores", ServerInfo{Name: "KoG | Gores #1", Map: "Pulse", GameType: "Gores"}, CommunityGores},
+		{"gores map", ServerInfo{Name: "Unique | hard maps", Map: "gores_fun", GameType: "DDraceNetwork"}, CommunityGores},
+		{"fng", ServerInfo{Name: "FNG² | Berlin", Map: "AliveFNG", GameType: "fng2"}, CommunityFNG},
+		{"fng in name", ServerInfo{Name: "[openFNG] Frankfurt", Map: "FNG_Anaconda", GameType: "CTF"}, CommunityFNG},
+		{"infection", ServerInfo{Name: "InfClass | Europe", Map: "infc_skull", GameType: "InfClass"}, CommunityInfection},
+		{"race", ServerInfo{Name: "Race | Fast maps", Map: "run_ice", GameType: "Race"}, CommunityRace},
+		{"vanilla", ServerInfo{Name: "Teeworlds Official CTF", Map: "ctf5", GameType: "CTF"}, CommunityVanilla},
+		{"vanilla dm", ServerInfo{Name: "My first server", Map: "dm1", GameType: "DM"}, CommunityVanilla},
+		{"unknown", ServerInfo{Name: "Some mod", Map: "custom", GameType: "iCTF+"}, ""},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.info.Community(); got != tt.want {
+				t.Errorf("ServerInfo.Community() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterCommunityRule(t *testing.T) {
+	defer func() {
+		communityMu.Lock()
+		communityRules = nil
+		communityMu.Unlock()
+	}()
+
+	RegisterCommunityRule(func(si *ServerInfo) (string, bool) {
+		return "Unique", si.Name == "Unique | hard maps"
+	})
+
+	info := ServerInfo{Name: "Unique | hard maps", Map: "gores_fun", GameType: "DDraceNetwork"}
+	if got := info.Community(); got != "Unique" {
+		t.Errorf("ServerInfo.Community() = %q, want %q", got, "Unique")
+	}
+
+	info.Name, info.Map = "DDNet GER10 - Novice", "Multeasymap"
+	if got := info.Community(); got != CommunityDDNet {
+		t.Errorf("ServerInfo.Community() = %q, want %q", got, CommunityDDNet)
+	}
+}
@@ -0,0 +1,87 @@
+package browser
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// contextConn bounds the deadlines of a connection by the deadline of a context
+// and interrupts pending reads as soon as the context is done.
+// This allows to use the Fetch functions with a context without closing the connection.
+type contextConn struct {
+	ReadWriteDeadliner
+	ctx context.Context
+
+	mu   sync.Mutex
+	done bool
+	stop chan struct{}
+}
+
+// withContext wraps rwd, the returned function must be called in order to release the context.
+func withContext(ctx context.Context, rwd ReadWriteDeadliner) (*contextConn, func()) {
+	c := &contextConn{
+		ReadWriteDeadliner: rwd,
+		ctx:                ctx,
+		stop:               make(chan struct{}),
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.mu.Lock()
+			c.done = true
+			// unblocks any pending read
+			c.ReadWriteDeadliner.SetReadDeadline(time.Unix(1, 0))
+			c.mu.Unlock()
+		case <-c.stop:
+		}
+	}()
+
+	return c, func() {
+		close(c.stop)
+	}
+}
+
+// SetReadDeadline sets the read deadline, but never later than the context's deadline.
+func (c *contextConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.done {
+		t = time.Unix(1, 0)
+	} else if deadline, ok := c.ctx.Deadline(); ok && deadline.Before(t) {
+		t = deadline
+	}
+	return c.ReadWriteDeadliner.SetReadDeadline(t)
+}
+
+// SetDeadline sets the read and write deadlines, but never later than the context's deadline.
+func (c *contextConn) SetDeadline(t time.Time) error {
+	err := c.SetReadDeadline(t)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := c.ctx.Deadline(); ok && deadline.Before(t) {
+		t = deadline
+	}
+	return c.ReadWriteDeadliner.SetWriteDeadline(t)
+}
+
+// Read returns the context's error if the read failed due to the context being done.
+func (c *contextConn) Read(b []byte) (int, error) {
+	n, err := c.ReadWriteDeadliner.Read(b)
+	if err != nil && c.ctx.Err() != nil {
+		return n, c.ctx.Err()
+	}
+	return n, err
+}
+
+// Write fails with the context's error once the context is done, which stops
+// any retry loop that sends requests.
+func (c *contextConn) Write(b []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.ReadWriteDeadliner.Write(b)
+}
@@ -0,0 +1,277 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// crawlerCheckpointInterval is the number of queried servers after which the progress is saved.
+const crawlerCheckpointInterval = 64
+
+// Checkpoint is the progress of a crawl.
+type Checkpoint struct {
+	// Pending contains the ip:port addresses of the servers that have not been queried yet.
+	Pending []string `json:"pending"`
+}
+
+// CheckpointStore persists the progress of a Crawler, so that an interrupted crawl can be resumed.
+type CheckpointStore interface {
+	// Save replaces the previously saved checkpoint.
+	Save(cp Checkpoint) error
+
+	// Load returns the last saved checkpoint. ok is false if there is none.
+	Load() (cp Checkpoint, ok bool, err error)
+}
+
+// FileCheckpointStore saves checkpoints as JSON file.
+type FileCheckpointStore struct {
+	Path string
+}
+
+// Save writes the checkpoint to a temporary file that replaces the previous checkpoint file,
+// which is why a crash while saving does not corrupt the previous checkpoint.
+func (fs *FileCheckpointStore) Save(cp Checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(fs.Path), filepath.Base(fs.Path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), fs.Path)
+}
+
+// Load reads the checkpoint file, a missing file is not an error.
+func (fs *FileCheckpointStore) Load() (cp Checkpoint, ok bool, err error) {
+	data, err := ioutil.ReadFile(fs.Path)
+	if os.IsNotExist(err) {
+		return Checkpoint{}, false, nil
+	} else if err != nil {
+		return Checkpoint{}, false, err
+	}
+
+	err = json.Unmarshal(data, &cp)
+	if err != nil {
+		return Checkpoint{}, false, err
+	}
+	return cp, true, nil
+}
+
+// Crawler queries every server of the whole network for its server info.
+// The progress is saved regularly to its CheckpointStore, which allows to resume
+// an interrupted crawl without querying the already queried servers again.
+// The queries are throttled with WithConcurrency and WithRateLimit, WithRecrawlInterval
+// reuses the infos of servers that have been crawled recently.
+type Crawler struct {
+	store CheckpointStore
+	opts  options
+	cache ConcurrentMap
+}
+
+// NewCrawler creates a crawler that saves its progress to store.
+// The options are the same that ListServersWithInfo accepts.
+func NewCrawler(store CheckpointStore, opts ...Option) *Crawler {
+	return &Crawler{
+		store: store,
+		opts:  newOptions(opts...),
+		cache: NewConcurrentMap(0),
+	}
+}
+
+// Run starts a crawl and returns a channel that receives the info of every server that responded.
+// If the store contains an unfinished crawl, that crawl is resumed, otherwise the server lists are fetched
+// from the master servers. An error is returned if neither is possible.
+// The channel is closed once every server has been queried or ctx is done. In both cases the progress is saved,
+// a completed crawl is saved as a checkpoint without pending servers.
+func (c *Crawler) Run(ctx context.Context) (<-chan ServerInfo, error) {
+	cp, ok, err := c.store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	pending := cp.Pending
+	if !ok || len(pending) == 0 {
+		servers, err := fetchServerLists(ctx, c.opts)
+		if err != nil {
+			return nil, err
+		}
+
+		pending = make([]string, 0, len(servers))
+		for _, srv := range servers {
+			pending = append(pending, srv.String())
+		}
+
+		err = c.store.Save(Checkpoint{Pending: pending})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	infos := make(chan ServerInfo)
+	go c.crawl(ctx, pending, infos)
+	return infos, nil
+}
+
+// crawl queries all pending servers and sends their infos to the infos channel.
+func (c *Crawler) crawl(ctx context.Context, pending []string, infos chan<- ServerInfo) {
+	defer close(infos)
+
+	var (
+		mu   sync.Mutex
+		done = make(map[string]bool, len(pending))
+		pool = newWorkerPool(limitWorkers(c.opts.concurrency, len(pending)))
+		rate = newRateLimiter(c.opts.rateLimit)
+	)
+
+	// must be called with the lock being held
+	save := func() {
+		remaining := make([]string, 0, len(pending)-len(done))
+		for _, addr := range pending {
+			if !done[addr] {
+				remaining = append(remaining, addr)
+			}
+		}
+		// a failed save is retried with the next checkpoint
+		c.store.Save(Checkpoint{Pending: remaining})
+	}
+
+	for _, addr := range pending {
+		addr := addr
+		info, cached := c.cached(addr)
+		if !cached && !rate.wait(ctx) {
+			break
+		}
+
+		submitted := pool.submit(ctx, func() {
+			if cached {
+				if !sendServerInfo(ctx, info, infos) {
+					return
+				}
+			} else if !c.query(ctx, addr, infos) {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			done[addr] = true
+			if len(done)%crawlerCheckpointInterval == 0 {
+				save()
+			}
+		})
+		if !submitted {
+			break
+		}
+	}
+	pool.wait()
+
+	mu.Lock()
+	save()
+	mu.Unlock()
+}
+
+// cached returns the info of addr, if it has been received within the recrawl interval.
+func (c *Crawler) cached(addr string) (ServerInfo, bool) {
+	if c.opts.recrawlInterval <= 0 {
+		return ServerInfo{}, false
+	}
+
+	c.cache.RLock()
+	esi, ok := c.cache.Map[addr]
+	c.cache.RUnlock()
+
+	if !ok || esi.Expired() {
+		return ServerInfo{}, false
+	}
+	return esi.ServerInfo, true
+}
+
+// query fetches the server info of addr and sends it to infos.
+// It returns false if the query was interrupted and needs to be repeated when resuming.
+// Servers that do not respond are not queried again, they are only sent to infos with WithIncludeOffline.
+func (c *Crawler) query(ctx context.Context, addr string, infos chan<- ServerInfo) bool {
+	srv, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return true
+	}
+
+	info, err := queryServerInfo(ctx, srv, c.opts)
+	if ctx.Err() != nil {
+		return false
+	} else if err != nil {
+		if !c.opts.includeOffline {
+			return true
+		}
+		info = ServerInfo{Address: addr, Err: err}
+	} else if c.opts.recrawlInterval > 0 {
+		c.cache.Add(info, c.opts.recrawlInterval)
+	}
+
+	return sendServerInfo(ctx, info, infos)
+}
+
+// sendServerInfo sends info to infos and returns false if ctx is done before.
+func sendServerInfo(ctx context.Context, info ServerInfo, infos chan<- ServerInfo) bool {
+	select {
+	case infos <- info:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// rateLimiter spaces out events evenly, so that at most qps events happen per second.
+type rateLimiter struct {
+	interval time.Duration
+	next     time.Time
+}
+
+// newRateLimiter creates a limiter that allows qps events per second.
+// A nil limiter is returned for values of 0 and below, which does not limit at all.
+func newRateLimiter(qps float64) *rateLimiter {
+	if qps <= 0 {
+		return nil
+	}
+	return &rateLimiter{
+		interval: time.Duration(float64(time.Second) / qps),
+	}
+}
+
+// wait blocks until the next event is allowed. It returns false if ctx is done before.
+// The first event is allowed immediately.
+func (r *rateLimiter) wait(ctx context.Context) bool {
+	if r == nil {
+		return ctx.Err() == nil
+	}
+
+	now := time.Now()
+	if d := r.next.Sub(now); d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return false
+		}
+		now = r.next
+	}
+	r.next = now.Add(r.interval)
+	return true
+}
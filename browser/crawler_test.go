@@ -0,0 +1,276 @@
+package browser
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestCheckpointStore(t *testing.T) (*FileCheckpointStore, func()) {
+	dir, err := ioutil.TempDir("", "crawler")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &FileCheckpointStore{Path: filepath.Join(dir, "checkpoint.json")}, func() {
+		os.RemoveAll(dir)
+	}
+}
+
+func TestFileCheckpointStore(t *testing.T) {
+	store, cleanup := newTestCheckpointStore(t)
+	defer cleanup()
+
+	_, ok, err := store.Load()
+	if err != nil || ok {
+		t.Fatalf("FileCheckpointStore.Load() without checkpoint = %v, %v, want false, <nil>", ok, err)
+	}
+
+	want := Checkpoint{Pending: []string{"127.0.0.1:8303", "[::1]:8304"}}
+	if err := store.Save(want); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(want); err != nil {
+		t.Fatal(err)
+	}
+
+	got, ok, err := store.Load()
+	if err != nil || !ok {
+		t.Fatalf("FileCheckpointStore.Load() = %v, %v, want true, <nil>", ok, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FileCheckpointStore.Load() = %v, want %v", got, want)
+	}
+
+	// no temporary files are left behind
+	files, err := ioutil.ReadDir(filepath.Dir(store.Path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("expected a single checkpoint file, got %d files", len(files))
+	}
+}
+
+func TestCrawler_Run(t *testing.T) {
+	names := []string{"server1", "server2", "server3"}
+	servers := make(ServerList, 0, len(names))
+	for _, name := range names {
+		gs := newFakeGameServer(t, ServerInfo{Version: "0.7.5", Name: name})
+		defer gs.Close()
+		servers = append(servers, gs.Addr())
+	}
+
+	ms := newFakeMasterServer(t, servers)
+	defer ms.Close()
+
+	store, cleanup := newTestCheckpointStore(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	crawler := NewCrawler(store, WithMasterServers(ms.Addr()), WithQueryTimeout(time.Second))
+	infos, err := crawler.Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make([]string, 0, len(names))
+	for info := range infos {
+		got = append(got, info.Name)
+	}
+	sort.Strings(got)
+
+	if !reflect.DeepEqual(got, names) {
+		t.Errorf("Crawler.Run() = %v, want %v", got, names)
+	}
+
+	cp, ok, err := store.Load()
+	if err != nil || !ok || len(cp.Pending) != 0 {
+		t.Errorf("expected a completed checkpoint, got %v, %v, %v", cp, ok, err)
+	}
+}
+
+func TestCrawler_Resume(t *testing.T) {
+	names := []string{"server1", "server2"}
+	pending := make([]string, 0, len(names)+2)
+	for _, name := range names {
+		gs := newFakeGameServer(t, ServerInfo{Version: "0.7.5", Name: name})
+		defer gs.Close()
+		pending = append(pending, gs.Addr().String())
+	}
+
+	// servers that never respond
+	silent := make([]string, 0, 2)
+	for i := 0; i < cap(silent); i++ {
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		silent = append(silent, conn.LocalAddr().String())
+	}
+	pending = append(pending, silent...)
+
+	store, cleanup := newTestCheckpointStore(t)
+	defer cleanup()
+
+	if err := store.Save(Checkpoint{Pending: pending}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the master servers must not be asked, as there is an unfinished crawl
+	crawler := NewCrawler(store, WithMasterServers(), WithQueryTimeout(10*time.Second))
+	infos, err := crawler.Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make([]string, 0, len(names))
+	for info := range infos {
+		got = append(got, info.Name)
+		if len(got) == len(names) {
+			// interrupt the crawl while the silent servers are being queried
+			cancel()
+		}
+	}
+	sort.Strings(got)
+
+	if !reflect.DeepEqual(got, names) {
+		t.Errorf("Crawler.Run() = %v, want %v", got, names)
+	}
+
+	cp, ok, err := store.Load()
+	if err != nil || !ok {
+		t.Fatalf("FileCheckpointStore.Load() = %v, %v", ok, err)
+	}
+	if !reflect.DeepEqual(cp.Pending, silent) {
+		t.Errorf("Checkpoint.Pending = %v, want %v", cp.Pending, silent)
+	}
+}
+
+// crawlNames runs a crawl and returns the sorted names of the received infos.
+func crawlNames(t *testing.T, crawler *Crawler) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	infos, err := crawler.Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make([]string, 0)
+	for info := range infos {
+		names = append(names, info.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestCrawler_RateLimit(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		times []time.Time
+	)
+	names := []string{"server1", "server2", "server3", "server4"}
+	servers := make(ServerList, 0, len(names))
+	for _, name := range names {
+		gs := newFakeServer(t)
+		gs.info = &ServerInfo{Version: "0.7.5", Name: name}
+		gs.beforeResponse = func(*fakeServer, *net.UDPAddr) {
+			mu.Lock()
+			times = append(times, time.Now())
+			mu.Unlock()
+		}
+		gs.start()
+		defer gs.Close()
+		servers = append(servers, gs.Addr())
+	}
+
+	ms := newFakeMasterServer(t, servers)
+	defer ms.Close()
+
+	store, cleanup := newTestCheckpointStore(t)
+	defer cleanup()
+
+	const qps = 20
+	crawler := NewCrawler(store, WithMasterServers(ms.Addr()), WithQueryTimeout(time.Second), WithRateLimit(qps))
+	got := crawlNames(t, crawler)
+	if !reflect.DeepEqual(got, names) {
+		t.Fatalf("Crawler.Run() = %v, want %v", got, names)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	sort.Slice(times, func(i, j int) bool {
+		return times[i].Before(times[j])
+	})
+
+	// the first query is sent right away, every further one waits for its interval
+	want := time.Duration(len(names)-1) * time.Second / qps
+	if elapsed := times[len(times)-1].Sub(times[0]); elapsed < want {
+		t.Errorf("queried %d servers within %v, want at least %v", len(names), elapsed, want)
+	}
+}
+
+func TestCrawler_RecrawlInterval(t *testing.T) {
+	var queries int32
+	names := []string{"server1", "server2"}
+	servers := make(ServerList, 0, len(names))
+	for _, name := range names {
+		gs := newFakeServer(t)
+		gs.info = &ServerInfo{Version: "0.7.5", Name: name}
+		gs.beforeResponse = func(*fakeServer, *net.UDPAddr) {
+			atomic.AddInt32(&queries, 1)
+		}
+		gs.start()
+		defer gs.Close()
+		servers = append(servers, gs.Addr())
+	}
+
+	ms := newFakeMasterServer(t, servers)
+	defer ms.Close()
+
+	store, cleanup := newTestCheckpointStore(t)
+	defer cleanup()
+
+	crawler := NewCrawler(store, WithMasterServers(ms.Addr()), WithQueryTimeout(time.Second), WithRecrawlInterval(time.Hour))
+	if got := crawlNames(t, crawler); !reflect.DeepEqual(got, names) {
+		t.Fatalf("first Crawler.Run() = %v, want %v", got, names)
+	}
+	queried := atomic.LoadInt32(&queries)
+	if queried == 0 {
+		t.Fatal("no server has been queried")
+	}
+
+	// the second crawl sends the cached infos without querying the servers again
+	if got := crawlNames(t, crawler); !reflect.DeepEqual(got, names) {
+		t.Errorf("second Crawler.Run() = %v, want %v", got, names)
+	}
+	if n := atomic.LoadInt32(&queries); n != queried {
+		t.Errorf("recently crawled servers have been queried %d more times", n-queried)
+	}
+
+	// expired infos are queried again
+	for addr, esi := range crawler.cache.Map {
+		esi.ExpiresAt = time.Now().Add(-time.Second)
+		crawler.cache.Map[addr] = esi
+	}
+	if got := crawlNames(t, crawler); !reflect.DeepEqual(got, names) {
+		t.Errorf("third Crawler.Run() = %v, want %v", got, names)
+	}
+	if n := atomic.LoadInt32(&queries); n == queried {
+		t.Error("servers with expired infos have not been queried again")
+	}
+}
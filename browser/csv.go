@@ -0,0 +1,47 @@
+package browser
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+// csvHeader is the column set of ExportCSV, new columns are only ever appended.
+var csvHeader = []string{"address", "name", "map", "gametype", "players", "max_players", "ping_ms"}
+
+// ExportCSV writes a header row and one row per server to w, e.g. in order to analyze
+// the results of ListServersWithInfo in a spreadsheet.
+// The players columns count all clients including spectators, the ping is rounded to milliseconds
+// and is empty if the info was not queried by this package.
+// Fields that contain commas, quotes or line breaks are quoted.
+func ExportCSV(infos []ServerInfo, w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	err := cw.Write(csvHeader)
+	if err != nil {
+		return err
+	}
+
+	record := make([]string, len(csvHeader))
+	for _, info := range infos {
+		record[0] = info.Address
+		record[1] = info.Name
+		record[2] = info.Map
+		record[3] = info.GameType
+		record[4] = strconv.Itoa(info.NumClients)
+		record[5] = strconv.Itoa(info.MaxClients)
+		record[6] = ""
+		if info.Ping > 0 {
+			record[6] = strconv.FormatInt(int64((info.Ping+time.Millisecond/2)/time.Millisecond), 10)
+		}
+
+		err = cw.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,40 @@
+package browser
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestExportCSV(t *testing.T) {
+	infos := []ServerInfo{
+		{Address: "127.0.0.1:8303", Name: "plain", Map: "ctf5", GameType: "CTF", NumClients: 3, MaxClients: 16, Ping: 42400 * time.Microsecond},
+		{Address: "[::1]:8304", Name: `"quoted", with comma`, Map: "dm1", GameType: "DM", MaxClients: 8},
+	}
+
+	var buf bytes.Buffer
+	err := ExportCSV(infos, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "address,name,map,gametype,players,max_players,ping_ms\n" +
+		"127.0.0.1:8303,plain,ctf5,CTF,3,16,42\n" +
+		"[::1]:8304,\"\"\"quoted\"\", with comma\",dm1,DM,0,8,\n"
+	if got := buf.String(); got != want {
+		t.Errorf("ExportCSV() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestExportCSVWriteError(t *testing.T) {
+	if err := ExportCSV([]ServerInfo{{Name: "name"}}, failingWriter{}); err == nil {
+		t.Error("expected the write error to be returned")
+	}
+}
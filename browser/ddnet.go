@@ -0,0 +1,445 @@
+package browser
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	// ddnetSendInfoExtended is the header of the extended server info of DDNet servers,
+	// which is sent instead of the 0.6 server info if the request asks for it.
+	ddnetSendInfoExtended = "\xff\xff\xff\xffiext"
+
+	// ddnetSendInfoExtendedMore is the header of the further packets of an extended server info,
+	// which contain the players that do not fit into the first packet.
+	ddnetSendInfoExtendedMore = "\xff\xff\xff\xffiex+"
+
+	// ddnetHeaderExtended replaces the first two bytes of the 0.6 connectionless header of requests,
+	// the header's remaining bytes carry the extra token.
+	ddnetHeaderExtended = "xe"
+
+	// ddnetTokenMask limits the token of extended requests to the basic token of one byte and the extra token of two bytes.
+	ddnetTokenMask = 0xffffff
+)
+
+var (
+	ddnetSendInfoExtendedRaw     = []byte(ddnetSendInfoExtended)
+	ddnetSendInfoExtendedMoreRaw = []byte(ddnetSendInfoExtendedMore)
+)
+
+// ScoreKind tells how the scores of a server's players are to be interpreted.
+type ScoreKind int
+
+const (
+	// ScoreKindUnspecified is used by servers that do not report the kind of their scores.
+	ScoreKindUnspecified ScoreKind = iota
+	// ScoreKindPoints is used by servers whose scores are points, e.g. kills.
+	ScoreKindPoints
+	// ScoreKindTime is used by servers whose scores are finish times in seconds, e.g. race servers.
+	ScoreKindTime
+)
+
+// String returns "points", "time" or an empty string if the score kind is unspecified.
+func (k ScoreKind) String() string {
+	switch k {
+	case ScoreKindPoints:
+		return "points"
+	case ScoreKindTime:
+		return "time"
+	default:
+		return ""
+	}
+}
+
+// ddnetInfo is the JSON server info of DDNet servers, as it is registered at and listed by
+// the DDNet HTTP master servers.
+type ddnetInfo struct {
+	MaxClients int    `json:"max_clients"`
+	MaxPlayers int    `json:"max_players"`
+	Passworded bool   `json:"passworded"`
+	GameType   string `json:"game_type"`
+	Name       string `json:"name"`
+	Map        struct {
+		Name   string `json:"name"`
+		SHA256 string `json:"sha256"`
+		Size   int    `json:"size"`
+	} `json:"map"`
+	Version         string `json:"version"`
+	ClientScoreKind string `json:"client_score_kind"`
+	Community       string `json:"community"`
+	Clients         []struct {
+		Name     string `json:"name"`
+		Clan     string `json:"clan"`
+		Country  int    `json:"country"`
+		Score    int    `json:"score"`
+		IsPlayer bool   `json:"is_player"`
+	} `json:"clients"`
+}
+
+// ParseDDNetServerInfo parses the JSON server info of a DDNet server, which contains the fields of
+// DDNet's protocol extensions, e.g. the map's SHA256 checksum and the kind of the scores.
+// Unlike the UDP responses, the JSON info does not contain the number of players that are in the game,
+// which is why NumPlayers is the number of listed players whose is_player field is true.
+func ParseDDNetServerInfo(data []byte, address string) (info ServerInfo, err error) {
+	var ddnet ddnetInfo
+	err = json.Unmarshal(data, &ddnet)
+	if err != nil {
+		return ServerInfo{}, fmt.Errorf("%w : %v", ErrMalformedResponseData, err)
+	}
+
+	info = ServerInfo{
+		Address:     address,
+		Version:     ddnet.Version,
+		Name:        ddnet.Name,
+		Map:         ddnet.Map.Name,
+		MapSHA256:   ddnet.Map.SHA256,
+		MapSize:     ddnet.Map.Size,
+		GameType:    ddnet.GameType,
+		MaxPlayers:  ddnet.MaxPlayers,
+		MaxClients:  ddnet.MaxClients,
+		CommunityID: ddnet.Community,
+		Online:      true,
+	}
+	if ddnet.Passworded {
+		info.ServerFlags |= ServerFlagPassword
+	}
+	switch ddnet.ClientScoreKind {
+	case "points":
+		info.ScoreKind = ScoreKindPoints
+	case "time":
+		info.ScoreKind = ScoreKindTime
+		info.ServerFlags |= ServerFlagTimescore
+	}
+
+	clients := ddnet.Clients
+	if len(clients) > MaxServerInfoPlayers {
+		clients = clients[:MaxServerInfoPlayers]
+	}
+	info.Players = make([]PlayerInfo, 0, len(clients))
+	for _, c := range clients {
+		player := PlayerInfo{
+			Name:    c.Name,
+			Clan:    c.Clan,
+			Country: c.Country,
+			Score:   c.Score,
+		}
+		if c.IsPlayer {
+			info.NumPlayers++
+		} else {
+			player.Type = 1
+		}
+		info.Players = append(info.Players, player)
+	}
+	info.NumClients = len(info.Players)
+	return info, nil
+}
+
+// parseDDNetExtendedInfo parses the string fields of a DDNet extended server info, which extends the 0.6 server info
+// by the map's CRC and size and by a reserved field after the client counts and after every player.
+// Servers with many players split the info into several packets, only the players of the first packet are parsed,
+// GetServerInfoExtended collects the further packets.
+func parseDDNetExtendedInfo(fields [][]byte, address string) (info ServerInfo, err error) {
+	// token, version, name, map, map crc, map size, game type, flags,
+	// num players, max players, num clients, max clients, reserved
+	const minFields = 13
+	if len(fields) < minFields {
+		return ServerInfo{}, fmt.Errorf("%w : expected at least %d fields got: %d", ErrMalformedResponseData, minFields, len(fields))
+	}
+
+	// the CRC is sent as signed integer
+	crc, err := strconv.ParseInt(string(fields[4]), 10, 64)
+	if err != nil {
+		return ServerInfo{}, fmt.Errorf("%w : %v", ErrMalformedResponseData, err)
+	}
+
+	ints := make([]int, 0, 7)
+	for _, field := range [][]byte{fields[5], fields[7], fields[8], fields[9], fields[10], fields[11]} {
+		i, err := strconv.Atoi(string(field))
+		if err != nil {
+			return ServerInfo{}, fmt.Errorf("%w : %v", ErrMalformedResponseData, err)
+		}
+		ints = append(ints, i)
+	}
+
+	info = ServerInfo{
+		Address:     address,
+		Version:     string(fields[1]),
+		Name:        string(fields[2]),
+		Map:         string(fields[3]),
+		MapCRC:      uint32(crc),
+		MapSize:     ints[0],
+		GameType:    string(fields[6]),
+		ServerFlags: ServerFlags(ints[1]),
+		NumPlayers:  ints[2],
+		MaxPlayers:  ints[3],
+		NumClients:  ints[4],
+		MaxClients:  ints[5],
+	}
+	fields = fields[minFields:]
+
+	// name, clan, country, score, is player, reserved
+	if len(fields)%6 != 0 {
+		return ServerInfo{}, fmt.Errorf("%w : expected 6 fields per player got: %d", ErrMalformedResponseData, len(fields))
+	}
+
+	info.Players = make([]PlayerInfo, 0, len(fields)/6)
+	for ; len(fields) > 0 && len(info.Players) < MaxServerInfoPlayers; fields = fields[6:] {
+		player, err := parseLegacyPlayer(fields[:5])
+		if err != nil {
+			return ServerInfo{}, err
+		}
+		info.Players = append(info.Players, player)
+	}
+	info.Online = true
+	return info, nil
+}
+
+// GetServerInfoExtended fetches the extended server info of the DDNet server at addr, e.g. "127.0.0.1:8303".
+// If the port is omitted, DefaultGamePort is used. Servers with many clients split their info into several packets,
+// which are reassembled in the order of their packet numbers, so that the player list is complete.
+// The request is repeated with the options' wait strategy until every packet has been received, but at most
+// for the query timeout or until the deadline of ctx. Every received packet extends the wait for the next one
+// by the timeout of WithExtInfoTimeout, which keeps slow servers from being asked to send their info again
+// while they are still sending it. If the first packet did not arrive by then, ErrTimeout or the error of ctx
+// is returned. If only further packets are missing, the info with the players that have been received
+// is returned without an error, its Truncated field tells that players are missing.
+// If ctx is cancelled, ctx.Err() is returned without the received players.
+func GetServerInfoExtended(ctx context.Context, addr string, opts ...Option) (ServerInfo, error) {
+	srv, err := ParseServerAddress(addr)
+	if err != nil {
+		return ServerInfo{}, err
+	}
+	o := newOptions(opts...)
+	udpAddr := &net.UDPAddr{IP: srv.IP, Port: int(srv.Port)}
+
+	timeout := o.queryTimeout
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < timeout {
+		timeout = time.Until(deadline)
+	}
+
+	dialed, err := o.dial(ctx, udpAddr)
+	if err != nil {
+		return ServerInfo{}, err
+	}
+	defer dialed.Close()
+
+	conn, stop := withContext(ctx, dialed)
+	defer stop()
+
+	chunks := newDDNetInfoChunks(o.clientToken()&ddnetTokenMask, udpAddr.String())
+	request := newDDNetInfoRequest(chunks.token)
+	buf := make([]byte, maxBufferSize)
+
+	begin := time.Now()
+	end := begin.Add(timeout)
+	for attempt := 0; ctx.Err() == nil; attempt++ {
+		currentTimeout := o.wait.Wait(attempt, time.Since(begin), timeout)
+		if currentTimeout <= 0 {
+			break
+		}
+		readDeadline := time.Now().Add(jitterDuration(currentTimeout, o.jitter))
+		conn.SetReadDeadline(readDeadline)
+
+		// the server sends all packets again, already received packets are ignored
+		if _, err := conn.Write(request); isTimeout(err) {
+			break
+		} else if err != nil {
+			return ServerInfo{}, unreachableError(err)
+		}
+
+		for {
+			n, err := conn.Read(buf)
+			if isTimeout(err) {
+				break
+			} else if err != nil {
+				return ServerInfo{}, unreachableError(err)
+			}
+			if n > maxPacketSize {
+				continue
+			}
+
+			accepted, complete := chunks.add(buf[:n])
+			if complete {
+				return chunks.assemble(), nil
+			} else if !accepted || o.extInfoTimeout <= 0 {
+				continue
+			}
+
+			// the server is still sending, the next packet is waited for regardless of the retry interval
+			if next := time.Now().Add(o.extInfoTimeout); next.After(readDeadline) {
+				readDeadline = next
+				if readDeadline.After(end) {
+					readDeadline = end
+				}
+				conn.SetReadDeadline(readDeadline)
+			}
+		}
+	}
+
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return ServerInfo{}, ctx.Err()
+	}
+	// the deadline of ctx is a timeout like the query timeout
+	if chunks.first != nil {
+		return chunks.assemble(), nil
+	}
+	if ctx.Err() != nil {
+		return ServerInfo{}, ctx.Err()
+	}
+	return ServerInfo{}, ErrTimeout
+}
+
+// newDDNetInfoRequest creates a 0.6 info request that asks for the extended server info.
+// The lowest byte of the token is sent as basic token after the "gie3" request, the two bytes above it
+// as extra token in the extended header. The server echoes both combined as token of its response.
+func newDDNetInfoRequest(token int) []byte {
+	request := make([]byte, 0, legacyConnlessHeaderSize+len(requestInfoRaw)+1)
+	request = append(request, ddnetHeaderExtended...)
+	request = append(request, byte(token>>16), byte(token>>8), 0, 0)
+	request = append(request, requestInfoRaw...)
+	request = append(request, byte(token))
+	return request
+}
+
+// ddnetInfoChunks reassembles the packets of an extended server info.
+type ddnetInfoChunks struct {
+	token   int
+	address string
+
+	// first is the info of the "iext" packet, more contains the players of the "iex+" packets by their packet number.
+	first *ServerInfo
+	more  map[int][]PlayerInfo
+}
+
+func newDDNetInfoChunks(token int, address string) *ddnetInfoChunks {
+	return &ddnetInfoChunks{
+		token:   token,
+		address: address,
+		more:    make(map[int][]PlayerInfo),
+	}
+}
+
+// add adds a received packet and returns complete once all players have been received.
+// Packets that are not an extended server info or do not echo the token are ignored, as are malformed packets,
+// which may be followed by intact ones. accepted is true for the packets of the info, including packets
+// that have been received before, which are not added again.
+func (c *ddnetInfoChunks) add(resp []byte) (accepted, complete bool) {
+	if len(resp) < legacyConnlessHeaderSize+len(ddnetSendInfoExtendedRaw) {
+		return false, false
+	}
+
+	header := resp[legacyConnlessHeaderSize : legacyConnlessHeaderSize+len(ddnetSendInfoExtendedRaw)]
+	first := bytes.Equal(header, ddnetSendInfoExtendedRaw)
+	if !first && !bytes.Equal(header, ddnetSendInfoExtendedMoreRaw) {
+		return false, false
+	}
+
+	fields := bytes.Split(resp[legacyConnlessHeaderSize+len(ddnetSendInfoExtendedRaw):], delimiter)
+	// the last string is terminated as well
+	fields = fields[:len(fields)-1]
+	if len(fields) == 0 {
+		return false, false
+	}
+	if token, err := strconv.Atoi(string(fields[0])); err != nil || token != c.token {
+		return false, false
+	}
+
+	if first {
+		if c.first == nil {
+			info, err := parseDDNetExtendedInfo(fields, c.address)
+			if err != nil {
+				return false, false
+			}
+			c.first = &info
+		}
+	} else {
+		packetNo, players, err := parseDDNetExtendedMore(fields)
+		if err != nil {
+			return false, false
+		}
+		if _, ok := c.more[packetNo]; !ok {
+			c.more[packetNo] = players
+		}
+	}
+	return true, c.first != nil && c.numPlayers() >= c.wantPlayers()
+}
+
+// numPlayers returns the number of players that have been received.
+func (c *ddnetInfoChunks) numPlayers() int {
+	n := 0
+	if c.first != nil {
+		n = len(c.first.Players)
+	}
+	for _, players := range c.more {
+		n += len(players)
+	}
+	return n
+}
+
+// wantPlayers returns the number of players that the first packet announced, at most MaxServerInfoPlayers.
+func (c *ddnetInfoChunks) wantPlayers() int {
+	want := c.first.NumClients
+	if want > MaxServerInfoPlayers {
+		want = MaxServerInfoPlayers
+	}
+	return want
+}
+
+// assemble appends the players of the further packets to the first packet's info in the order of their packet numbers.
+// The info is truncated if players are still missing.
+func (c *ddnetInfoChunks) assemble() ServerInfo {
+	packetNos := make([]int, 0, len(c.more))
+	for packetNo := range c.more {
+		packetNos = append(packetNos, packetNo)
+	}
+	sort.Ints(packetNos)
+
+	info := *c.first
+	info.Players = append(make([]PlayerInfo, 0, c.numPlayers()), c.first.Players...)
+	for _, packetNo := range packetNos {
+		info.Players = append(info.Players, c.more[packetNo]...)
+	}
+	if len(info.Players) > MaxServerInfoPlayers {
+		info.Players = info.Players[:MaxServerInfoPlayers]
+	}
+	info.Truncated = len(info.Players) < c.wantPlayers()
+	return info
+}
+
+// parseDDNetExtendedMore parses the string fields of a further packet of an extended server info, which consist of
+// the token, the packet number and a reserved field followed by the players in the same format as in the first packet.
+func parseDDNetExtendedMore(fields [][]byte) (packetNo int, players []PlayerInfo, err error) {
+	// token, packet number, reserved
+	const minFields = 3
+	if len(fields) < minFields {
+		return 0, nil, fmt.Errorf("%w : expected at least %d fields got: %d", ErrMalformedResponseData, minFields, len(fields))
+	}
+
+	packetNo, err = strconv.Atoi(string(fields[1]))
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w : %v", ErrMalformedResponseData, err)
+	}
+	fields = fields[minFields:]
+
+	// name, clan, country, score, is player, reserved
+	if len(fields)%6 != 0 {
+		return 0, nil, fmt.Errorf("%w : expected 6 fields per player got: %d", ErrMalformedResponseData, len(fields))
+	}
+
+	players = make([]PlayerInfo, 0, len(fields)/6)
+	for ; len(fields) > 0 && len(players) < MaxServerInfoPlayers; fields = fields[6:] {
+		player, err := parseLegacyPlayer(fields[:5])
+		if err != nil {
+			return 0, nil, err
+		}
+		players = append(players, player)
+	}
+	return packetNo, players, nil
+}
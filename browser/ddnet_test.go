@@ -0,0 +1,349 @@
+package browser
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jxsl13/twapi/internal/mockserver"
+)
+
+// packDDNetExtendedInfo creates a DDNet extended server info response from its string fields.
+func packDDNetExtendedInfo(fields ...string) []byte {
+	return []byte("\xff\xff\xff\xff\xff\xff" + ddnetSendInfoExtended + strings.Join(fields, "\x00") + "\x00")
+}
+
+func TestParseLegacyServerInfoDDNetExtended(t *testing.T) {
+	// extended info response as sent by DDNet 16 servers
+	response := packDDNetExtendedInfo("7", "0.6.4, 16.3.2", "DDNet GER - Novice", "Kobra 4", "-1204227493", "97612", "DDraceNetwork",
+		"0", "2", "64", "3", "64", "",
+		"alice", "", "276", "-9999", "1", "",
+		"bob", "Clan", "-1", "1523", "1", "",
+		"carol", "", "0", "-9999", "0", "")
+
+	info, err := ParseLegacyServerInfo(response, "127.0.0.1:8303")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := ServerInfo{
+		Address:    "127.0.0.1:8303",
+		Version:    "0.6.4, 16.3.2",
+		Name:       "DDNet GER - Novice",
+		Map:        "Kobra 4",
+		MapCRC:     0xb838f25b,
+		MapSize:    97612,
+		GameType:   "DDraceNetwork",
+		NumPlayers: 2,
+		MaxPlayers: 64,
+		NumClients: 3,
+		MaxClients: 64,
+		Players: []PlayerInfo{
+			{Name: "alice", Country: 276, Score: -9999},
+			{Name: "bob", Clan: "Clan", Country: -1, Score: 1523},
+			{Name: "carol", Score: -9999, Type: 1},
+		},
+	}
+	if !info.Equal(want) {
+		t.Errorf("ParseLegacyServerInfo() = %s, want %s", info.String(), want.String())
+	}
+}
+
+func TestParseLegacyServerInfoNonDDNet(t *testing.T) {
+	info, err := ParseLegacyServerInfo(packLegacyServerInfo("0", "0.6.4", "name", "dm1", "DM", "0", "0", "16", "0", "16"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.MapCRC != 0 || info.MapSize != 0 || info.MapSHA256 != "" || info.ScoreKind != ScoreKindUnspecified || info.CommunityID != "" {
+		t.Errorf("expected empty DDNet fields, got %s", info.String())
+	}
+}
+
+func TestParseLegacyServerInfoDDNetExtendedInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		response []byte
+	}{
+		{"missing fields", packDDNetExtendedInfo("7", "0.6.4, 16.3.2", "name", "map", "0", "0", "DDraceNetwork")},
+		{"invalid crc", packDDNetExtendedInfo("7", "0.6.4, 16.3.2", "name", "map", "x", "0", "DDraceNetwork", "0", "0", "64", "0", "64", "")},
+		{"player without reserved field", packDDNetExtendedInfo("7", "0.6.4, 16.3.2", "name", "map", "0", "0", "DDraceNetwork", "0", "1", "64", "1", "64", "",
+			"alice", "", "276", "-9999", "1")},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseLegacyServerInfo(tt.response, ""); !errors.Is(err, ErrMalformedResponseData) {
+				t.Errorf("expected %v, got %v", ErrMalformedResponseData, err)
+			}
+		})
+	}
+}
+
+func TestParseDDNetServerInfo(t *testing.T) {
+	// server info as listed by the DDNet HTTP master servers
+	const data = `{
+	"max_clients": 64,
+	"max_players": 64,
+	"passworded": false,
+	"game_type": "DDraceNetwork",
+	"name": "DDNet GER - Novice",
+	"map": {
+		"name": "Kobra 4",
+		"sha256": "2b1bbb3fd2ff4d9b4e0bf5c6ba9eddca3c5d2b7a6b8b4c5f3a0d6a7f4e2c9d81",
+		"size": 97612
+	},
+	"version": "0.6.4, 17.4.2",
+	"client_score_kind": "time",
+	"community": "ddnet",
+	"clients": [
+		{"name": "alice", "clan": "", "country": 276, "score": -9999, "is_player": true, "skin": {"name": "default"}, "afk": false, "team": 0},
+		{"name": "bob", "clan": "Clan", "country": -1, "score": 1523, "is_player": false, "skin": {"name": "santa"}, "afk": true, "team": 0}
+	]
+}`
+
+	info, err := ParseDDNetServerInfo([]byte(data), "127.0.0.1:8303")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := ServerInfo{
+		Address:     "127.0.0.1:8303",
+		Version:     "0.6.4, 17.4.2",
+		Name:        "DDNet GER - Novice",
+		Map:         "Kobra 4",
+		MapSHA256:   "2b1bbb3fd2ff4d9b4e0bf5c6ba9eddca3c5d2b7a6b8b4c5f3a0d6a7f4e2c9d81",
+		MapSize:     97612,
+		GameType:    "DDraceNetwork",
+		ServerFlags: ServerFlagTimescore,
+		ScoreKind:   ScoreKindTime,
+		CommunityID: "ddnet",
+		NumPlayers:  1,
+		MaxPlayers:  64,
+		NumClients:  2,
+		MaxClients:  64,
+		Players: []PlayerInfo{
+			{Name: "alice", Country: 276, Score: -9999},
+			{Name: "bob", Clan: "Clan", Country: -1, Score: 1523, Type: 1},
+		},
+	}
+	if !info.Equal(want) {
+		t.Errorf("ParseDDNetServerInfo() = %s, want %s", info.String(), want.String())
+	}
+	if got := info.ScoreKind.String(); got != "time" {
+		t.Errorf("ScoreKind.String() = %q, want %q", got, "time")
+	}
+}
+
+func TestParseDDNetServerInfoInvalid(t *testing.T) {
+	if _, err := ParseDDNetServerInfo([]byte(`{"name": 1}`), ""); !errors.Is(err, ErrMalformedResponseData) {
+		t.Errorf("expected %v, got %v", ErrMalformedResponseData, err)
+	}
+}
+
+// packDDNetExtendedMore creates a further packet of a DDNet extended server info from its string fields.
+func packDDNetExtendedMore(fields ...string) []byte {
+	return []byte("\xff\xff\xff\xff\xff\xff" + ddnetSendInfoExtendedMore + strings.Join(fields, "\x00") + "\x00")
+}
+
+// newFakeDDNetServer answers extended info requests with the passed number of players,
+// which are split into packets of at most 16 players each. The packets are passed to send
+// together with the token of the request, which decides which of them are sent in which order.
+func newFakeDDNetServer(t *testing.T, numPlayers int, send func(fs *mockserver.Server, addr *net.UDPAddr, token int, packets [][]byte)) *mockserver.Server {
+	fs, err := mockserver.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs.SetLegacyInfoHandler(func(request []byte, addr *net.UDPAddr) {
+		n := len(request)
+		if n != legacyConnlessHeaderSize+len(requestInfoRaw)+1 || !bytes.HasPrefix(request, []byte(ddnetHeaderExtended)) {
+			return
+		}
+		token := int(request[2])<<16 | int(request[3])<<8 | int(request[n-1])
+		tokenStr := strconv.Itoa(token)
+
+		const perPacket = 16
+		fields := []string{tokenStr, "0.6.4, 16.3.2", "crowded", "Kobra 4", "0", "97612", "DDraceNetwork",
+			"0", strconv.Itoa(numPlayers), "64", strconv.Itoa(numPlayers), "64", ""}
+		var packets [][]byte
+		for i := 0; i < numPlayers; i++ {
+			if i > 0 && i%perPacket == 0 {
+				if len(packets) == 0 {
+					packets = append(packets, packDDNetExtendedInfo(fields...))
+				} else {
+					packets = append(packets, packDDNetExtendedMore(fields...))
+				}
+				fields = []string{tokenStr, strconv.Itoa(len(packets)), ""}
+			}
+			fields = append(fields, fmt.Sprintf("player%d", i), "", "0", strconv.Itoa(i), "1", "")
+		}
+		if len(packets) == 0 {
+			packets = append(packets, packDDNetExtendedInfo(fields...))
+		} else {
+			packets = append(packets, packDDNetExtendedMore(fields...))
+		}
+		send(fs, addr, token, packets)
+	})
+	if err := fs.Start(); err != nil {
+		t.Fatal(err)
+	}
+	return fs
+}
+
+func TestGetServerInfoExtended(t *testing.T) {
+	fs := newFakeDDNetServer(t, MaxServerInfoPlayers, func(fs *mockserver.Server, addr *net.UDPAddr, token int, packets [][]byte) {
+		// a stray packet of someone else's request
+		fs.WriteTo(packDDNetExtendedMore(strconv.Itoa(token+1), "1", "", "stray", "", "0", "0", "1", ""), addr)
+		// the further packets in reverse order before the first one
+		for i := len(packets) - 1; i >= 0; i-- {
+			fs.WriteTo(packets[i], addr)
+		}
+	})
+	defer fs.Stop()
+
+	info, err := GetServerInfoExtended(context.Background(), fs.Addr().String(), WithQueryTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Name != "crowded" || info.NumClients != MaxServerInfoPlayers || info.MapSize != 97612 {
+		t.Errorf("GetServerInfoExtended() = %s", info.String())
+	}
+	if len(info.Players) != MaxServerInfoPlayers {
+		t.Fatalf("GetServerInfoExtended() returned %d players, want %d", len(info.Players), MaxServerInfoPlayers)
+	}
+	for i, player := range info.Players {
+		if want := fmt.Sprintf("player%d", i); player.Name != want || player.Score != i {
+			t.Errorf("player %d = %v, want %s with score %d", i, player, want, i)
+		}
+	}
+}
+
+func TestGetServerInfoExtendedMissingPacket(t *testing.T) {
+	fs := newFakeDDNetServer(t, 40, func(fs *mockserver.Server, addr *net.UDPAddr, token int, packets [][]byte) {
+		// the last packet is always lost
+		for _, packet := range packets[:len(packets)-1] {
+			fs.WriteTo(packet, addr)
+		}
+	})
+	defer fs.Stop()
+
+	begin := time.Now()
+	info, err := GetServerInfoExtended(context.Background(), fs.Addr().String(), WithQueryTimeout(300*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Errorf("GetServerInfoExtended() returned after %s, want it to time out after the query timeout", elapsed)
+	}
+
+	// the players of the first two packets are kept
+	if info.Name != "crowded" || info.NumClients != 40 || len(info.Players) != 32 || !info.Truncated {
+		t.Fatalf("GetServerInfoExtended() = %s with %d players, want the partial info with 32 players", info.String(), len(info.Players))
+	}
+	for i, player := range info.Players {
+		if want := fmt.Sprintf("player%d", i); player.Name != want {
+			t.Errorf("player %d = %v, want %s", i, player, want)
+		}
+	}
+
+	// the deadline of the context is a timeout as well
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	info, err = GetServerInfoExtended(ctx, fs.Addr().String())
+	if err != nil || !info.Truncated || len(info.Players) != 32 {
+		t.Errorf("GetServerInfoExtended() with a context deadline returned %d players and %v, want the partial info with 32 players",
+			len(info.Players), err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	if _, err := GetServerInfoExtended(ctx, fs.Addr().String()); err != context.Canceled {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestGetServerInfoExtendedSlowContinuation(t *testing.T) {
+	const gap = 150 * time.Millisecond
+	fs := newFakeDDNetServer(t, MaxServerInfoPlayers, func(fs *mockserver.Server, addr *net.UDPAddr, token int, packets [][]byte) {
+		// a busy server that sends its packets slower than the retry interval
+		go func() {
+			for i, packet := range packets {
+				if i > 0 {
+					time.Sleep(gap)
+				}
+				if err := fs.WriteTo(packet, addr); err != nil {
+					return
+				}
+			}
+		}()
+	})
+	defer fs.Stop()
+
+	// a single attempt, which does not wait for the slow continuation packets
+	wait := WithWaitStrategy(ExponentialWait{Initial: 100 * time.Millisecond, MaxAttempts: 1})
+	info, err := GetServerInfoExtended(context.Background(), fs.Addr().String(), WithQueryTimeout(2*time.Second), wait)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Truncated || len(info.Players) != 16 || info.NumClients != MaxServerInfoPlayers {
+		t.Errorf("GetServerInfoExtended() returned %d players, truncated: %t, want the 16 players of the first packet",
+			len(info.Players), info.Truncated)
+	}
+
+	// every packet extends the wait for the next one
+	info, err = GetServerInfoExtended(context.Background(), fs.Addr().String(),
+		WithQueryTimeout(2*time.Second), WithExtInfoTimeout(2*gap), wait)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Truncated || len(info.Players) != MaxServerInfoPlayers {
+		t.Errorf("GetServerInfoExtended() returned %d players, truncated: %t, want all %d players",
+			len(info.Players), info.Truncated, MaxServerInfoPlayers)
+	}
+
+	// the query timeout limits the total time of the reassembly
+	begin := time.Now()
+	info, err = GetServerInfoExtended(context.Background(), fs.Addr().String(),
+		WithQueryTimeout(2*gap), WithExtInfoTimeout(time.Second), wait)
+	if err != nil || !info.Truncated {
+		t.Errorf("expected a truncated info without an error, got %d players and %v", len(info.Players), err)
+	}
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Errorf("GetServerInfoExtended() returned after %s, want it to return after the query timeout", elapsed)
+	}
+}
+
+func TestGetServerInfoExtendedMalformedPacket(t *testing.T) {
+	fs := newFakeDDNetServer(t, 20, func(fs *mockserver.Server, addr *net.UDPAddr, token int, packets [][]byte) {
+		// malformed packets that echo the token precede the intact ones
+		fs.WriteTo(packDDNetExtendedInfo(strconv.Itoa(token), "0.6.4", "broken"), addr)
+		fs.WriteTo(packDDNetExtendedMore(strconv.Itoa(token), "1", "", "broken"), addr)
+		for _, packet := range packets {
+			fs.WriteTo(packet, addr)
+		}
+	})
+	defer fs.Stop()
+
+	info, err := GetServerInfoExtended(context.Background(), fs.Addr().String(), WithQueryTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Name != "crowded" || len(info.Players) != 20 {
+		t.Errorf("GetServerInfoExtended() = %s with %d players, want the intact info with 20 players", info.String(), len(info.Players))
+	}
+}
+
+func TestNewDDNetInfoRequest(t *testing.T) {
+	want := append([]byte("xe\x12\x34\x00\x00"), requestInfoRaw...)
+	want = append(want, 0x56)
+	if got := newDDNetInfoRequest(0x123456); !bytes.Equal(got, want) {
+		t.Errorf("newDDNetInfoRequest() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,117 @@
+package browser
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jxsl13/twapi/compression"
+	"github.com/jxsl13/twapi/internal/mockserver"
+)
+
+// fakeServer emulates a master server or a game server on the loopback interface.
+// It is a thin wrapper of the server behind testutil.MockServer, which cannot be imported here,
+// because it imports this package. The fields are passed to the server by start and after every
+// call of beforeResponse, which is why beforeResponse may change them in order to alter the next responses.
+type fakeServer struct {
+	*mockserver.Server
+
+	serverToken int
+	servers     ServerList
+	info        *ServerInfo
+	rawInfo     []byte     // sent instead of info, if set
+	strayList   ServerList // sent with a wrong client token before every server list packet, if set
+	delay       time.Duration
+
+	// beforeResponse is called right before a request that carries the server token is answered.
+	beforeResponse func(fs *fakeServer, addr *net.UDPAddr)
+}
+
+// newFakeMasterServer starts a master server that serves the passed server list.
+func newFakeMasterServer(t *testing.T, servers ServerList) *fakeServer {
+	fs := newFakeServer(t)
+	fs.servers = servers
+	fs.start()
+	return fs
+}
+
+// newFakeGameServer starts a game server that serves the passed server info.
+func newFakeGameServer(t *testing.T, info ServerInfo) *fakeServer {
+	fs := newFakeServer(t)
+	fs.info = &info
+	fs.start()
+	return fs
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	srv, err := mockserver.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &fakeServer{
+		Server:      srv,
+		serverToken: srv.Token(),
+	}
+}
+
+func (fs *fakeServer) start() {
+	fs.configure()
+	if fs.beforeResponse != nil {
+		fs.SetBeforeResponse(func(addr *net.UDPAddr) {
+			fs.beforeResponse(fs, addr)
+			fs.configure()
+		})
+	}
+	fs.Start()
+}
+
+// configure passes the fields to the server.
+func (fs *fakeServer) configure() {
+	fs.SetToken(fs.serverToken)
+	fs.SetServerList(fs.servers)
+	fs.SetStrayServerList(fs.strayList)
+	fs.SetDelay(fs.delay)
+
+	switch {
+	case fs.rawInfo != nil:
+		fs.SetServerInfo(fs.rawInfo)
+	case fs.info != nil:
+		data, _ := fs.info.MarshalBinary()
+		fs.SetServerInfo(data)
+	default:
+		fs.SetServerInfo(nil)
+	}
+}
+
+// Close stops the server and waits for it to shut down.
+func (fs *fakeServer) Close() {
+	fs.Stop()
+}
+
+// packInfoResponse creates an info response that echoes extraToken and contains the packed server info data.
+func packInfoResponse(prefix []byte, extraToken int, data []byte) []byte {
+	var v compression.VarInt
+	v.Pack(extraToken)
+	packet := append(append([]byte{}, prefix...), sendInfoRaw...)
+	packet = append(packet, v.Bytes()...)
+	return append(packet, data...)
+}
+
+// packTokenResponse creates the response to a token request
+func packTokenResponse(clientToken, serverToken int) []byte {
+	return mockserver.PackTokenResponse(clientToken, serverToken)
+}
+
+// packServerListPackets splits the server list into multiple server list response packets
+func packServerListPackets(prefix []byte, servers ServerList) [][]byte {
+	packets := mockserver.PackServerList(servers)
+	for idx, payload := range packets {
+		packets[idx] = append(append([]byte{}, prefix...), payload...)
+	}
+	return packets
+}
+
+func unpackInt(b []byte) int {
+	return (int(b[0]) << 24) + (int(b[1]) << 16) + (int(b[2]) << 8) + int(b[3])
+}
@@ -0,0 +1,132 @@
+package browser
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fastestMasters remembers the fastest master server of every set of master servers
+// that has been used with WithFastestMaster.
+var fastestMasters = struct {
+	sync.Mutex
+	m map[string]fastestMaster
+}{m: make(map[string]fastestMaster)}
+
+type fastestMaster struct {
+	addr        *net.UDPAddr
+	evaluatedAt time.Time
+}
+
+// FastestMaster requests a token from every master server and returns the one that responds first.
+// As the token is kept, the returned master server can request its server list right away.
+// The master servers are set with WithMasterServers, by default MasterServerAddresses are used.
+// Each master server is given the master server timeout to respond, ErrNoMasterServer is returned
+// if none of them responds. The caller must close the returned master server.
+func FastestMaster(ctx context.Context, opts ...Option) (*MasterServer, error) {
+	o := newOptions(opts...)
+	o.resolveMasterServers()
+	return findFastestMaster(ctx, o)
+}
+
+func findFastestMaster(ctx context.Context, o options) (*MasterServer, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.masterServerTimeout)
+	defer cancel()
+
+	// nil for master servers that did not respond
+	responded := make(chan *MasterServer, len(o.masterServers))
+	for _, addr := range o.masterServers {
+		go func(addr *net.UDPAddr) {
+			master, err := newMasterServer(addr, o)
+			if err != nil {
+				responded <- nil
+				return
+			}
+
+			conn, stop := withContext(ctx, master.conn)
+			err = master.refreshToken(conn, o.masterServerTimeout)
+			stop()
+			if err != nil {
+				master.Close()
+				responded <- nil
+				return
+			}
+			responded <- master
+		}(addr)
+	}
+
+	var fastest *MasterServer
+	for range o.masterServers {
+		master := <-responded
+		if master == nil {
+			continue
+		}
+		if fastest != nil {
+			master.Close()
+			continue
+		}
+		fastest = master
+		// the slower master servers do not need to respond anymore
+		cancel()
+	}
+
+	if fastest == nil {
+		if ctx.Err() != nil && ctx.Err() != context.DeadlineExceeded {
+			return nil, ctx.Err()
+		}
+		return nil, ErrNoMasterServer
+	}
+	return fastest, nil
+}
+
+// fetchFastestServerList fetches the server list of the remembered fastest master server.
+// The fastest master server is evaluated again if the interval elapsed or it failed to send its list.
+func fetchFastestServerList(ctx context.Context, o options) ([]ServerAddress, error) {
+	key := masterServersKey(o.masterServers)
+
+	fastestMasters.Lock()
+	cached, ok := fastestMasters.m[key]
+	fastestMasters.Unlock()
+
+	var (
+		master *MasterServer
+		err    error
+	)
+	if ok && time.Since(cached.evaluatedAt) < o.fastestMasterInterval {
+		master, err = newMasterServer(cached.addr, o)
+	} else {
+		master, err = findFastestMaster(ctx, o)
+		if err == nil {
+			fastestMasters.Lock()
+			fastestMasters.m[key] = fastestMaster{addr: master.Addr(), evaluatedAt: time.Now()}
+			fastestMasters.Unlock()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer master.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, o.masterServerTimeout)
+	defer cancel()
+
+	servers, err := master.getServerList(ctx, -1)
+	if err != nil {
+		fastestMasters.Lock()
+		delete(fastestMasters.m, key)
+		fastestMasters.Unlock()
+		return nil, err
+	}
+	return servers, nil
+}
+
+// masterServersKey identifies a set of master servers.
+func masterServersKey(addrs []*net.UDPAddr) string {
+	keys := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		keys = append(keys, addr.String())
+	}
+	return strings.Join(keys, ",")
+}
@@ -0,0 +1,91 @@
+package browser
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestFastestMaster(t *testing.T) {
+	slow := newFakeServer(t)
+	slow.servers = ServerList{{IP: net.IPv4(10, 0, 0, 1), Port: DefaultGamePort}}
+	slow.delay = 200 * time.Millisecond
+	slow.start()
+	defer slow.Close()
+
+	fast := newFakeMasterServer(t, ServerList{{IP: net.IPv4(10, 0, 0, 2), Port: DefaultGamePort}})
+	defer fast.Close()
+
+	dead := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1}
+
+	master, err := FastestMaster(context.Background(),
+		WithMasterServers(slow.Addr(), dead, fast.Addr()),
+		WithMasterServerTimeout(time.Second),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer master.Close()
+
+	if master.Addr().String() != fast.Addr().String() {
+		t.Errorf("expected the fast master server %s, got %s", fast.Addr(), master.Addr())
+	}
+
+	list, err := master.GetServerList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].String() != "10.0.0.2:8303" {
+		t.Errorf("GetServerList() = %v", list)
+	}
+}
+
+func TestFastestMasterNoResponse(t *testing.T) {
+	dead := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1}
+	_, err := FastestMaster(context.Background(), WithMasterServers(dead), WithMasterServerTimeout(100*time.Millisecond))
+	if err != ErrNoMasterServer {
+		t.Errorf("expected %v, got %v", ErrNoMasterServer, err)
+	}
+}
+
+func TestWithFastestMaster(t *testing.T) {
+	slowInfo := ServerInfo{Version: "0.7.5", Name: "listed by the slow master"}
+	slowGame := newFakeGameServer(t, slowInfo)
+	defer slowGame.Close()
+
+	fastInfo := ServerInfo{Version: "0.7.5", Name: "listed by the fast master"}
+	fastGame := newFakeGameServer(t, fastInfo)
+	defer fastGame.Close()
+
+	slow := newFakeServer(t)
+	slow.servers = ServerList{slowGame.Addr()}
+	slow.delay = 200 * time.Millisecond
+	slow.start()
+	defer slow.Close()
+
+	fast := newFakeMasterServer(t, ServerList{fastGame.Addr()})
+	defer fast.Close()
+
+	for i := 0; i < 2; i++ {
+		infos, err := ListServersWithInfo(context.Background(),
+			WithMasterServers(slow.Addr(), fast.Addr()),
+			WithMasterServerTimeout(time.Second),
+			WithQueryTimeout(time.Second),
+			WithFastestMaster(time.Minute),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(infos) != 1 || infos[0].Name != fastInfo.Name {
+			t.Fatalf("expected only the server of the fast master, got %v", infos)
+		}
+	}
+
+	fastestMasters.Lock()
+	cached := fastestMasters.m[masterServersKey(ServerList{slow.Addr(), fast.Addr()})]
+	fastestMasters.Unlock()
+	if cached.addr.String() != fast.Addr().String() {
+		t.Errorf("expected the fast master server to be remembered, got %v", cached.addr)
+	}
+}
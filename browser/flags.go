@@ -0,0 +1,46 @@
+package browser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ServerFlags is the flag byte of a server info.
+type ServerFlags int
+
+const (
+	// ServerFlagPassword is set if joining the server requires a password.
+	ServerFlagPassword ServerFlags = 1 << iota
+	// ServerFlagTimescore is set if the scores of the players are times, e.g. in race modes.
+	ServerFlagTimescore
+)
+
+// HasPassword returns true if joining the server requires a password.
+func (f ServerFlags) HasPassword() bool {
+	return f&ServerFlagPassword != 0
+}
+
+// IsTimescore returns true if the scores of the players are times rather than points.
+func (f ServerFlags) IsTimescore() bool {
+	return f&ServerFlagTimescore != 0
+}
+
+// String returns the names of the set flags separated by "|", e.g. "password|timescore".
+// Unknown flags are listed as their hexadecimal value and no set flags result in "none".
+func (f ServerFlags) String() string {
+	names := make([]string, 0, 2)
+	if f.HasPassword() {
+		names = append(names, "password")
+	}
+	if f.IsTimescore() {
+		names = append(names, "timescore")
+	}
+	if unknown := f &^ (ServerFlagPassword | ServerFlagTimescore); unknown != 0 {
+		names = append(names, "0x"+strconv.FormatInt(int64(unknown), 16))
+	}
+
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "|")
+}
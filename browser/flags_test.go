@@ -0,0 +1,56 @@
+package browser
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestServerFlags(t *testing.T) {
+	tests := []struct {
+		raw          byte
+		wantPassword bool
+		wantTime     bool
+		wantString   string
+	}{
+		{0x00, false, false, "none"},
+		{0x01, true, false, "password"},
+		{0x02, false, true, "timescore"},
+		{0x03, true, true, "password|timescore"},
+		{0x84, false, false, "0x84"},
+		{0x05, true, false, "password|0x4"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.wantString, func(t *testing.T) {
+			info := ServerInfo{Version: "0.7.5", Name: "flags", ServerFlags: ServerFlags(tt.raw)}
+			data, _ := info.MarshalBinary()
+
+			var parsed ServerInfo
+			if err := parsed.UnmarshalBinary(data); err != nil {
+				t.Fatal(err)
+			}
+
+			flags := parsed.ServerFlags
+			if flags.HasPassword() != tt.wantPassword {
+				t.Errorf("HasPassword() = %t, want %t", flags.HasPassword(), tt.wantPassword)
+			}
+			if flags.IsTimescore() != tt.wantTime {
+				t.Errorf("IsTimescore() = %t, want %t", flags.IsTimescore(), tt.wantTime)
+			}
+			if flags.String() != tt.wantString {
+				t.Errorf("String() = %q, want %q", flags.String(), tt.wantString)
+			}
+		})
+	}
+
+	// the flags are still encoded as number
+	b, err := json.Marshal(ServerInfo{ServerFlags: ServerFlagPassword})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	json.Unmarshal(b, &decoded)
+	if decoded["server_flags"] != float64(1) {
+		t.Errorf("server_flags = %v, want 1", decoded["server_flags"])
+	}
+}
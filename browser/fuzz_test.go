@@ -0,0 +1,41 @@
+//go:build go1.18
+// +build go1.18
+
+package browser
+
+import (
+	"testing"
+)
+
+func FuzzParseServerInfo(f *testing.F) {
+	prefix := packToken(0x12345678, 0x01020304)
+
+	info := ServerInfo{
+		Version:    "0.7.5",
+		Name:       "fuzzed server",
+		Map:        "ctf5",
+		GameType:   "CTF",
+		NumPlayers: 1,
+		MaxPlayers: 16,
+		NumClients: 1,
+		MaxClients: 16,
+		Players:    []PlayerInfo{{Name: "nameless tee", Clan: "clan", Country: -1, Score: 10, Type: 0}},
+	}
+	data, err := info.MarshalBinary()
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(packInfoResponse(prefix, 0, data))
+	f.Add(append(prefix, sendInfoRaw...))
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		info, err := ParseServerInfo(data, "127.0.0.1:8303")
+		if err != nil {
+			return
+		}
+		if len(info.Players) > MaxServerInfoPlayers {
+			t.Fatalf("ParseServerInfo() returned %d players", len(info.Players))
+		}
+	})
+}
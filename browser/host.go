@@ -0,0 +1,69 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+// lookupIPAddr resolves hostnames to their IP addresses
+var lookupIPAddr = net.DefaultResolver.LookupIPAddr
+
+// QueryServerInfoHost fetches the server info of the game server at host.
+// host can be a hostname or an IP address that is optionally followed by a port,
+// e.g. "example.com", "example.com:8305" or "[::1]:8303". If the port is omitted, DefaultGamePort is used.
+// If a hostname resolves to multiple addresses, these are queried one after another until one of them
+// responds, in the order that is defined by WithAddressFamily. Every address is given an equal share
+// of the query timeout, which is TimeoutServers by default. Resolving the hostname is limited by the dial timeout.
+func QueryServerInfoHost(host string, opts ...Option) (ServerInfo, error) {
+	o := newOptions(opts...)
+
+	hostname, port, err := splitHostPort(host, DefaultGamePort)
+	if err != nil {
+		return ServerInfo{}, err
+	}
+
+	ips, err := resolveHost(hostname, o.addressFamily, o.dialTimeout)
+	if err != nil {
+		return ServerInfo{}, err
+	}
+
+	o.queryTimeout /= time.Duration(len(ips))
+	for _, ip := range ips {
+		var info ServerInfo
+		info, err = queryServerInfo(context.Background(), &net.UDPAddr{IP: ip, Port: port}, o)
+		if err == nil {
+			return info, nil
+		}
+	}
+	return ServerInfo{}, err
+}
+
+// resolveHost returns the IP addresses of hostname ordered and filtered by the address family.
+// ErrDialTimeout is returned if the lookup takes longer than timeout.
+func resolveHost(hostname string, family AddressFamily, timeout time.Duration) ([]net.IP, error) {
+	var ips []net.IP
+	if ip := net.ParseIP(hostname); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		addrs, err := lookupIPAddr(ctx, hostname)
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("%w: %s", ErrDialTimeout, hostname)
+		} else if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+
+	ips = family.filter(ips)
+	if len(ips) == 0 {
+		return nil, ErrInvalidIP
+	}
+	return ips, nil
+}
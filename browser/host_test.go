@@ -0,0 +1,170 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestQueryServerInfoHost(t *testing.T) {
+	fs := newFakeGameServer(t, ServerInfo{Version: "0.7.5", Name: "resolved", MaxClients: 16})
+	defer fs.Close()
+
+	defer func(lookup func(context.Context, string) ([]net.IPAddr, error)) {
+		lookupIPAddr = lookup
+	}(lookupIPAddr)
+
+	var lookedUp string
+	lookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		lookedUp = host
+		// the first address is not reachable
+		return []net.IPAddr{
+			{IP: net.IPv4(127, 0, 0, 2)},
+			{IP: net.IPv4(127, 0, 0, 1)},
+		}, nil
+	}
+
+	port := strconv.Itoa(fs.Addr().Port)
+	info, err := QueryServerInfoHost("teeworlds.example:" + port)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lookedUp != "teeworlds.example" {
+		t.Errorf("resolved %q, want %q", lookedUp, "teeworlds.example")
+	}
+	if info.Name != "resolved" {
+		t.Errorf("QueryServerInfoHost().Name = %q, want %q", info.Name, "resolved")
+	}
+	if want := "127.0.0.1:" + port; info.Address != want {
+		t.Errorf("QueryServerInfoHost().Address = %q, want %q", info.Address, want)
+	}
+
+	if _, err := QueryServerInfoHost("teeworlds.example:port"); err != ErrInvalidPort {
+		t.Errorf("expected %v, got %v", ErrInvalidPort, err)
+	}
+}
+
+func TestWithAddressFamily(t *testing.T) {
+	defer func(lookup func(context.Context, string) ([]net.IPAddr, error)) {
+		lookupIPAddr = lookup
+	}(lookupIPAddr)
+
+	v4, v6 := net.ParseIP("192.0.2.1"), net.ParseIP("2001:db8::1")
+	lookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		return []net.IPAddr{{IP: v6}, {IP: v4}}, nil
+	}
+
+	tests := []struct {
+		name    string
+		family  AddressFamily
+		want    []net.IP
+		wantErr bool
+	}{
+		{"auto", AddressFamilyAuto, []net.IP{v6, v4}, false},
+		{"prefer ipv4", PreferIPv4, []net.IP{v4, v6}, false},
+		{"prefer ipv6", PreferIPv6, []net.IP{v6, v4}, false},
+		{"ipv4 only", IPv4Only, []net.IP{v4}, false},
+		{"ipv6 only", IPv6Only, []net.IP{v6}, false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			o := newOptions(WithAddressFamily(tt.family))
+			got, err := resolveHost("teeworlds.example", o.addressFamily, o.dialTimeout)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolveHost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := resolveHost("192.0.2.1", IPv6Only, defaultDialTimeout); err != ErrInvalidIP {
+		t.Errorf("expected %v, got %v", ErrInvalidIP, err)
+	}
+
+	servers := []ServerAddress{{IP: v4, Port: 8303}, {IP: v6, Port: 8303}}
+	if got := filterServers(servers, IPv6Only); len(got) != 1 || !got[0].IP.Equal(v6) {
+		t.Errorf("filterServers() = %v, want only %v", got, v6)
+	}
+	if got := filterServers(servers, PreferIPv6); len(got) != len(servers) {
+		t.Errorf("filterServers() = %v, want all servers", got)
+	}
+}
+
+func TestQueryServerInfoHostDialTimeout(t *testing.T) {
+	defer func(lookup func(context.Context, string) ([]net.IPAddr, error)) {
+		lookupIPAddr = lookup
+	}(lookupIPAddr)
+
+	// a blackholed name server never answers
+	lookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		<-ctx.Done()
+		return nil, &net.DNSError{Err: "i/o timeout", Name: host, IsTimeout: true}
+	}
+
+	begin := time.Now()
+	_, err := QueryServerInfoHost("blackholed.example", WithDialTimeout(50*time.Millisecond), WithQueryTimeout(5*time.Second))
+	if !errors.Is(err, ErrDialTimeout) {
+		t.Fatalf("expected %v, got %v", ErrDialTimeout, err)
+	}
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Errorf("dial timeout fired after %s", elapsed)
+	}
+}
+
+func TestMasterServerAddressFamily(t *testing.T) {
+	ms := newFakeMasterServer(t, ServerList{{IP: net.IPv4(1, 2, 3, 4), Port: 8303}})
+	defer ms.Close()
+
+	defer func(lookup func(context.Context, string) ([]net.IPAddr, error)) {
+		lookupIPAddr = lookup
+	}(lookupIPAddr)
+
+	v6 := net.ParseIP("2001:db8::1")
+	lookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		// the IPv6 address is not reachable
+		return []net.IPAddr{{IP: v6}, {IP: ms.Addr().IP}}, nil
+	}
+
+	port := strconv.Itoa(ms.Addr().Port)
+	master, err := NewMasterServer("master.example:"+port, WithAddressFamily(PreferIPv4), WithMasterServerTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer master.Close()
+
+	if !master.Addr().IP.Equal(ms.Addr().IP) {
+		t.Errorf("MasterServer.Addr() = %v, want %v", master.Addr(), ms.Addr())
+	}
+	servers, err := master.GetServerList()
+	if err != nil || len(servers) != 1 {
+		t.Errorf("MasterServer.GetServerList() = %v, %v", servers, err)
+	}
+
+	// the default master servers are resolved in the order of the address family
+	o := newOptions(WithAddressFamily(PreferIPv6))
+	o.resolveMasterServers()
+	if len(o.masterServers) != len(masterServerHostnames) {
+		t.Fatalf("resolved %d master servers, want %d", len(o.masterServers), len(masterServerHostnames))
+	}
+	for _, addr := range o.masterServers {
+		if !addr.IP.Equal(v6) || addr.Port != DefaultMasterPort {
+			t.Errorf("resolved master server %v, want %v", addr, &net.UDPAddr{IP: v6, Port: DefaultMasterPort})
+		}
+	}
+
+	// explicitly set master servers are kept
+	custom := []*net.UDPAddr{ms.Addr()}
+	o = newOptions(WithAddressFamily(PreferIPv6), WithMasterServers(custom...))
+	o.resolveMasterServers()
+	if !reflect.DeepEqual(o.masterServers, custom) {
+		t.Errorf("master servers = %v, want %v", o.masterServers, custom)
+	}
+}
@@ -0,0 +1,117 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+var (
+	// TimeoutLAN is the duration DiscoverLAN waits for servers in the local network to respond.
+	TimeoutLAN = time.Second
+
+	// lanBroadcastIP is the address the LAN discovery request is sent to.
+	lanBroadcastIP = net.IPv4bcast
+)
+
+// DiscoverLAN broadcasts a token request to the given port of every host in the local network
+// and queries every game server that responds for its server info.
+// Responses are collected until TimeoutLAN elapsed or ctx is done, whichever happens first.
+// Only if the context is cancelled before the time window is over, the context's error is returned
+// alongside with the infos that have been collected up to that point.
+//
+// Broadcasting does not need any special socket setup, as UDP sockets are created
+// with SO_BROADCAST enabled by default.
+func DiscoverLAN(ctx context.Context, port uint16) ([]ServerInfo, error) {
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(TimeoutLAN)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetReadDeadline(deadline)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			// unblocks any pending read
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	broadcast := &net.UDPAddr{IP: lanBroadcastIP, Port: int(port)}
+	_, err = conn.WriteToUDP(NewTokenRequestPacket(), broadcast)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make(map[string]Token)
+	received := make(map[string]bool)
+	infos := make([]ServerInfo, 0, 8)
+	buffer := make([]byte, maxBufferSize)
+
+	for {
+		read, addr, err := conn.ReadFromUDP(buffer)
+		if ctx.Err() != nil {
+			return infos, ctx.Err()
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return infos, nil
+		} else if err != nil {
+			return infos, err
+		}
+
+		if read > maxPacketSize {
+			continue
+		}
+
+		response := buffer[:read]
+		match, err := MatchResponse(response)
+		if err != nil {
+			continue
+		}
+
+		key := addr.String()
+		switch match {
+		case "token":
+			if _, ok := tokens[key]; ok {
+				continue
+			}
+
+			token, err := ParseToken(response)
+			if err != nil {
+				continue
+			}
+			request, err := NewServerInfoRequestPacket(token)
+			if err != nil {
+				continue
+			}
+
+			tokens[key] = token
+			conn.WriteToUDP(request, addr)
+
+		case "serverinfo":
+			token, ok := tokens[key]
+			if !ok || received[key] || !token.IsResponseToken(response) {
+				continue
+			}
+
+			info, err := ParseServerInfo(response, key)
+			if err != nil {
+				continue
+			}
+
+			received[key] = true
+			infos = append(infos, info)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package browser
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDiscoverLAN(t *testing.T) {
+	want := ServerInfo{Version: "0.7.5", Name: "LAN server", Map: "ctf5", GameType: "CTF", MaxPlayers: 8, MaxClients: 8}
+	gs := newFakeGameServer(t, want)
+	defer gs.Close()
+
+	// broadcasting is not available in every environment, the loopback address
+	// reaches the fake server as well.
+	oldBroadcastIP, oldTimeout := lanBroadcastIP, TimeoutLAN
+	lanBroadcastIP, TimeoutLAN = gs.Addr().IP, 300*time.Millisecond
+	defer func() {
+		lanBroadcastIP, TimeoutLAN = oldBroadcastIP, oldTimeout
+	}()
+
+	infos, err := DiscoverLAN(context.Background(), uint16(gs.Addr().Port))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(infos) != 1 {
+		t.Fatalf("expected a single server, got %d", len(infos))
+	}
+
+	want.Address = gs.Addr().String()
+	if !infos[0].Equal(want) {
+		t.Fatalf("expected %s, got %s", want.String(), infos[0].String())
+	}
+}
+
+func TestDiscoverLANBroadcast(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping broadcast in short mode")
+	}
+
+	oldTimeout := TimeoutLAN
+	TimeoutLAN = 100 * time.Millisecond
+	defer func() {
+		TimeoutLAN = oldTimeout
+	}()
+
+	// there might not be any server in the local network, but the broadcast itself must work
+	_, err := DiscoverLAN(context.Background(), DefaultGamePort)
+	if err != nil {
+		t.Skipf("broadcast not available: %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package browser
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+const (
+	// legacyConnlessHeaderSize is the size of the 0.6 connectionless packet header, which consists of 0xff bytes only.
+	legacyConnlessHeaderSize = 6
+
+	legacySendInfo = "\xff\xff\xff\xffinf3"
+)
+
+var legacySendInfoRaw = []byte(legacySendInfo)
+
+// ParseLegacyServerInfo parses the server info response of a 0.6 server, which sends every field as string.
+//
+// The player and client counts are mapped as follows:
+//
+//	0.7: NumPlayers and MaxPlayers count the players in the game, NumClients and MaxClients also count the spectators.
+//	0.6: same as 0.7, every field is sent as a decimal string.
+//	0.6 responses without client counts, e.g. of old or modified servers: MaxClients mirrors MaxPlayers
+//	     and NumClients is the number of listed players.
+//
+// A 0.6 player whose "is player" field is 0 is a spectator and gets the Type 1, like in 0.7 responses.
+//
+// The extended server info of DDNet servers is detected by its "iext" header, in that case
+// the additional fields MapCRC and MapSize are set as well.
+func ParseLegacyServerInfo(serverResponse []byte, address string) (info ServerInfo, err error) {
+	if len(serverResponse) < legacyConnlessHeaderSize+len(legacySendInfoRaw) {
+		return ServerInfo{}, ErrInvalidResponseMessage
+	}
+
+	header := serverResponse[legacyConnlessHeaderSize : legacyConnlessHeaderSize+len(legacySendInfoRaw)]
+	extended := bytes.Equal(header, ddnetSendInfoExtendedRaw)
+	if !extended && !bytes.Equal(header, legacySendInfoRaw) {
+		return ServerInfo{}, ErrUnexpectedResponseHeader
+	}
+
+	fields := bytes.Split(serverResponse[legacyConnlessHeaderSize+len(legacySendInfoRaw):], delimiter)
+	// the last string is terminated as well
+	fields = fields[:len(fields)-1]
+
+	if extended {
+		return parseDDNetExtendedInfo(fields, address)
+	}
+
+	// token, version, name, map, game type, flags, num players, max players
+	const minFields = 8
+	if len(fields) < minFields {
+		return ServerInfo{}, fmt.Errorf("%w : expected at least %d fields got: %d", ErrMalformedResponseData, minFields, len(fields))
+	}
+
+	ints := make([]int, 0, 5)
+	for _, field := range [][]byte{fields[5], fields[6], fields[7]} {
+		i, err := strconv.Atoi(string(field))
+		if err != nil {
+			return ServerInfo{}, fmt.Errorf("%w : %v", ErrMalformedResponseData, err)
+		}
+		ints = append(ints, i)
+	}
+
+	info = ServerInfo{
+		Address:     address,
+		Version:     string(fields[1]),
+		Name:        string(fields[2]),
+		Map:         string(fields[3]),
+		GameType:    string(fields[4]),
+		ServerFlags: ServerFlags(ints[0]),
+		NumPlayers:  ints[1],
+		MaxPlayers:  ints[2],
+		NumClients:  ints[1],
+		MaxClients:  ints[2],
+	}
+	fields = fields[minFields:]
+
+	// num clients and max clients, unless the remaining fields are only players
+	mirrored := true
+	if len(fields) >= 2 && len(fields)%5 == 2 {
+		mirrored = false
+		numClients, err1 := strconv.Atoi(string(fields[0]))
+		maxClients, err2 := strconv.Atoi(string(fields[1]))
+		if err1 != nil || err2 != nil {
+			return ServerInfo{}, fmt.Errorf("%w : invalid client counts", ErrMalformedResponseData)
+		}
+		info.NumClients, info.MaxClients = numClients, maxClients
+		fields = fields[2:]
+	}
+
+	if len(fields)%5 != 0 {
+		return ServerInfo{}, fmt.Errorf("%w : expected 5 fields per player got: %d", ErrMalformedResponseData, len(fields))
+	}
+
+	info.Players = make([]PlayerInfo, 0, len(fields)/5)
+	for ; len(fields) > 0 && len(info.Players) < MaxServerInfoPlayers; fields = fields[5:] {
+		player, err := parseLegacyPlayer(fields[:5])
+		if err != nil {
+			return ServerInfo{}, err
+		}
+		info.Players = append(info.Players, player)
+	}
+
+	if mirrored {
+		info.NumClients = len(info.Players)
+	}
+	info.Online = true
+	return info, nil
+}
+
+// parseLegacyPlayer parses the name, clan, country, score and "is player" fields of a 0.6 player.
+func parseLegacyPlayer(fields [][]byte) (PlayerInfo, error) {
+	country, err1 := strconv.Atoi(string(fields[2]))
+	score, err2 := strconv.Atoi(string(fields[3]))
+	isPlayer, err3 := strconv.Atoi(string(fields[4]))
+	if err1 != nil || err2 != nil || err3 != nil {
+		return PlayerInfo{}, fmt.Errorf("%w : invalid player %q", ErrMalformedResponseData, fields[0])
+	}
+
+	player := PlayerInfo{
+		Name:    string(fields[0]),
+		Clan:    string(fields[1]),
+		Country: country,
+		Score:   score,
+	}
+	if isPlayer == 0 {
+		player.Type = 1
+	}
+	return player, nil
+}
@@ -0,0 +1,99 @@
+package browser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// packLegacyServerInfo creates a 0.6 server info response from its string fields.
+func packLegacyServerInfo(fields ...string) []byte {
+	return []byte("\xff\xff\xff\xff\xff\xff" + legacySendInfo + strings.Join(fields, "\x00") + "\x00")
+}
+
+func TestParseLegacyServerInfo(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  []string
+		want    [4]int // num players, max players, num clients, max clients
+		players []PlayerInfo
+	}{
+		{
+			"0.6 with spectator",
+			[]string{"0", "0.6.4", "name", "dm1", "DM", "0", "1", "8", "2", "12",
+				"alice", "clan", "276", "5", "1",
+				"bob", "", "-1", "0", "0"},
+			[4]int{1, 8, 2, 12},
+			[]PlayerInfo{{Name: "alice", Clan: "clan", Country: 276, Score: 5}, {Name: "bob", Country: -1, Type: 1}},
+		},
+		{
+			"0.6 empty",
+			[]string{"0", "0.6.4", "name", "dm1", "DM", "0", "0", "16", "0", "16"},
+			[4]int{0, 16, 0, 16},
+			[]PlayerInfo{},
+		},
+		{
+			"without client counts",
+			[]string{"0", "0.6.4", "name", "dm1", "DM", "1", "1", "8",
+				"alice", "clan", "276", "5", "1"},
+			[4]int{1, 8, 1, 8},
+			[]PlayerInfo{{Name: "alice", Clan: "clan", Country: 276, Score: 5}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := ParseLegacyServerInfo(packLegacyServerInfo(tt.fields...), "127.0.0.1:8303")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := [4]int{info.NumPlayers, info.MaxPlayers, info.NumClients, info.MaxClients}
+			if got != tt.want {
+				t.Errorf("counts = %v, want %v", got, tt.want)
+			}
+			want := ServerInfo{Address: "127.0.0.1:8303", Version: "0.6.4", Name: "name", Map: "dm1", GameType: "DM",
+				ServerFlags: info.ServerFlags, NumPlayers: tt.want[0], MaxPlayers: tt.want[1], NumClients: tt.want[2], MaxClients: tt.want[3],
+				Players: tt.players}
+			if !info.Equal(want) {
+				t.Errorf("ParseLegacyServerInfo() = %s, want %s", info.String(), want.String())
+			}
+		})
+	}
+}
+
+func TestParseLegacyServerInfoInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		response []byte
+		wantErr  error
+	}{
+		{"too short", []byte("\xff\xff\xff"), ErrInvalidResponseMessage},
+		{"0.7 header", append(packToken(1, 2), sendInfoRaw...), ErrUnexpectedResponseHeader},
+		{"missing fields", packLegacyServerInfo("0", "0.6.4", "name"), ErrMalformedResponseData},
+		{"invalid count", packLegacyServerInfo("0", "0.6.4", "name", "dm1", "DM", "0", "x", "8", "0", "8"), ErrMalformedResponseData},
+		{"incomplete player", packLegacyServerInfo("0", "0.6.4", "name", "dm1", "DM", "0", "1", "8", "1", "8", "alice", "clan"), ErrMalformedResponseData},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseLegacyServerInfo(tt.response, ""); !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestParseServerInfoCounts(t *testing.T) {
+	// 0.7: one player in the game and one spectator
+	sent := ServerInfo{Version: "0.7.5", Name: "name", Map: "ctf5", GameType: "CTF", NumPlayers: 1, MaxPlayers: 8, MaxClients: 12,
+		Players: []PlayerInfo{{Name: "alice", Score: 5}, {Name: "bob", Type: 1}}}
+	data, _ := sent.MarshalBinary()
+
+	info, err := ParseServerInfo(packInfoResponse(packToken(1, 2), 0, data), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := [4]int{info.NumPlayers, info.MaxPlayers, info.NumClients, info.MaxClients}, [4]int{1, 8, 2, 12}; got != want {
+		t.Errorf("counts = %v, want %v", got, want)
+	}
+}
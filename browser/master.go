@@ -0,0 +1,336 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// listPacketTimeout is the time that is waited for further server list packets
+// after the previous one has been received.
+// A master server sends its list in several packets of at most 75 servers each at once.
+var listPacketTimeout = 5 * minTimeout
+
+// MasterServerError reports that the server list of a master server could not be fetched.
+type MasterServerError struct {
+	// Addr is the ip:port address of the master server.
+	Addr string
+	Err  error
+}
+
+func (e *MasterServerError) Error() string {
+	return fmt.Sprintf("master server %s: %v", e.Addr, e.Err)
+}
+
+// Unwrap returns the reason why the server list could not be fetched.
+func (e *MasterServerError) Unwrap() error {
+	return e.Err
+}
+
+// MasterServerErrors contains the errors of all master servers that failed to send their server list.
+type MasterServerErrors []*MasterServerError
+
+func (e MasterServerErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// GetAllServers fetches the server lists of all master servers concurrently and returns the union
+// of the listed servers, addresses are unique by ip:port.
+// If some master servers fail, the servers of the others are returned alongside with MasterServerErrors,
+// which tells which master servers failed and why. If all of them fail, the error wraps ErrNoServerList.
+// The master servers, their timeout and concurrency and the address family can be configured with options.
+func GetAllServers(ctx context.Context, opts ...Option) ([]ServerAddress, error) {
+	o := newOptions(opts...)
+	o.resolveMasterServers()
+	if len(o.masterServers) == 0 {
+		return nil, ErrNoServerList
+	}
+
+	servers, errs := collectServerLists(ctx, o)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(errs) == len(o.masterServers) {
+		return nil, fmt.Errorf("%w: %v", ErrNoServerList, errs)
+	}
+
+	addrs := filterServers(servers, o.addressFamily)
+	if len(errs) > 0 {
+		return addrs, errs
+	}
+	return addrs, nil
+}
+
+// MasterServer is a connection to a single master server.
+// It keeps track of the token that is needed in order to request the server list.
+type MasterServer struct {
+	addr  *net.UDPAddr
+	conn  udpConn
+	token Token
+
+	// clientToken returns the client token of the next token handshake.
+	clientToken func() int
+	wait        WaitStrategy
+
+	// refreshAt is the time after which the token is refreshed before it is used.
+	refreshAt       time.Time
+	refreshInterval time.Duration
+}
+
+// NewMasterServer connects to the master server at address, e.g. "master1.teeworlds.com:8283".
+// If the port is omitted, DefaultMasterPort is used.
+// A hostname is resolved within the dial timeout, if it resolves to multiple addresses, the first one in the
+// order of WithAddressFamily is used.
+// Only WithTokenRefreshInterval, WithClientToken, WithWaitStrategy, WithSOCKS5, WithDialTimeout and
+// WithAddressFamily affect a MasterServer, other options are ignored.
+func NewMasterServer(address string, opts ...Option) (*MasterServer, error) {
+	o := newOptions(opts...)
+
+	hostname, port, err := splitHostPort(address, DefaultMasterPort)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", err, address)
+	}
+
+	ips, err := resolveHost(hostname, o.addressFamily, o.dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return newMasterServer(&net.UDPAddr{IP: ips[0], Port: port}, o)
+}
+
+func newMasterServer(addr *net.UDPAddr, o options) (*MasterServer, error) {
+	conn, err := o.dial(context.Background(), addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MasterServer{
+		addr:            addr,
+		conn:            conn,
+		clientToken:     o.clientToken,
+		wait:            o.wait,
+		refreshInterval: o.tokenRefreshInterval,
+	}, nil
+}
+
+// Addr returns the address of the master server.
+func (ms *MasterServer) Addr() *net.UDPAddr {
+	return ms.addr
+}
+
+// Close closes the connection to the master server.
+func (ms *MasterServer) Close() error {
+	return ms.conn.Close()
+}
+
+// RefreshToken fetches a new token from the master server.
+// The token is refreshed automatically when it expires or is not accepted anymore,
+// so calling this is usually not necessary.
+func (ms *MasterServer) RefreshToken() error {
+	return ms.RefreshTokenContext(context.Background())
+}
+
+// RefreshTokenContext fetches a new token like RefreshToken does, but waits at most until the deadline of ctx,
+// or TimeoutMasterServers if ctx has none. If ctx is done before the token has been received, ctx.Err() is returned.
+func (ms *MasterServer) RefreshTokenContext(ctx context.Context) error {
+	conn, stop := withContext(ctx, ms.conn)
+	defer stop()
+
+	err := ms.refreshToken(conn, contextTimeout(ctx, TimeoutMasterServers))
+	if err != nil {
+		return contextError(ctx, err)
+	}
+	return nil
+}
+
+func (ms *MasterServer) refreshToken(rwd ReadWriteDeadliner, timeout time.Duration) error {
+	resp, err := fetchToken(rwd, timeout, 0, ms.wait, ms.clientToken())
+	if err != nil {
+		return err
+	}
+
+	token, err := ParseToken(resp)
+	if err != nil {
+		return err
+	}
+	ms.token = token
+	ms.refreshAt = time.Now().Add(ms.refreshInterval)
+	return nil
+}
+
+// tokenNeedsRefresh returns true if the token expired or the refresh interval elapsed.
+func (ms *MasterServer) tokenNeedsRefresh() bool {
+	return ms.token.Expired() || !time.Now().Before(ms.refreshAt)
+}
+
+// GetServerList fetches the complete list of servers that are registered at the master server.
+// It is GetServerListContext without a deadline, which waits at most TimeoutMasterServers for the first packet.
+func (ms *MasterServer) GetServerList() ([]ServerAddress, error) {
+	return ms.GetServerListContext(context.Background())
+}
+
+// GetServerListContext fetches the complete list of servers like GetServerList does, but waits at most until
+// the deadline of ctx, or TimeoutMasterServers for the first packet if ctx has none.
+// If ctx is done before the list has been received, ctx.Err() is returned.
+func (ms *MasterServer) GetServerListContext(ctx context.Context) ([]ServerAddress, error) {
+	return ms.getServerList(ctx, -1)
+}
+
+// GetServerListN fetches the server list like GetServerList does, but stops as soon as n
+// server addresses have been collected. The returned subset is neither sorted nor prioritized,
+// it simply consists of the servers that happened to be received first.
+// If the master server knows less than n servers, all of them are returned.
+func (ms *MasterServer) GetServerListN(ctx context.Context, n int) ([]ServerAddress, error) {
+	if n < 1 {
+		return []ServerAddress{}, nil
+	}
+	return ms.getServerList(ctx, n)
+}
+
+// getServerList fetches the server list until no more packets arrive or limit servers
+// have been collected. A negative limit fetches the complete list.
+func (ms *MasterServer) getServerList(ctx context.Context, limit int) ([]ServerAddress, error) {
+	conn, stop := withContext(ctx, ms.conn)
+	defer stop()
+
+	resp, err := ms.requestServerList(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	unique := make(map[string]bool, maxServersPerMasterServer)
+	servers := make([]ServerAddress, 0, maxServersPerMasterServer)
+
+	for {
+		list, err := ParseServerAddresses(resp)
+		if err != nil {
+			return nil, err
+		}
+
+		// master servers without any registered servers send a single empty packet,
+		// there is no need to wait for further packets.
+		if len(list) == 0 {
+			return servers, nil
+		}
+
+		for _, srv := range list {
+			// requests are sent in bursts, which is why the same packet may be received multiple times.
+			key := srv.String()
+			if unique[key] {
+				continue
+			}
+			unique[key] = true
+			servers = append(servers, srv)
+
+			if len(servers) == limit {
+				return servers, nil
+			}
+		}
+
+		resp, err = ms.receiveListPacket(conn)
+		if isTimeout(err) {
+			// no more packets after having received at least one packet
+			return servers, nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// GetRawListPackets requests the server list and returns the received packets as they are,
+// including their token prefix, e.g. in order to inspect or replay them.
+// Unlike GetServerList, the packets are neither parsed nor deduplicated and it is always waited
+// for further packets until none arrive anymore.
+func (ms *MasterServer) GetRawListPackets(ctx context.Context) ([][]byte, error) {
+	conn, stop := withContext(ctx, ms.conn)
+	defer stop()
+
+	resp, err := ms.requestServerList(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	packets := make([][]byte, 0, 1)
+	for {
+		packets = append(packets, resp)
+
+		resp, err = ms.receiveListPacket(conn)
+		if isTimeout(err) {
+			return packets, nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// requestServerList requests the server list and returns its first packet. The token is refreshed
+// before if needed and once more if the master server does not respond to the current token.
+func (ms *MasterServer) requestServerList(ctx context.Context, conn *contextConn) ([]byte, error) {
+	timeout := contextTimeout(ctx, TimeoutMasterServers)
+	begin := time.Now()
+	refreshed := false
+	if ms.tokenNeedsRefresh() {
+		err := ms.refreshToken(conn, timeout)
+		if err != nil {
+			return nil, contextError(ctx, err)
+		}
+		refreshed = true
+	}
+
+	fetchTimeout := timeout - time.Since(begin)
+	if !refreshed {
+		// the master server silently drops requests with a token that it does not accept anymore,
+		// which is why half of the time is reserved for a single retry with a new token.
+		fetchTimeout /= 2
+	}
+
+	resp, _, err := fetchWithToken("serverlist", ms.token, conn, fetchTimeout, 0, ms.wait)
+	if err == ErrTimeout && !refreshed && ctx.Err() == nil {
+		err = ms.refreshToken(conn, timeout-time.Since(begin))
+		if err == nil {
+			resp, _, err = fetchWithToken("serverlist", ms.token, conn, timeout-time.Since(begin), 0, ms.wait)
+		}
+	}
+	if err != nil {
+		return nil, contextError(ctx, err)
+	}
+	return resp, nil
+}
+
+// receiveListPacket waits at most listPacketTimeout for the next server list packet.
+// Unrelated packets and list packets that do not echo our client token are ignored, as the latter are responses
+// to someone else's request. If no packet arrives in time, the returned error satisfies isTimeout.
+func (ms *MasterServer) receiveListPacket(conn *contextConn) ([]byte, error) {
+	conn.SetReadDeadline(time.Now().Add(listPacketTimeout))
+	resp, err := receiveWithToken("serverlist", ms.token, conn)
+	for err == ErrRequestResponseMismatch || err == ErrInvalidResponseMessage || err == ErrInvalidHeaderLength {
+		resp, err = receiveWithToken("serverlist", ms.token, conn)
+	}
+	return resp, err
+}
+
+// contextTimeout returns the time until the deadline of ctx, or fallback if ctx has no deadline.
+func contextTimeout(ctx context.Context, fallback time.Duration) time.Duration {
+	if deadline, ok := ctx.Deadline(); ok {
+		return time.Until(deadline)
+	}
+	return fallback
+}
+
+// contextError returns the error of ctx if it is done or its deadline has passed, as the timeout of a request
+// that ends at the deadline may expire right before ctx reports it. Otherwise err is returned.
+func contextError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if deadline, ok := ctx.Deadline(); ok && !time.Now().Before(deadline) {
+		return context.DeadlineExceeded
+	}
+	return err
+}
@@ -0,0 +1,433 @@
+package browser
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// newServerList creates a list of n distinct server addresses.
+func newServerList(n int) ServerList {
+	servers := make(ServerList, 0, n)
+	for i := 0; i < n; i++ {
+		servers = append(servers, &net.UDPAddr{
+			IP:   net.IPv4(10, 0, byte(i>>8), byte(i)).To4(),
+			Port: DefaultGamePort,
+		})
+	}
+	return servers
+}
+
+func TestMasterServer_GetServerList(t *testing.T) {
+	// more than fit into a single packet
+	servers := newServerList(3*maxServersPerMasterServer + 10)
+
+	fs := newFakeMasterServer(t, servers)
+	defer fs.Close()
+
+	ms, err := NewMasterServer(fs.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ms.Close()
+
+	list, err := ms.GetServerList()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != len(servers) {
+		t.Fatalf("GetServerList() returned %d servers, want %d", len(list), len(servers))
+	}
+	for idx, srv := range list {
+		if srv.String() != servers[idx].String() {
+			t.Errorf("GetServerList()[%d] = %s, want %s", idx, srv, servers[idx])
+		}
+	}
+}
+
+func TestMasterServer_GetServerListN(t *testing.T) {
+	servers := newServerList(3 * maxServersPerMasterServer)
+
+	fs := newFakeMasterServer(t, servers)
+	defer fs.Close()
+
+	ms, err := NewMasterServer(fs.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ms.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		name string
+		n    int
+		want int
+	}{
+		{"none", 0, 0},
+		{"within first packet", 10, 10},
+		{"across packets", maxServersPerMasterServer + 25, maxServersPerMasterServer + 25},
+		{"more than available", len(servers) + 100, len(servers)},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := ms.GetServerListN(ctx, tt.n)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(list) != tt.want {
+				t.Errorf("GetServerListN(%d) returned %d servers, want %d", tt.n, len(list), tt.want)
+			}
+		})
+	}
+}
+
+func TestMasterServer_GetServerListNCancel(t *testing.T) {
+	// never responds
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ms, err := NewMasterServer(conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ms.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	begin := time.Now()
+	_, err = ms.GetServerListN(ctx, 10)
+	if err != context.Canceled {
+		t.Errorf("GetServerListN() error = %v, want %v", err, context.Canceled)
+	}
+
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Errorf("GetServerListN() returned after %s, want it to return right after cancellation", elapsed)
+	}
+}
+
+func TestMasterServer_GetServerListEmpty(t *testing.T) {
+	fs := newFakeMasterServer(t, ServerList{})
+	defer fs.Close()
+
+	ms, err := NewMasterServer(fs.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ms.Close()
+
+	begin := time.Now()
+	list, err := ms.GetServerList()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if list == nil || len(list) != 0 {
+		t.Errorf("GetServerList() = %#v, want an empty, non-nil list", list)
+	}
+	if elapsed := time.Since(begin); elapsed >= listPacketTimeout {
+		t.Errorf("GetServerList() took %s, want it to return without waiting for further packets", elapsed)
+	}
+}
+
+func TestMasterServer_GetServerListRejectedToken(t *testing.T) {
+	servers := newServerList(10)
+
+	fs := newFakeServer(t)
+	fs.servers = servers
+	rotated := false
+	fs.beforeResponse = func(fs *fakeServer, addr *net.UDPAddr) {
+		// the token of the first request is not accepted anymore afterwards
+		if !rotated {
+			fs.serverToken++
+			rotated = true
+		}
+	}
+	fs.start()
+	defer fs.Close()
+
+	ms, err := NewMasterServer(fs.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ms.Close()
+
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		list, err := ms.GetServerListN(ctx, len(servers))
+		cancel()
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		if len(list) != len(servers) {
+			t.Fatalf("request %d: GetServerListN() returned %d servers, want %d", i, len(list), len(servers))
+		}
+	}
+}
+
+func TestMasterServer_TokenRefreshInterval(t *testing.T) {
+	fs := newFakeMasterServer(t, newServerList(1))
+	defer fs.Close()
+
+	ms, err := NewMasterServer(fs.Addr().String(), WithTokenRefreshInterval(time.Nanosecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ms.Close()
+
+	if _, err := ms.GetServerList(); err != nil {
+		t.Fatal(err)
+	}
+	token := ms.token
+
+	if _, err := ms.GetServerList(); err != nil {
+		t.Fatal(err)
+	}
+	if ms.token.Equal(token) {
+		t.Error("expected the token to be refreshed after the refresh interval elapsed")
+	}
+}
+
+func TestMasterServer_GetRawListPackets(t *testing.T) {
+	servers := newServerList(2*maxServersPerMasterServer + 10)
+
+	fs := newFakeMasterServer(t, servers)
+	defer fs.Close()
+
+	ms, err := NewMasterServer(fs.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ms.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	packets, err := ms.GetRawListPackets(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := packServerListPackets(packToken(fs.serverToken, ms.token.client), servers)
+	if len(packets) != len(want) {
+		t.Fatalf("GetRawListPackets() returned %d packets, want %d", len(packets), len(want))
+	}
+	for idx, packet := range packets {
+		if !bytes.Equal(packet, want[idx]) {
+			t.Errorf("GetRawListPackets()[%d] = %v, want %v", idx, packet, want[idx])
+		}
+	}
+}
+
+func TestMasterServer_GetServerListSilent(t *testing.T) {
+	// a single packet followed by silence
+	servers := newServerList(maxServersPerMasterServer / 2)
+	fs := newFakeMasterServer(t, servers)
+	defer fs.Close()
+
+	ms, err := NewMasterServer(fs.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ms.Close()
+
+	list, err := ms.GetServerList()
+	if err != nil {
+		t.Fatalf("GetServerList() error = %v, want the collected servers", err)
+	}
+	if len(list) != len(servers) {
+		t.Errorf("GetServerList() returned %d servers, want %d", len(list), len(servers))
+	}
+
+	// answers token requests, but never sends a server list
+	silent := newFakeServer(t)
+	silent.start()
+	defer silent.Close()
+
+	ms2, err := NewMasterServer(silent.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ms2.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	list, err = ms2.GetServerListN(ctx, 10)
+	if !isTimeout(err) && err != ErrTimeout {
+		t.Errorf("GetServerListN() error = %v, want a timeout", err)
+	}
+	if list != nil {
+		t.Errorf("GetServerListN() = %v, want nil", list)
+	}
+}
+
+func TestMasterServer_GetServerListSameIP(t *testing.T) {
+	ip := net.IPv4(10, 0, 0, 1).To4()
+
+	fs := newFakeServer(t)
+	fs.servers = ServerList{
+		{IP: ip, Port: 8303},
+		{IP: ip, Port: 8304},
+		{IP: ip, Port: 8303},
+	}
+	fs.start()
+	defer fs.Close()
+
+	ms, err := NewMasterServer(fs.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ms.Close()
+
+	servers, err := ms.GetServerList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(servers) != 2 || servers[0].String() != "10.0.0.1:8303" || servers[1].String() != "10.0.0.1:8304" {
+		t.Errorf("GetServerList() = %v, want 10.0.0.1:8303 and 10.0.0.1:8304", servers)
+	}
+}
+
+func TestMasterServer_GetServerListStrayPackets(t *testing.T) {
+	servers := newServerList(3*maxServersPerMasterServer + 10)
+	stray := ServerList{{IP: net.IPv4(192, 168, 0, 1).To4(), Port: DefaultGamePort}}
+
+	fs := newFakeServer(t)
+	fs.servers = servers
+	fs.strayList = stray
+	fs.start()
+	defer fs.Close()
+
+	ms, err := NewMasterServer(fs.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ms.Close()
+
+	list, err := ms.GetServerList()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != len(servers) {
+		t.Fatalf("GetServerList() returned %d servers, want %d", len(list), len(servers))
+	}
+	for idx, srv := range list {
+		if srv.String() != servers[idx].String() {
+			t.Errorf("GetServerList()[%d] = %s, want %s", idx, srv, servers[idx])
+		}
+	}
+}
+
+func TestMasterServer_Context(t *testing.T) {
+	// never responds
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ms, err := NewMasterServer(conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ms.Close()
+
+	tests := []struct {
+		name string
+		call func(ctx context.Context) error
+	}{
+		{"RefreshTokenContext", ms.RefreshTokenContext},
+		{"GetServerListContext", func(ctx context.Context) error {
+			_, err := ms.GetServerListContext(ctx)
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+" deadline", func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+
+			begin := time.Now()
+			if err := tt.call(ctx); err != context.DeadlineExceeded {
+				t.Errorf("%s() error = %v, want %v", tt.name, err, context.DeadlineExceeded)
+			}
+			if elapsed := time.Since(begin); elapsed > time.Second {
+				t.Errorf("%s() returned after %s, want it to return at the deadline", tt.name, elapsed)
+			}
+		})
+
+		t.Run(tt.name+" cancel", func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(100*time.Millisecond, cancel)
+
+			begin := time.Now()
+			if err := tt.call(ctx); err != context.Canceled {
+				t.Errorf("%s() error = %v, want %v", tt.name, err, context.Canceled)
+			}
+			if elapsed := time.Since(begin); elapsed > time.Second {
+				t.Errorf("%s() returned after %s, want it to return right after cancellation", tt.name, elapsed)
+			}
+		})
+	}
+}
+
+func TestGetAllServers(t *testing.T) {
+	servers := newServerList(2*maxServersPerMasterServer + 10)
+
+	first := newFakeMasterServer(t, servers[:maxServersPerMasterServer+10])
+	defer first.Close()
+	// overlaps with the first list
+	second := newFakeMasterServer(t, servers[maxServersPerMasterServer:])
+	defer second.Close()
+
+	// a master server that is down
+	offline := newFakeServer(t)
+	offline.Close()
+
+	got, err := GetAllServers(context.Background(),
+		WithMasterServers(first.Addr(), offline.Addr(), second.Addr()),
+		WithMasterServerTimeout(500*time.Millisecond),
+	)
+
+	var errs MasterServerErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("GetAllServers() error = %v, want MasterServerErrors", err)
+	}
+	if len(errs) != 1 || errs[0].Addr != offline.Addr().String() {
+		t.Errorf("GetAllServers() error = %v, want an error of %s", err, offline.Addr())
+	}
+
+	if len(got) != len(servers) {
+		t.Fatalf("GetAllServers() returned %d servers, want %d", len(got), len(servers))
+	}
+	unique := make(map[string]bool, len(got))
+	for _, addr := range got {
+		unique[addr.String()] = true
+	}
+	for _, srv := range servers {
+		if !unique[srv.String()] {
+			t.Errorf("GetAllServers() is missing %s", srv)
+		}
+	}
+
+	got, err = GetAllServers(context.Background(),
+		WithMasterServers(offline.Addr()),
+		WithMasterServerTimeout(200*time.Millisecond),
+	)
+	if !errors.Is(err, ErrNoServerList) || got != nil {
+		t.Errorf("GetAllServers() = %v, %v, want %v", got, err, ErrNoServerList)
+	}
+}
@@ -0,0 +1,30 @@
+package browser
+
+import "time"
+
+// Metrics is notified about every game server query, which allows to monitor the health of scans.
+// Implementations must be safe for concurrent use, as servers are queried concurrently.
+// The interface does not depend on any metrics library, an adapter to e.g. Prometheus
+// only needs to increment its counters and observe its histogram.
+type Metrics interface {
+	// QuerySent is called when a server is about to be queried.
+	QuerySent()
+
+	// ResponseReceived is called when a server responded with its info. rtt is the round-trip time
+	// of the info request, the same as ServerInfo.Ping.
+	ResponseReceived(rtt time.Duration)
+
+	// Timeout is called when a server did not respond in time.
+	Timeout()
+
+	// ParseError is called when a server responded with malformed data.
+	ParseError()
+}
+
+// noopMetrics is used if no Metrics are configured.
+type noopMetrics struct{}
+
+func (noopMetrics) QuerySent()                         {}
+func (noopMetrics) ResponseReceived(rtt time.Duration) {}
+func (noopMetrics) Timeout()                           {}
+func (noopMetrics) ParseError()                        {}
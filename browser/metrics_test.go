@@ -0,0 +1,78 @@
+package browser
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeMetrics struct {
+	queries, responses, timeouts, parseErrors, rtts int64
+}
+
+func (m *fakeMetrics) QuerySent() { atomic.AddInt64(&m.queries, 1) }
+func (m *fakeMetrics) ResponseReceived(rtt time.Duration) {
+	atomic.AddInt64(&m.responses, 1)
+	if rtt > 0 {
+		atomic.AddInt64(&m.rtts, 1)
+	}
+}
+func (m *fakeMetrics) Timeout()    { atomic.AddInt64(&m.timeouts, 1) }
+func (m *fakeMetrics) ParseError() { atomic.AddInt64(&m.parseErrors, 1) }
+
+func TestWithMetrics(t *testing.T) {
+	servers := make(ServerList, 0, 4)
+	for i := 0; i < 2; i++ {
+		gs := newFakeGameServer(t, ServerInfo{Version: "0.7.5", Name: "server"})
+		defer gs.Close()
+		servers = append(servers, gs.Addr())
+	}
+
+	broken := newFakeServer(t)
+	broken.rawInfo = []byte("malformed")
+	broken.start()
+	defer broken.Close()
+	servers = append(servers, broken.Addr())
+
+	silent, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer silent.Close()
+	servers = append(servers, silent.LocalAddr().(*net.UDPAddr))
+
+	ms := newFakeMasterServer(t, servers)
+	defer ms.Close()
+
+	metrics := &fakeMetrics{}
+	infos, err := ListServersWithInfo(context.Background(),
+		WithMasterServers(ms.Addr()),
+		WithQueryTimeout(200*time.Millisecond),
+		WithMetrics(metrics),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("ListServersWithInfo() returned %d infos, want %d", len(infos), 2)
+	}
+
+	tests := []struct {
+		name  string
+		value int64
+		want  int64
+	}{
+		{"queries", metrics.queries, 4},
+		{"responses", metrics.responses, 3},
+		{"rtts", metrics.rtts, 3},
+		{"timeouts", metrics.timeouts, 1},
+		{"parse errors", metrics.parseErrors, 1},
+	}
+	for _, tt := range tests {
+		if tt.value != tt.want {
+			t.Errorf("%s = %d, want %d", tt.name, tt.value, tt.want)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+package browser
+
+import (
+	"math/rand"
+	"net"
+	"time"
+)
+
+const (
+	// defaultConcurrency is the default number of servers that are queried at the same time.
+	defaultConcurrency = 256
+
+	// defaultDialTimeout is the default time that resolving a hostname may take.
+	defaultDialTimeout = 5 * time.Second
+)
+
+// Option configures the high level functions like ListServersWithInfo.
+type Option func(*options)
+
+type options struct {
+	masterServers       []*net.UDPAddr
+	customMasters       bool
+	masterServerTimeout time.Duration
+	dialTimeout         time.Duration
+	queryTimeout        time.Duration
+	extInfoTimeout      time.Duration
+	concurrency         int
+	masterConcurrency   int
+	jitter              float64
+	metrics             Metrics
+	addressFamily       AddressFamily
+	includeOffline      bool
+	wait                WaitStrategy
+
+	rateLimit       float64
+	recrawlInterval time.Duration
+
+	tokenRefreshInterval  time.Duration
+	fastestMasterInterval time.Duration
+	clientToken           func() int
+
+	socks5Addr string
+	socks5Auth *SOCKS5Auth
+	dial       dialFunc
+
+	readBuffer  int
+	writeBuffer int
+}
+
+func newOptions(opts ...Option) options {
+	o := options{
+		masterServers:       MasterServerAddresses,
+		masterServerTimeout: TimeoutMasterServers,
+		dialTimeout:         defaultDialTimeout,
+		queryTimeout:        TimeoutServers,
+		concurrency:         defaultConcurrency,
+		metrics:             noopMetrics{},
+		wait:                TimeoutWait{},
+
+		tokenRefreshInterval: TokenExpirationDuration,
+		clientToken:          GenerateClientToken,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	o.dial = dialUDP
+	if o.socks5Addr != "" {
+		o.dial = socks5Dialer(o.socks5Addr, o.socks5Auth, o.dialTimeout)
+	}
+	if o.readBuffer > 0 || o.writeBuffer > 0 {
+		o.dial = withSocketBuffers(o.dial, o.readBuffer, o.writeBuffer)
+	}
+	return o
+}
+
+// WithMasterServers sets the master servers that are asked for their server lists.
+// By default the resolved MasterServerAddresses are used, unless WithAddressFamily prefers
+// an address family, which resolves the default master servers again in its order.
+func WithMasterServers(addrs ...*net.UDPAddr) Option {
+	return func(o *options) {
+		o.masterServers = addrs
+		o.customMasters = true
+	}
+}
+
+// WithMasterServerTimeout sets the time that is waited for a master server to respond.
+// By default TimeoutMasterServers is used.
+func WithMasterServerTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.masterServerTimeout = timeout
+	}
+}
+
+// WithDialTimeout sets the time that is waited for a hostname to be resolved, before a server is queried.
+// Unlike the query timeout it does not include the time that is waited for a response, so that unresolvable
+// hosts fail fast with ErrDialTimeout. Defaults to 5 seconds, values below 1 are ignored.
+func WithDialTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		if timeout > 0 {
+			o.dialTimeout = timeout
+		}
+	}
+}
+
+// WithSOCKS5 sends all master server and game server queries via the UDP association of the SOCKS5 proxy
+// at addr, e.g. "127.0.0.1:1080". auth may be nil for proxies that do not require an authentication.
+// Connecting to the proxy is limited by the dial timeout.
+func WithSOCKS5(addr string, auth *SOCKS5Auth) Option {
+	return func(o *options) {
+		o.socks5Addr = addr
+		o.socks5Auth = auth
+	}
+}
+
+// WithQueryTimeout sets the time that is waited for a single game server to respond.
+// By default TimeoutServers is used.
+func WithQueryTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.queryTimeout = timeout
+	}
+}
+
+// WithExtInfoTimeout sets the time that GetServerInfoExtended waits for the next packet of an extended server info
+// after it received a packet, independently of the retry interval of the wait strategy. Servers with many clients
+// send their info in several packets, which may arrive slower than the retry interval, especially if the server is busy.
+// The query timeout still limits the total time of the query. Values of 0 and below disable the extension,
+// which is the default, in that case the packets are waited for until the retry interval elapsed.
+func WithExtInfoTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.extInfoTimeout = timeout
+	}
+}
+
+// WithConcurrency sets the maximum number of game servers that are queried at the same time.
+// Values below 1 are ignored.
+func WithConcurrency(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.concurrency = n
+		}
+	}
+}
+
+// WithMasterConcurrency sets the maximum number of master servers that are queried at the same time,
+// e.g. 1 in order to query them one after another on constrained networks.
+// By default all master servers are queried at once, values below 1 restore the default.
+func WithMasterConcurrency(n int) Option {
+	return func(o *options) {
+		o.masterConcurrency = n
+	}
+}
+
+// WithJitter randomizes the query timeout and the retry intervals of every game server query
+// by up to the passed fraction, e.g. 0.1 results in durations between 90% and 110% of their
+// original value. This spreads out the retries of many concurrent queries, which would otherwise
+// be sent in synchronized bursts. The fraction is limited to the range [0, 1], jitter is disabled by default.
+func WithJitter(fraction float64) Option {
+	return func(o *options) {
+		switch {
+		case fraction < 0:
+			o.jitter = 0
+		case fraction > 1:
+			o.jitter = 1
+		default:
+			o.jitter = fraction
+		}
+	}
+}
+
+// WithTokenRefreshInterval sets the time after which a MasterServer fetches a new token before
+// requesting the server list, even if the token did not expire yet.
+// By default the token is refreshed once it expires. Values below 1 are ignored.
+func WithTokenRefreshInterval(interval time.Duration) Option {
+	return func(o *options) {
+		if interval > 0 {
+			o.tokenRefreshInterval = interval
+		}
+	}
+}
+
+// WithClientToken pins the client token of every token handshake, e.g. in order to match the requests
+// of a test server. By default every handshake uses a new token of GenerateClientToken, which is
+// why pinning the token should be avoided outside of tests.
+func WithClientToken(token int) Option {
+	return func(o *options) {
+		o.clientToken = func() int {
+			return token
+		}
+	}
+}
+
+// WithFastestMaster fetches the server list only from the master server that responds fastest
+// instead of all master servers, see FastestMaster. The fastest master server is remembered
+// and evaluated again after the passed interval. If it fails to send its list, the list is
+// fetched from all master servers and the fastest one is evaluated again next time.
+// Values below 1 disable the selection, which is the default.
+func WithFastestMaster(reevaluate time.Duration) Option {
+	return func(o *options) {
+		o.fastestMasterInterval = reevaluate
+	}
+}
+
+// AddressFamily defines which IP versions are used if both are available.
+type AddressFamily int
+
+const (
+	// AddressFamilyAuto uses the addresses in the order they were resolved or listed.
+	AddressFamilyAuto AddressFamily = iota
+
+	// PreferIPv4 uses IPv4 addresses before IPv6 addresses.
+	PreferIPv4
+
+	// PreferIPv6 uses IPv6 addresses before IPv4 addresses.
+	PreferIPv6
+
+	// IPv4Only ignores all IPv6 addresses.
+	IPv4Only
+
+	// IPv6Only ignores all IPv4 addresses.
+	IPv6Only
+)
+
+// filter returns the IPs in the order of the preferred address family without the excluded addresses.
+// The order within an address family is kept.
+func (f AddressFamily) filter(ips []net.IP) []net.IP {
+	v4 := make([]net.IP, 0, len(ips))
+	v6 := make([]net.IP, 0, len(ips))
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			v4 = append(v4, ip)
+		} else {
+			v6 = append(v6, ip)
+		}
+	}
+
+	switch f {
+	case PreferIPv4:
+		return append(v4, v6...)
+	case PreferIPv6:
+		return append(v6, v4...)
+	case IPv4Only:
+		return v4
+	case IPv6Only:
+		return v6
+	default:
+		return ips
+	}
+}
+
+// WithAddressFamily sets the IP version that is preferred when a hostname resolves to addresses of both versions.
+// This applies to the hostnames of game servers and master servers, including the default master servers.
+// IPv4Only and IPv6Only additionally leave out the game servers of the other version from the server lists.
+// By default AddressFamilyAuto is used.
+func WithAddressFamily(family AddressFamily) Option {
+	return func(o *options) {
+		o.addressFamily = family
+	}
+}
+
+// resolveMasterServers resolves the hostnames of the default master servers again in the order of the address family,
+// as MasterServerAddresses are resolved when the package is loaded, without knowing the preferred family.
+// Master servers that are set with WithMasterServers are kept as they are, as well as the defaults of AddressFamilyAuto.
+func (o *options) resolveMasterServers() {
+	if o.customMasters || o.addressFamily == AddressFamilyAuto {
+		return
+	}
+
+	addrs := make([]*net.UDPAddr, 0, len(masterServerHostnames))
+	for _, hostname := range masterServerHostnames {
+		ips, err := resolveHost(hostname, o.addressFamily, o.dialTimeout)
+		if err != nil {
+			continue
+		}
+		addrs = append(addrs, &net.UDPAddr{IP: ips[0], Port: DefaultMasterPort})
+	}
+	o.masterServers = addrs
+}
+
+// WithSocketBuffers sets the sizes of the operating system's receive and send buffers of every UDP socket
+// that is opened to a master or game server, e.g. in order to not drop responses when many servers are
+// queried at once. Values below 1 keep the default of the package.
+// The operating system may cap the sizes: Linux limits them to net.core.rmem_max and net.core.wmem_max
+// and doubles the requested values for its bookkeeping, macOS limits them to kern.ipc.maxsockbuf and
+// rejects larger values, in which case the default sizes are kept. Failing to set a size is not an error.
+func WithSocketBuffers(readBytes, writeBytes int) Option {
+	return func(o *options) {
+		o.readBuffer = readBytes
+		o.writeBuffer = writeBytes
+	}
+}
+
+// WithWaitStrategy sets the WaitStrategy that decides how long the queries of master and game servers
+// wait for responses, e.g. an AdaptiveWait that is shared by all queries of a scan.
+// A nil value restores the default TimeoutWait.
+func WithWaitStrategy(s WaitStrategy) Option {
+	return func(o *options) {
+		if s == nil {
+			s = TimeoutWait{}
+		}
+		o.wait = s
+	}
+}
+
+// WithIncludeOffline adds a ServerInfo for every listed server that did not respond to its info query,
+// e.g. in order to show unresponsive servers as offline instead of leaving them out.
+// Such an info only contains the server's Address and the query's Err, its Online field is false.
+// Servers whose query was interrupted by a cancelled context are left out nonetheless.
+func WithIncludeOffline(include bool) Option {
+	return func(o *options) {
+		o.includeOffline = include
+	}
+}
+
+// WithRateLimit limits the number of game servers that a Crawler queries per second, e.g. in order to
+// not flood the network or to stay below the limits of a hoster. The limit applies in addition to
+// WithConcurrency. Values of 0 and below disable the limit, which is the default.
+func WithRateLimit(qps float64) Option {
+	return func(o *options) {
+		o.rateLimit = qps
+	}
+}
+
+// WithRecrawlInterval caches the info of every server that responded to a Crawler for the passed interval.
+// A server that is pending again within that interval is not queried, its cached info is sent instead,
+// which is why repeated crawls only query the servers whose info is outdated.
+// Values of 0 and below disable the cache, which is the default.
+func WithRecrawlInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.recrawlInterval = interval
+	}
+}
+
+// WithMetrics sets the Metrics that are notified about every game server query.
+// A nil value disables metrics, which is the default.
+func WithMetrics(m Metrics) Option {
+	return func(o *options) {
+		if m == nil {
+			m = noopMetrics{}
+		}
+		o.metrics = m
+	}
+}
+
+// jitterDuration randomly scales d by a factor in the range [1-fraction, 1+fraction].
+func jitterDuration(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 {
+		return d
+	}
+	factor := 1 + fraction*(2*rand.Float64()-1)
+	return time.Duration(float64(d) * factor)
+}
@@ -0,0 +1,64 @@
+package browser
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// sysctlInt reads an integer kernel parameter, e.g. "net/core/rmem_max".
+func sysctlInt(t *testing.T, name string) int {
+	data, err := ioutil.ReadFile("/proc/sys/" + name)
+	if err != nil {
+		t.Skipf("cannot read %s: %v", name, err)
+	}
+	i, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Skipf("cannot parse %s: %v", name, err)
+	}
+	return i
+}
+
+// socketBuffers returns the sizes of the receive and send buffers of conn as reported by the kernel.
+func socketBuffers(t *testing.T, conn *net.UDPConn) (read, write int) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var readErr, writeErr error
+	err = raw.Control(func(fd uintptr) {
+		read, readErr = syscall.GetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_RCVBUF)
+		write, writeErr = syscall.GetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_SNDBUF)
+	})
+	for _, err := range []error{err, readErr, writeErr} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return read, write
+}
+
+func TestWithSocketBuffers(t *testing.T) {
+	const readBytes, writeBytes = 128 << 10, 96 << 10
+	if sysctlInt(t, "net/core/rmem_max") < readBytes || sysctlInt(t, "net/core/wmem_max") < writeBytes {
+		t.Skip("the kernel caps the buffers below the tested sizes")
+	}
+
+	o := newOptions(WithSocketBuffers(readBytes, writeBytes))
+	conn, err := o.dial(context.Background(), &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: DefaultGamePort})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Linux reports twice the requested sizes
+	read, write := socketBuffers(t, conn.(*net.UDPConn))
+	if read < readBytes || write < writeBytes {
+		t.Errorf("expected buffers of at least %d and %d bytes, got %d and %d", readBytes, writeBytes, read, write)
+	}
+}
@@ -0,0 +1,77 @@
+package browser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithJitter(t *testing.T) {
+	tests := []struct {
+		name     string
+		fraction float64
+		want     float64
+	}{
+		{"disabled", 0, 0},
+		{"negative", -0.5, 0},
+		{"fraction", 0.2, 0.2},
+		{"too large", 1.5, 1},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			o := newOptions(WithJitter(tt.fraction))
+			if o.jitter != tt.want {
+				t.Errorf("WithJitter(%v) = %v, want %v", tt.fraction, o.jitter, tt.want)
+			}
+		})
+	}
+}
+
+func Test_jitterDuration(t *testing.T) {
+	const (
+		d        = time.Second
+		fraction = 0.2
+	)
+
+	if got := jitterDuration(d, 0); got != d {
+		t.Errorf("jitterDuration() without jitter = %s, want %s", got, d)
+	}
+
+	min := time.Duration(float64(d) * (1 - fraction))
+	max := time.Duration(float64(d) * (1 + fraction))
+
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 1000; i++ {
+		got := jitterDuration(d, fraction)
+		if got < min || got > max {
+			t.Fatalf("jitterDuration() = %s, want it to be within [%s, %s]", got, min, max)
+		}
+		seen[got] = true
+	}
+
+	if len(seen) < 2 {
+		t.Errorf("jitterDuration() always returned the same duration")
+	}
+}
+
+func TestWithClientToken(t *testing.T) {
+	fs := newFakeServer(t)
+	fs.start()
+	defer fs.Close()
+
+	const pinned = 0x0c0ffee0
+	ms, err := NewMasterServer(fs.Addr().String(), WithClientToken(pinned))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ms.Close()
+
+	for i := 0; i < 2; i++ {
+		if err := ms.RefreshToken(); err != nil {
+			t.Fatal(err)
+		}
+		if ms.token.client != pinned {
+			t.Errorf("expected the pinned client token %#x, got %#x", pinned, ms.token.client)
+		}
+	}
+}
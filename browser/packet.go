@@ -2,18 +2,39 @@ package browser
 
 import (
 	"bytes"
-	"math/rand"
-	"net"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
 	"time"
+
+	"github.com/jxsl13/twapi/compression"
 )
 
+// GenerateClientToken returns a random, non-negative client token for the token handshake.
+// The server echoes the client token in its responses, which is why it must not be guessable
+// by anyone who wants to spoof responses, nor collide with the tokens of concurrent handshakes.
+// The token is read from crypto/rand. If that fails, GenerateClientToken panics instead of
+// falling back to a predictable token.
+func GenerateClientToken() int {
+	var b [4]byte
+	if _, err := io.ReadFull(randReader, b[:]); err != nil {
+		panic(fmt.Sprintf("browser: failed to generate a client token: %v", err))
+	}
+	return int(binary.BigEndian.Uint32(b[:]) & 0x7fffffff)
+}
+
+// randReader is the source of the client tokens.
+var randReader = rand.Reader
+
 // NewTokenRequestPacket generates a new token request packet that can be
 // used to request for a new server token
 func NewTokenRequestPacket() TokenRequestPacket {
-	seedSource := rand.NewSource(time.Now().UnixNano())
-	randomNumberGenerator := rand.New(seedSource)
+	return newTokenRequestPacket(GenerateClientToken())
+}
 
-	clientToken := int(randomNumberGenerator.Int31())
+// newTokenRequestPacket creates a token request packet with the given client token.
+func newTokenRequestPacket(clientToken int) TokenRequestPacket {
 	serverToken := -1
 
 	header := packTokenRequest(clientToken, serverToken)
@@ -55,11 +76,20 @@ func NewServerInfoRequestPacket(t Token) (ServerInfoRequestPacket, error) {
 		return ServerInfoRequestPacket{}, ErrTokenExpired
 	}
 
-	payload := make([]byte, 0, len(requestInfoRaw)+len(t.Payload))
+	return ServerInfoRequestPacket(newInfoRequest(t, 0)), nil
+}
+
+// newInfoRequest creates the connectionless info request that consists of the token prefix,
+// the "gie3" request and the packed extra token, which the server echoes in its response.
+func newInfoRequest(t Token, extraToken int) []byte {
+	var v compression.VarInt
+	v.Pack(extraToken)
+
+	payload := make([]byte, 0, len(t.Payload)+len(requestInfoRaw)+v.Size())
 	payload = append(payload, t.Payload...)
 	payload = append(payload, requestInfoRaw...)
-
-	return ServerInfoRequestPacket(payload), nil
+	payload = append(payload, v.Bytes()...)
+	return payload
 }
 
 // ParseToken creates a new token from a response message that was sent by a server that was
@@ -83,12 +113,21 @@ func ParseToken(serverResponse []byte) (Token, error) {
 
 // ParseServerList parses the response server list
 func ParseServerList(serverResponse []byte) (ServerList, error) {
+	addrs, err := ParseServerAddresses(serverResponse)
+	if err != nil {
+		return nil, err
+	}
+	return udpAddrs(addrs), nil
+}
+
+// ParseServerAddresses parses a server list response into the addresses of its servers.
+// Each server is listed with 16 bytes for its IP, IPv4 addresses are mapped into IPv6 addresses,
+// followed by 2 bytes for its port. Trailing bytes that do not form a complete address are ignored.
+func ParseServerAddresses(serverResponse []byte) ([]ServerAddress, error) {
 	if len(serverResponse) < tokenPrefixSize+len(sendServerListRaw) {
 		return nil, ErrInvalidResponseMessage
 	}
 
-	//newTokenFromFollowUpRequest(serverResponse[:tokenPrefixSize])
-
 	responseHeaderRaw := serverResponse[tokenPrefixSize : tokenPrefixSize+len(sendServerListRaw)]
 
 	if !bytes.Equal(responseHeaderRaw, sendServerListRaw) {
@@ -97,40 +136,12 @@ func ParseServerList(serverResponse []byte) (ServerList, error) {
 
 	data := serverResponse[tokenPrefixSize+len(sendServerListRaw):]
 
-	/*
-		each server information contains of 18 bytes
-		first 16 bytes define the IP
-		the last 2 bytes define the port
-
-		if the first 12 bytes match the defined pefix, the IP is parsed as IPv4
-		and if it does not match, the IP is parsed as IPv6
-	*/
-	numServers := len(data) / 18 // 18 bytes, 16 for IPv4/IPv6 and 2 bytes for the port
-	serverList := make([]*net.UDPAddr, 0, numServers)
-
-	// fixed size array
-	ipv4Prefix := [12]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xFF, 0xFF}
-
-	for idx := 0; idx < numServers; idx++ {
-		var ip []byte
-
-		// compare byte slices
-		if bytes.Equal(ipv4Prefix[:], data[idx*18:idx*18+12]) {
-			// IPv4 has a prefix
-			ip = data[idx*18+12 : idx*18+16]
-		} else {
-			// full IP is the IPv6 otherwise
-			ip = data[idx*18 : idx*18+16]
-		}
-
-		serverList = append(serverList, &net.UDPAddr{
-			IP:   ip,
-			Port: (int(data[idx*18+16]) << 8) + int(data[idx*18+17]),
-		})
+	addrs := make([]ServerAddress, len(data)/serverAddressSize)
+	for idx := range addrs {
+		// cannot fail, the size is always correct
+		addrs[idx].UnmarshalBinary(data[idx*serverAddressSize : (idx+1)*serverAddressSize])
 	}
-
-	return serverList, nil
-
+	return addrs, nil
 }
 
 // ParseServerCount parses the response and returns the number of currently registered servers.
@@ -160,25 +171,41 @@ func ParseServerCount(serverResponse []byte) (int, error) {
 }
 
 // ParseServerInfo parses the serrver's server info response
+// The extra token that the server echoes from the request is skipped, whatever its value is.
 func ParseServerInfo(serverResponse []byte, address string) (info ServerInfo, err error) {
+	_, data, err := splitInfoResponse(serverResponse)
+	if err != nil {
+		return ServerInfo{}, err
+	}
+
+	err = info.UnmarshalBinary(data)
+	if err != nil {
+		return ServerInfo{}, err
+	}
+	info.Address = address
+	info.Online = true
+	return
+}
+
+// splitInfoResponse verifies the header of an info response and splits the rest into the extra token,
+// which the server echoes from the request, and the server info.
+func splitInfoResponse(serverResponse []byte) (extraToken int, data []byte, err error) {
 	if len(serverResponse) < tokenPrefixSize+len(sendInfoRaw) {
-		return ServerInfo{}, ErrInvalidResponseMessage
+		return 0, nil, ErrInvalidResponseMessage
 	}
 
 	responseHeaderRaw := serverResponse[tokenPrefixSize : tokenPrefixSize+len(sendInfoRaw)]
 
 	if !bytes.Equal(responseHeaderRaw, sendInfoRaw) {
-		return ServerInfo{}, ErrUnexpectedResponseHeader
+		return 0, nil, ErrUnexpectedResponseHeader
 	}
 
-	data := serverResponse[tokenPrefixSize+len(sendInfoRaw):]
-
-	err = info.UnmarshalBinary(data)
+	u := compression.NewUnpacker(serverResponse[tokenPrefixSize+len(sendInfoRaw):], false)
+	extraToken, err = u.NextInt()
 	if err != nil {
-		return ServerInfo{}, err
+		return 0, nil, fmt.Errorf("%w : missing extra token", ErrMalformedResponseData)
 	}
-	info.Address = address
-	return
+	return extraToken, u.Buffer, nil
 }
 
 // packs header
@@ -239,3 +266,23 @@ func packToken(tokenClient, tokenServer int) (header []byte) {
 
 	return
 }
+
+// StripConnless recognizes a 0.7 connectionless packet by its flag and version bits and returns the token
+// that it is addressed to, which the server echoes from our requests, and the payload that follows the header.
+// ok is false for packets that are not connectionless, e.g. token responses, for 0.6 packets
+// and for packets that are shorter than the header. It is the inverse of the header that packToken creates.
+func StripConnless(b []byte) (token int, payload []byte, ok bool) {
+	const netPacketFlagConnless = 8
+	const netPacketVersion = 1
+
+	if len(b) < tokenPrefixSize {
+		return 0, nil, false
+	}
+	flags := b[0] >> 2
+	if flags&netPacketFlagConnless == 0 || b[0]&0b11 != netPacketVersion {
+		return 0, nil, false
+	}
+
+	token = (int(b[1]) << 24) + (int(b[2]) << 16) + (int(b[3]) << 8) + int(b[4])
+	return token, b[tokenPrefixSize:], true
+}
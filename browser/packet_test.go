@@ -1,6 +1,9 @@
 package browser
 
 import (
+	"bytes"
+	"errors"
+	"io"
 	"reflect"
 	"testing"
 )
@@ -162,3 +165,174 @@ func TestParseServerList(t *testing.T) {
 		})
 	}
 }
+
+func TestParseServerAddresses(t *testing.T) {
+	prefix := append(packToken(1, 2), sendServerListRaw...)
+
+	var resp []byte
+	resp = append(resp, prefix...)
+	// IPv4-mapped 10.0.0.1:8303
+	resp = append(resp, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff, 10, 0, 0, 1, 0x20, 0x6f)
+	// 2001:db8::2:8304
+	resp = append(resp, 0x20, 0x01, 0x0d, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 0x20, 0x70)
+	// truncated trailing address
+	resp = append(resp, 0, 0, 0, 0, 0)
+
+	addrs, err := ParseServerAddresses(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"10.0.0.1:8303", "[2001:db8::2]:8304"}
+	if len(addrs) != len(want) {
+		t.Fatalf("ParseServerAddresses() = %v, want %v", addrs, want)
+	}
+	for idx, addr := range addrs {
+		if addr.String() != want[idx] {
+			t.Errorf("ParseServerAddresses()[%d] = %s, want %s", idx, addr, want[idx])
+		}
+	}
+	if len(addrs[0].IP) != 4 {
+		t.Errorf("expected a 4 byte IPv4 address, got %d bytes", len(addrs[0].IP))
+	}
+
+	list, err := ParseServerList(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != len(addrs) {
+		t.Fatalf("ParseServerList() = %v, want %v", list, addrs)
+	}
+	for idx, srv := range list {
+		if !srv.IP.Equal(addrs[idx].IP) || srv.Port != int(addrs[idx].Port) {
+			t.Errorf("ParseServerList()[%d] = %s, want %s", idx, srv, addrs[idx])
+		}
+	}
+
+	if _, err := ParseServerAddresses(append(packToken(1, 2), sendServerCountRaw...)); err != ErrUnexpectedResponseHeader {
+		t.Errorf("expected %v, got %v", ErrUnexpectedResponseHeader, err)
+	}
+	if _, err := ParseServerAddresses(prefix[:len(prefix)-1]); err != ErrInvalidResponseMessage {
+		t.Errorf("expected %v, got %v", ErrInvalidResponseMessage, err)
+	}
+}
+
+func Test_newInfoRequest(t *testing.T) {
+	token, err := ParseToken([]byte{0x04, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x0a, 0x0b, 0x0c, 0x0d})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prefix := []byte{
+		0x21,                   // connless flag and version
+		0x0a, 0x0b, 0x0c, 0x0d, // server token
+		0x01, 0x02, 0x03, 0x04, // client token
+		0xff, 0xff, 0xff, 0xff, 'g', 'i', 'e', '3',
+	}
+
+	tests := []struct {
+		name       string
+		extraToken int
+		want       []byte
+	}{
+		{"zero extra token", 0, append(prefix[:len(prefix):len(prefix)], 0x00)},
+		{"small extra token", 42, append(prefix[:len(prefix):len(prefix)], 0x2a)},
+		{"large extra token", 1234, append(prefix[:len(prefix):len(prefix)], 0x92, 0x13)},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newInfoRequest(token, tt.extraToken); !bytes.Equal(got, tt.want) {
+				t.Errorf("newInfoRequest() = %x, want %x", got, tt.want)
+			}
+		})
+	}
+
+	packet, err := NewServerInfoRequestPacket(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(packet, tests[0].want) {
+		t.Errorf("NewServerInfoRequestPacket() = %x, want %x", packet, tests[0].want)
+	}
+}
+
+func TestGenerateClientToken(t *testing.T) {
+	first, second := GenerateClientToken(), GenerateClientToken()
+	if first == second {
+		t.Errorf("expected two different client tokens, got %d twice", first)
+	}
+	for i := 0; i < 100; i++ {
+		if token := GenerateClientToken(); token < 0 {
+			t.Fatalf("expected a non-negative client token, got %d", token)
+		}
+	}
+}
+
+func TestGenerateClientTokenFailure(t *testing.T) {
+	defer func(r io.Reader) {
+		randReader = r
+	}(randReader)
+	randReader = bytes.NewReader(nil)
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected GenerateClientToken() to panic without a source of randomness")
+		}
+	}()
+	token := GenerateClientToken()
+	t.Errorf("GenerateClientToken() = %d without a source of randomness", token)
+}
+
+func TestStripConnless(t *testing.T) {
+	payload := append(append([]byte{}, sendInfoRaw...), 1, 2, 3)
+
+	tests := []struct {
+		name        string
+		packet      []byte
+		wantToken   int
+		wantPayload []byte
+		wantOK      bool
+	}{
+		{"response", append(packToken(0x11223344, 0x0c0ffee0), payload...), 0x0c0ffee0, payload, true},
+		{"header only", packToken(1, 0x7fffffff), 0x7fffffff, []byte{}, true},
+		{"token response", packTokenResponse(0x0c0ffee0, 0x11223344), 0, nil, false},
+		{"0.6 packet", []byte("\xff\xff\xff\xff\xff\xff" + legacySendInfo), 0, nil, false},
+		{"wrong version", append([]byte{8 << 2}, make([]byte, tokenPrefixSize)...), 0, nil, false},
+		{"too short", packToken(1, 2)[:tokenPrefixSize-1], 0, nil, false},
+		{"empty", nil, 0, nil, false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			token, payload, ok := StripConnless(tt.packet)
+			if ok != tt.wantOK || token != tt.wantToken || !bytes.Equal(payload, tt.wantPayload) {
+				t.Errorf("StripConnless() = %#x, %v, %v, want %#x, %v, %v", token, payload, ok, tt.wantToken, tt.wantPayload, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseServerInfoExtraToken(t *testing.T) {
+	sent := ServerInfo{Version: "0.7.5", Name: "name", Map: "ctf5", GameType: "CTF", MaxPlayers: 8, MaxClients: 8}
+	data, _ := sent.MarshalBinary()
+
+	// the echoed extra token is packed as variable integer of one or more bytes
+	for _, extraToken := range []int{0, 42, 1234} {
+		resp := packInfoResponse(packToken(1, 2), extraToken, data)
+
+		if match, err := MatchResponse(resp); err != nil || match != "serverinfo" {
+			t.Errorf("extra token %d: MatchResponse() = %q, %v, want serverinfo", extraToken, match, err)
+		}
+		info, err := ParseServerInfo(resp, "")
+		if err != nil {
+			t.Fatalf("extra token %d: %v", extraToken, err)
+		}
+		if !info.Equal(sent) {
+			t.Errorf("extra token %d: ParseServerInfo() = %s, want %s", extraToken, info.String(), sent.String())
+		}
+	}
+
+	if _, err := ParseServerInfo(append(packToken(1, 2), sendInfoRaw...), ""); !errors.Is(err, ErrMalformedResponseData) {
+		t.Errorf("expected %v for a missing extra token, got %v", ErrMalformedResponseData, err)
+	}
+}
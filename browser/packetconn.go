@@ -0,0 +1,64 @@
+package browser
+
+import (
+	"net"
+	"time"
+)
+
+// packetConn sends to and receives from a single address via a shared net.PacketConn.
+// Packets of any other address are discarded. Closing it does not close the shared connection.
+type packetConn struct {
+	net.PacketConn
+	addr net.Addr
+}
+
+// Read reads the next packet that has been sent by the remote address.
+func (pc *packetConn) Read(b []byte) (int, error) {
+	for {
+		n, from, err := pc.ReadFrom(b)
+		if err != nil {
+			return n, err
+		}
+		if from.String() == pc.addr.String() {
+			return n, nil
+		}
+	}
+}
+
+// Write sends b to the remote address.
+func (pc *packetConn) Write(b []byte) (int, error) {
+	return pc.WriteTo(b, pc.addr)
+}
+
+// Close does nothing, as the shared connection is owned by the caller.
+func (pc *packetConn) Close() error {
+	return nil
+}
+
+// NewMasterServerWithConn creates a master server that sends its requests to addr via conn,
+// e.g. a socket that is also used for NAT traversal. Packets of other addresses that are received
+// while a request is pending are discarded. Closing the master server does not close conn.
+func NewMasterServerWithConn(conn net.PacketConn, addr net.Addr) (*MasterServer, error) {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return &MasterServer{
+		addr:            udpAddr,
+		conn:            &packetConn{PacketConn: conn, addr: addr},
+		clientToken:     GenerateClientToken,
+		wait:            TimeoutWait{},
+		refreshInterval: TokenExpirationDuration,
+	}, nil
+}
+
+// QueryServerInfoConn fetches the server info of the game server at addr via conn and waits at most timeout
+// for the response. Packets of other addresses that are received in the meantime are discarded.
+func QueryServerInfoConn(conn net.PacketConn, addr net.Addr, timeout time.Duration) (ServerInfo, error) {
+	resp, err := Fetch("serverinfo", &packetConn{PacketConn: conn, addr: addr}, timeout)
+	if err != nil {
+		return ServerInfo{}, err
+	}
+	return ParseServerInfo(resp, addr.String())
+}
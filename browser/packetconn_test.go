@@ -0,0 +1,73 @@
+package browser
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func newLoopbackPacketConn(t *testing.T) net.PacketConn {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+func TestQueryServerInfoConn(t *testing.T) {
+	info := ServerInfo{Version: "0.7.5", Name: "shared socket", Map: "dm1", GameType: "DM", MaxPlayers: 8, MaxClients: 8}
+	gs := newFakeGameServer(t, info)
+	defer gs.Close()
+
+	conn := newLoopbackPacketConn(t)
+	defer conn.Close()
+
+	// packets of other addresses are ignored
+	other := newLoopbackPacketConn(t)
+	defer other.Close()
+	other.WriteTo([]byte("unrelated"), conn.LocalAddr())
+
+	got, err := QueryServerInfoConn(conn, gs.Addr(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info.Address = gs.Addr().String()
+	if !got.Equal(info) {
+		t.Errorf("QueryServerInfoConn() = %s, want %s", got.String(), info.String())
+	}
+
+	// the socket is still usable
+	if _, err := QueryServerInfoConn(conn, gs.Addr(), time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNewMasterServerWithConn(t *testing.T) {
+	servers := ServerList{{IP: net.IPv4(10, 0, 0, 1), Port: DefaultGamePort}}
+	master := newFakeMasterServer(t, servers)
+	defer master.Close()
+
+	conn := newLoopbackPacketConn(t)
+	defer conn.Close()
+
+	ms, err := NewMasterServerWithConn(conn, master.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := ms.GetServerList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].String() != "10.0.0.1:8303" {
+		t.Errorf("GetServerList() = %v", list)
+	}
+
+	if err := ms.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// closing the master server keeps the caller's socket open
+	if _, err := conn.WriteTo([]byte{0}, master.Addr()); err != nil {
+		t.Errorf("the socket has been closed: %v", err)
+	}
+}
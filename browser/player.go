@@ -0,0 +1,55 @@
+package browser
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	// colorCode matches the ^RGB color codes of the chat, e.g. ^900 for red,
+	// which some modifications allow in player names as well.
+	colorCode = regexp.MustCompile(`\^[0-9]{3}`)
+
+	// clanTagPrefix matches clan tags in front of a name, e.g. "[Tag] name", "{Tag}name" or "Tag | name"
+	clanTagPrefix = regexp.MustCompile(`^(?:\[([^\]]+)\]|\{([^}]+)\}|\(([^)]+)\)|<([^>]+)>|([^|]+?)\s*\|)\s*\S`)
+
+	// clanTagSuffix matches clan tags behind a name, e.g. "name [Tag]"
+	clanTagSuffix = regexp.MustCompile(`\S\s*(?:\[([^\]]+)\]|\{([^}]+)\}|\(([^)]+)\)|<([^>]+)>)$`)
+)
+
+// NormalizePlayerName removes color codes from the name and replaces control characters with spaces
+// like the game server does. Leading and trailing whitespaces are removed, including unicode whitespaces.
+func NormalizePlayerName(name string) string {
+	name = colorCode.ReplaceAllString(name, "")
+	name = strings.Map(func(r rune) rune {
+		if r < 32 {
+			return ' '
+		}
+		return r
+	}, name)
+	return strings.TrimFunc(name, unicode.IsSpace)
+}
+
+// ExtractClanTag returns the clan of a player. If the clan field is empty, the clan tag is
+// extracted from the name, e.g. "Tag" from "[Tag] name", "name [Tag]" or "Tag | name".
+// An empty string is returned if the player does not belong to any clan.
+func ExtractClanTag(name, clan string) string {
+	if clan = NormalizePlayerName(clan); clan != "" {
+		return clan
+	}
+
+	name = NormalizePlayerName(name)
+	for _, re := range []*regexp.Regexp{clanTagPrefix, clanTagSuffix} {
+		match := re.FindStringSubmatch(name)
+		if match == nil {
+			continue
+		}
+		for _, tag := range match[1:] {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				return tag
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,49 @@
+package browser
+
+import "testing"
+
+func TestNormalizePlayerName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"nameless tee", "nameless tee"},
+		{"^900red^090green", "redgreen"},
+		{"tab\tname\n", "tab name"},
+		{"　  ☃ Schneemann ☃ ", "☃ Schneemann ☃"},
+		{"ünïcödé", "ünïcödé"},
+		{"^12 incomplete", "^12 incomplete"},
+		{"\x01\x02", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizePlayerName(tt.name); got != tt.want {
+			t.Errorf("NormalizePlayerName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExtractClanTag(t *testing.T) {
+	tests := []struct {
+		name string
+		clan string
+		want string
+	}{
+		{"brainless tee", "Tee Squad", "Tee Squad"},
+		{"[KoG] Player", "", "KoG"},
+		{"{ßandits}Player", "", "ßandits"},
+		{"(42) Player", "", "42"},
+		{"<DDNet>Player", "", "DDNet"},
+		{"Ninja | Player", "", "Ninja"},
+		{"Player [Clan]", "", "Clan"},
+		{"^900[Red]^999 Player", "", "Red"},
+		{"Player", "", ""},
+		{"[Unclosed Player", "", ""},
+		{"[]", "", ""},
+		{"Player", " \t", ""},
+	}
+	for _, tt := range tests {
+		if got := ExtractClanTag(tt.name, tt.clan); got != tt.want {
+			t.Errorf("ExtractClanTag(%q, %q) = %q, want %q", tt.name, tt.clan, got, tt.want)
+		}
+	}
+}
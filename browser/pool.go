@@ -0,0 +1,65 @@
+package browser
+
+import (
+	"context"
+	"sync"
+)
+
+// workerPool runs jobs on a bounded number of workers.
+// Submitting a job blocks until a worker is free, which limits the number of concurrent queries
+// and slows down the producer if the jobs cannot keep up, e.g. because their results are not consumed.
+type workerPool struct {
+	jobs chan func()
+	wg   sync.WaitGroup
+}
+
+// newWorkerPool starts workers that wait for jobs. At least one worker is started.
+func newWorkerPool(workers int) *workerPool {
+	if workers < 1 {
+		workers = 1
+	}
+
+	p := &workerPool{
+		jobs: make(chan func()),
+	}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer p.wg.Done()
+			for job := range p.jobs {
+				job()
+			}
+		}()
+	}
+	return p
+}
+
+// submit passes job to the next free worker. It returns false without running job if ctx is done
+// before a worker becomes free. Jobs need to observe ctx themselves in order to stop early.
+func (p *workerPool) submit(ctx context.Context, job func()) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	select {
+	case p.jobs <- job:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// wait stops accepting jobs and waits for all submitted jobs to finish.
+// submit must not be called after wait.
+func (p *workerPool) wait() {
+	close(p.jobs)
+	p.wg.Wait()
+}
+
+// limitWorkers returns the number of workers needed for the number of jobs, but at most max.
+func limitWorkers(max, jobs int) int {
+	if max < 1 || jobs < max {
+		return jobs
+	}
+	return max
+}
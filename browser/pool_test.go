@@ -0,0 +1,106 @@
+package browser
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkerPoolDrain(t *testing.T) {
+	pool := newWorkerPool(4)
+
+	var finished int64
+	for i := 0; i < 100; i++ {
+		if !pool.submit(context.Background(), func() {
+			time.Sleep(time.Millisecond)
+			atomic.AddInt64(&finished, 1)
+		}) {
+			t.Fatal("submit failed without the context being done")
+		}
+	}
+	pool.wait()
+
+	if finished != 100 {
+		t.Errorf("expected all 100 jobs to finish before wait returns, got %d", finished)
+	}
+}
+
+func TestWorkerPoolCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pool := newWorkerPool(2)
+	started := make(chan struct{}, 2)
+	var stopped int64
+	for i := 0; i < 2; i++ {
+		pool.submit(ctx, func() {
+			started <- struct{}{}
+			<-ctx.Done()
+			atomic.AddInt64(&stopped, 1)
+		})
+	}
+	<-started
+	<-started
+
+	// both workers are busy, the next job waits until the context is cancelled
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	if pool.submit(ctx, func() { t.Error("job has been run after the cancellation") }) {
+		t.Error("submit succeeded after the cancellation")
+	}
+	if pool.submit(ctx, func() { t.Error("job has been run after the cancellation") }) {
+		t.Error("submit succeeded with a cancelled context")
+	}
+
+	pool.wait()
+	if stopped != 2 {
+		t.Errorf("expected the running jobs to stop, %d stopped", stopped)
+	}
+}
+
+func TestWorkerPoolBackpressure(t *testing.T) {
+	pool := newWorkerPool(2)
+	results := make(chan int)
+
+	submitted := make(chan int, 10)
+	go func() {
+		for i := 0; i < 5; i++ {
+			i := i
+			pool.submit(context.Background(), func() { results <- i })
+			submitted <- i
+		}
+		pool.wait()
+		close(results)
+	}()
+
+	// nobody consumes the results, which blocks both workers and the third submit
+	time.Sleep(50 * time.Millisecond)
+	if n := len(submitted); n != 2 {
+		t.Fatalf("expected 2 submitted jobs while the results are not consumed, got %d", n)
+	}
+
+	received := 0
+	for range results {
+		received++
+	}
+	if received != 5 {
+		t.Errorf("expected 5 results, got %d", received)
+	}
+}
+
+func TestLimitWorkers(t *testing.T) {
+	tests := []struct{ max, jobs, want int }{
+		{256, 10, 10},
+		{4, 10, 4},
+		{0, 10, 10},
+		{4, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := limitWorkers(tt.max, tt.jobs); got != tt.want {
+			t.Errorf("limitWorkers(%d, %d) = %d, want %d", tt.max, tt.jobs, got, tt.want)
+		}
+	}
+}
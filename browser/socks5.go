@@ -0,0 +1,275 @@
+package browser
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+const (
+	socks5Version     = 5
+	socks5AuthNone    = 0
+	socks5AuthUserPwd = 2
+	socks5NoMethods   = 0xff
+	socks5CmdUDPAssoc = 3
+	socks5AtypIPv4    = 1
+	socks5AtypDomain  = 3
+	socks5AtypIPv6    = 4
+	socks5Succeeded   = 0
+)
+
+var (
+	// ErrSOCKS5 is returned if the SOCKS5 proxy rejects the authentication or the UDP association.
+	ErrSOCKS5 = errors.New("socks5 proxy error")
+)
+
+// SOCKS5Auth contains the credentials for the username/password authentication of a SOCKS5 proxy.
+type SOCKS5Auth struct {
+	Username string
+	Password string
+}
+
+// udpConn is a connection to a single master or game server.
+type udpConn interface {
+	ReadWriteDeadliner
+	io.Closer
+}
+
+// dialFunc creates a connection to the master or game server at addr.
+type dialFunc func(ctx context.Context, addr *net.UDPAddr) (udpConn, error)
+
+// dialUDP connects directly to addr.
+func dialUDP(ctx context.Context, addr *net.UDPAddr) (udpConn, error) {
+	conn, err := net.DialUDP("udp", nil, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// server lists consist of multiple packets that arrive at once.
+	conn.SetReadBuffer(maxBufferSize * maxChunks)
+	return conn, nil
+}
+
+// socketBufferSetter is implemented by connections whose socket buffers can be resized.
+type socketBufferSetter interface {
+	SetReadBuffer(bytes int) error
+	SetWriteBuffer(bytes int) error
+}
+
+// withSocketBuffers returns a dialFunc that resizes the socket buffers of every connection of dial.
+// Sizes below 1 are not changed.
+func withSocketBuffers(dial dialFunc, readBytes, writeBytes int) dialFunc {
+	return func(ctx context.Context, addr *net.UDPAddr) (udpConn, error) {
+		conn, err := dial(ctx, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		if s, ok := conn.(socketBufferSetter); ok {
+			if readBytes > 0 {
+				s.SetReadBuffer(readBytes)
+			}
+			if writeBytes > 0 {
+				s.SetWriteBuffer(writeBytes)
+			}
+		}
+		return conn, nil
+	}
+}
+
+// socks5Dialer returns a dialFunc that relays the UDP packets of every connection via a UDP association
+// of the SOCKS5 proxy at proxyAddr. The association is kept alive until the connection is closed.
+func socks5Dialer(proxyAddr string, auth *SOCKS5Auth, dialTimeout time.Duration) dialFunc {
+	return func(ctx context.Context, addr *net.UDPAddr) (udpConn, error) {
+		ctx, cancel := context.WithTimeout(ctx, dialTimeout)
+		defer cancel()
+
+		var d net.Dialer
+		ctrl, err := d.DialContext(ctx, "tcp", proxyAddr)
+		if err != nil {
+			return nil, err
+		}
+
+		if deadline, ok := ctx.Deadline(); ok {
+			ctrl.SetDeadline(deadline)
+		}
+		relay, err := socks5Associate(ctrl, auth)
+		if err != nil {
+			ctrl.Close()
+			return nil, err
+		}
+		ctrl.SetDeadline(time.Time{})
+
+		// proxies that listen on all interfaces reply with an unspecified address
+		if relay.IP.IsUnspecified() {
+			relay.IP = ctrl.RemoteAddr().(*net.TCPAddr).IP
+		}
+
+		conn, err := net.DialUDP("udp", nil, relay)
+		if err != nil {
+			ctrl.Close()
+			return nil, err
+		}
+		conn.SetReadBuffer(maxBufferSize * maxChunks)
+
+		return &socks5Conn{
+			UDPConn: conn,
+			ctrl:    ctrl,
+			header:  packSOCKS5Header(addr),
+		}, nil
+	}
+}
+
+// socks5Associate authenticates at the proxy and requests a UDP association.
+// It returns the address of the relay that the UDP packets are sent to.
+func socks5Associate(ctrl net.Conn, auth *SOCKS5Auth) (*net.UDPAddr, error) {
+	method := byte(socks5AuthNone)
+	if auth != nil {
+		method = socks5AuthUserPwd
+	}
+	if _, err := ctrl.Write([]byte{socks5Version, 1, method}); err != nil {
+		return nil, err
+	}
+
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(ctrl, reply); err != nil {
+		return nil, err
+	}
+	if reply[0] != socks5Version || reply[1] != method {
+		return nil, fmt.Errorf("%w: authentication method not accepted", ErrSOCKS5)
+	}
+
+	if auth != nil {
+		if len(auth.Username) > 255 || len(auth.Password) > 255 {
+			return nil, fmt.Errorf("%w: username or password too long", ErrSOCKS5)
+		}
+		req := make([]byte, 0, 3+len(auth.Username)+len(auth.Password))
+		req = append(req, 1, byte(len(auth.Username)))
+		req = append(req, auth.Username...)
+		req = append(req, byte(len(auth.Password)))
+		req = append(req, auth.Password...)
+		if _, err := ctrl.Write(req); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(ctrl, reply); err != nil {
+			return nil, err
+		}
+		if reply[1] != socks5Succeeded {
+			return nil, fmt.Errorf("%w: authentication failed", ErrSOCKS5)
+		}
+	}
+
+	// the address the packets are sent from is not known in advance
+	if _, err := ctrl.Write([]byte{socks5Version, socks5CmdUDPAssoc, 0, socks5AtypIPv4, 0, 0, 0, 0, 0, 0}); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(ctrl, header); err != nil {
+		return nil, err
+	}
+	if header[0] != socks5Version || header[1] != socks5Succeeded {
+		return nil, fmt.Errorf("%w: udp associate failed with reply code %d", ErrSOCKS5, header[1])
+	}
+
+	host, port, err := readSOCKS5Addr(ctrl, header[3])
+	if err != nil {
+		return nil, err
+	}
+	return net.ResolveUDPAddr("udp", net.JoinHostPort(host, strconv.Itoa(port)))
+}
+
+// readSOCKS5Addr reads an address of the type atyp followed by its port.
+func readSOCKS5Addr(r io.Reader, atyp byte) (host string, port int, err error) {
+	var b []byte
+	switch atyp {
+	case socks5AtypIPv4:
+		b = make([]byte, net.IPv4len+2)
+	case socks5AtypIPv6:
+		b = make([]byte, net.IPv6len+2)
+	case socks5AtypDomain:
+		size := make([]byte, 1)
+		if _, err = io.ReadFull(r, size); err != nil {
+			return
+		}
+		b = make([]byte, int(size[0])+2)
+	default:
+		return "", 0, fmt.Errorf("%w: unknown address type %d", ErrSOCKS5, atyp)
+	}
+
+	if _, err = io.ReadFull(r, b); err != nil {
+		return
+	}
+
+	if atyp == socks5AtypDomain {
+		host = string(b[:len(b)-2])
+	} else {
+		host = net.IP(b[:len(b)-2]).String()
+	}
+	return host, int(binary.BigEndian.Uint16(b[len(b)-2:])), nil
+}
+
+// packSOCKS5Header creates the header that precedes every UDP packet that is relayed to addr.
+func packSOCKS5Header(addr *net.UDPAddr) []byte {
+	header := []byte{0, 0, 0} // reserved and fragment number
+	if ip := addr.IP.To4(); ip != nil {
+		header = append(header, socks5AtypIPv4)
+		header = append(header, ip...)
+	} else {
+		header = append(header, socks5AtypIPv6)
+		header = append(header, addr.IP.To16()...)
+	}
+	return append(header, byte(addr.Port>>8), byte(addr.Port))
+}
+
+// socks5Conn sends and receives the packets of a single server via the relay of a SOCKS5 proxy.
+type socks5Conn struct {
+	*net.UDPConn
+	ctrl   net.Conn
+	header []byte
+}
+
+// Write sends b to the server.
+func (c *socks5Conn) Write(b []byte) (int, error) {
+	packet := make([]byte, 0, len(c.header)+len(b))
+	packet = append(packet, c.header...)
+	packet = append(packet, b...)
+
+	_, err := c.UDPConn.Write(packet)
+	if err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Read receives the next packet of the server. Packets from other addresses and
+// fragmented packets, which are not supported, are skipped.
+func (c *socks5Conn) Read(b []byte) (int, error) {
+	buffer := make([]byte, len(c.header)+maxBufferSize)
+	for {
+		n, err := c.UDPConn.Read(buffer)
+		if err != nil {
+			return 0, err
+		}
+
+		// the relay sets the source address, which is the same as the destination of our requests
+		if n < len(c.header) || string(buffer[:len(c.header)]) != string(c.header) {
+			continue
+		}
+		return copy(b, buffer[len(c.header):n]), nil
+	}
+}
+
+// Close closes the UDP connection as well as the TCP connection, which ends the UDP association.
+func (c *socks5Conn) Close() error {
+	err := c.UDPConn.Close()
+	if cerr := c.ctrl.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,204 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeSOCKS5 is a minimal SOCKS5 proxy that only supports UDP associations.
+type fakeSOCKS5 struct {
+	ln       net.Listener
+	auth     *SOCKS5Auth
+	relayed  int64
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	sessions []io.Closer
+}
+
+func newFakeSOCKS5(t *testing.T, auth *SOCKS5Auth) *fakeSOCKS5 {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &fakeSOCKS5{ln: ln, auth: auth}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		for {
+			ctrl, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			p.track(ctrl)
+			p.wg.Add(1)
+			go p.serve(ctrl)
+		}
+	}()
+	return p
+}
+
+func (p *fakeSOCKS5) Addr() string {
+	return p.ln.Addr().String()
+}
+
+func (p *fakeSOCKS5) Close() {
+	p.ln.Close()
+	p.mu.Lock()
+	for _, c := range p.sessions {
+		c.Close()
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+func (p *fakeSOCKS5) track(c io.Closer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sessions = append(p.sessions, c)
+}
+
+func (p *fakeSOCKS5) serve(ctrl net.Conn) {
+	defer p.wg.Done()
+	defer ctrl.Close()
+
+	greeting := make([]byte, 3)
+	if _, err := io.ReadFull(ctrl, greeting); err != nil {
+		return
+	}
+
+	method := byte(socks5AuthNone)
+	if p.auth != nil {
+		method = socks5AuthUserPwd
+	}
+	if greeting[2] != method {
+		ctrl.Write([]byte{socks5Version, socks5NoMethods})
+		return
+	}
+	ctrl.Write([]byte{socks5Version, method})
+
+	if p.auth != nil {
+		// version, username length, username, password length, password
+		hdr := make([]byte, 2)
+		if _, err := io.ReadFull(ctrl, hdr); err != nil {
+			return
+		}
+		user := make([]byte, int(hdr[1])+1)
+		if _, err := io.ReadFull(ctrl, user); err != nil {
+			return
+		}
+		pass := make([]byte, int(user[len(user)-1]))
+		if _, err := io.ReadFull(ctrl, pass); err != nil {
+			return
+		}
+		if string(user[:len(user)-1]) != p.auth.Username || string(pass) != p.auth.Password {
+			ctrl.Write([]byte{1, 1})
+			return
+		}
+		ctrl.Write([]byte{1, socks5Succeeded})
+	}
+
+	req := make([]byte, 10)
+	if _, err := io.ReadFull(ctrl, req); err != nil || req[1] != socks5CmdUDPAssoc {
+		return
+	}
+
+	relay, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		return
+	}
+	p.track(relay)
+	defer relay.Close()
+
+	port := relay.LocalAddr().(*net.UDPAddr).Port
+	// an unspecified address is replaced with the address of the proxy
+	ctrl.Write([]byte{socks5Version, socks5Succeeded, 0, socks5AtypIPv4, 0, 0, 0, 0, byte(port >> 8), byte(port)})
+
+	p.wg.Add(1)
+	go p.relay(relay)
+
+	// the association ends with the control connection
+	io.Copy(ioutil.Discard, ctrl)
+}
+
+func (p *fakeSOCKS5) relay(relay *net.UDPConn) {
+	defer p.wg.Done()
+
+	var client *net.UDPAddr
+	buf := make([]byte, 2*maxBufferSize)
+	for {
+		n, src, err := relay.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+
+		if client == nil || src.String() == client.String() {
+			// request of the client
+			client = src
+			if n < 10 || buf[3] != socks5AtypIPv4 {
+				continue
+			}
+			dst := &net.UDPAddr{IP: net.IP(append([]byte{}, buf[4:8]...)), Port: int(buf[8])<<8 | int(buf[9])}
+			relay.WriteToUDP(buf[10:n], dst)
+			continue
+		}
+
+		// response of a server
+		atomic.AddInt64(&p.relayed, 1)
+		packet := append(packSOCKS5Header(src), buf[:n]...)
+		relay.WriteToUDP(packet, client)
+	}
+}
+
+func TestWithSOCKS5(t *testing.T) {
+	info := ServerInfo{Version: "0.7.5", Name: "proxied", Map: "ctf5", GameType: "CTF", MaxPlayers: 16, MaxClients: 16}
+	gs := newFakeGameServer(t, info)
+	defer gs.Close()
+
+	master := newFakeMasterServer(t, ServerList{gs.Addr()})
+	defer master.Close()
+
+	auth := &SOCKS5Auth{Username: "user", Password: "secret"}
+	proxy := newFakeSOCKS5(t, auth)
+	defer proxy.Close()
+
+	got, err := ListServersWithInfo(context.Background(),
+		WithMasterServers(master.Addr()),
+		WithMasterServerTimeout(time.Second),
+		WithQueryTimeout(time.Second),
+		WithSOCKS5(proxy.Addr(), auth),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Name != info.Name || got[0].Address != gs.Addr().String() {
+		t.Fatalf("ListServersWithInfo() = %v", got)
+	}
+
+	// token, server list and token, server info
+	if relayed := atomic.LoadInt64(&proxy.relayed); relayed < 4 {
+		t.Errorf("the proxy relayed %d packets, want at least 4", relayed)
+	}
+}
+
+func TestWithSOCKS5AuthenticationFailed(t *testing.T) {
+	proxy := newFakeSOCKS5(t, &SOCKS5Auth{Username: "user", Password: "secret"})
+	defer proxy.Close()
+
+	o := newOptions(WithSOCKS5(proxy.Addr(), &SOCKS5Auth{Username: "user", Password: "wrong"}))
+	if _, err := o.dial(context.Background(), &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: DefaultGamePort}); !errors.Is(err, ErrSOCKS5) {
+		t.Errorf("expected %v, got %v", ErrSOCKS5, err)
+	}
+
+	o = newOptions(WithSOCKS5(proxy.Addr(), nil))
+	if _, err := o.dial(context.Background(), &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: DefaultGamePort}); !errors.Is(err, ErrSOCKS5) {
+		t.Errorf("expected %v, got %v", ErrSOCKS5, err)
+	}
+}
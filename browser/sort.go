@@ -0,0 +1,75 @@
+package browser
+
+import (
+	"sort"
+	"strings"
+)
+
+// SortKey defines the ServerInfo field that SortServers sorts by.
+type SortKey int
+
+const (
+	// SortByPlayers sorts by the number of clients, including spectators.
+	SortByPlayers SortKey = iota
+
+	// SortByName sorts by the server name, ignoring the case.
+	SortByName
+
+	// SortByMap sorts by the map name, ignoring the case.
+	SortByMap
+
+	// SortByGameType sorts by the gametype, ignoring the case.
+	SortByGameType
+
+	// SortByAddress sorts by the address the info was queried from.
+	SortByAddress
+
+	// SortByPing sorts by the round-trip time of the info query. Servers without a ping,
+	// e.g. offline servers, are sorted last in both directions.
+	SortByPing
+)
+
+// SortServers sorts the list in place by the passed key, descending if desc is true.
+// Servers with equal keys are sorted ascending by their address, so that the order
+// does not depend on the order in which the servers responded.
+func SortServers(list []ServerInfo, by SortKey, desc bool) {
+	sort.SliceStable(list, func(i, j int) bool {
+		if by == SortByPing && (list[i].Ping == 0) != (list[j].Ping == 0) {
+			return list[j].Ping == 0
+		}
+
+		c := compareServers(&list[i], &list[j], by)
+		if c == 0 {
+			return list[i].Address < list[j].Address
+		}
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+}
+
+// compareServers returns a negative number if a is less than b, zero if both are equal and a positive number otherwise.
+func compareServers(a, b *ServerInfo, by SortKey) int {
+	switch by {
+	case SortByPlayers:
+		return a.NumClients - b.NumClients
+	case SortByName:
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	case SortByMap:
+		return strings.Compare(strings.ToLower(a.Map), strings.ToLower(b.Map))
+	case SortByGameType:
+		return strings.Compare(strings.ToLower(a.GameType), strings.ToLower(b.GameType))
+	case SortByPing:
+		switch {
+		case a.Ping < b.Ping:
+			return -1
+		case a.Ping > b.Ping:
+			return 1
+		default:
+			return 0
+		}
+	default:
+		return strings.Compare(a.Address, b.Address)
+	}
+}
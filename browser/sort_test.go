@@ -0,0 +1,52 @@
+package browser
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSortServers(t *testing.T) {
+	servers := []ServerInfo{
+		{Address: "10.0.0.3:8303", Name: "beta", Map: "dm1", GameType: "DM", NumClients: 4, Ping: 40 * time.Millisecond},
+		{Address: "10.0.0.1:8303", Name: "Alpha", Map: "ctf5", GameType: "CTF", NumClients: 12, Ping: 15 * time.Millisecond},
+		{Address: "10.0.0.4:8303", Name: "gamma", Map: "Ctf5", GameType: "ctf", NumClients: 4, Ping: 40 * time.Millisecond},
+		{Address: "10.0.0.2:8303", Name: "Delta", Map: "dm2", GameType: "TDM", NumClients: 0},
+	}
+
+	tests := []struct {
+		name string
+		by   SortKey
+		desc bool
+		want []string // addresses
+	}{
+		{"players", SortByPlayers, false, []string{"10.0.0.2:8303", "10.0.0.3:8303", "10.0.0.4:8303", "10.0.0.1:8303"}},
+		{"players desc", SortByPlayers, true, []string{"10.0.0.1:8303", "10.0.0.3:8303", "10.0.0.4:8303", "10.0.0.2:8303"}},
+		{"name", SortByName, false, []string{"10.0.0.1:8303", "10.0.0.3:8303", "10.0.0.2:8303", "10.0.0.4:8303"}},
+		{"name desc", SortByName, true, []string{"10.0.0.4:8303", "10.0.0.2:8303", "10.0.0.3:8303", "10.0.0.1:8303"}},
+		{"map", SortByMap, false, []string{"10.0.0.1:8303", "10.0.0.4:8303", "10.0.0.3:8303", "10.0.0.2:8303"}},
+		{"map desc", SortByMap, true, []string{"10.0.0.2:8303", "10.0.0.3:8303", "10.0.0.1:8303", "10.0.0.4:8303"}},
+		{"gametype", SortByGameType, false, []string{"10.0.0.1:8303", "10.0.0.4:8303", "10.0.0.3:8303", "10.0.0.2:8303"}},
+		{"gametype desc", SortByGameType, true, []string{"10.0.0.2:8303", "10.0.0.3:8303", "10.0.0.1:8303", "10.0.0.4:8303"}},
+		{"address", SortByAddress, false, []string{"10.0.0.1:8303", "10.0.0.2:8303", "10.0.0.3:8303", "10.0.0.4:8303"}},
+		{"address desc", SortByAddress, true, []string{"10.0.0.4:8303", "10.0.0.3:8303", "10.0.0.2:8303", "10.0.0.1:8303"}},
+		// 10.0.0.2 has not been queried
+		{"ping", SortByPing, false, []string{"10.0.0.1:8303", "10.0.0.3:8303", "10.0.0.4:8303", "10.0.0.2:8303"}},
+		{"ping desc", SortByPing, true, []string{"10.0.0.3:8303", "10.0.0.4:8303", "10.0.0.1:8303", "10.0.0.2:8303"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			list := append([]ServerInfo{}, servers...)
+			SortServers(list, tt.by, tt.desc)
+
+			got := make([]string, 0, len(list))
+			for _, si := range list {
+				got = append(got, si.Address)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SortServers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package browser
+
+// SourceKind is the kind of a server list source.
+type SourceKind int
+
+const (
+	// SourceMaster is a UDP master server.
+	SourceMaster SourceKind = iota
+	// SourceHTTP is an HTTP master server, e.g. the one of DDNet.
+	SourceHTTP
+	// SourceLAN is the discovery of servers in the local network.
+	SourceLAN
+)
+
+// String returns the name of the source kind.
+func (k SourceKind) String() string {
+	switch k {
+	case SourceMaster:
+		return "master"
+	case SourceHTTP:
+		return "http"
+	case SourceLAN:
+		return "lan"
+	default:
+		return "unknown"
+	}
+}
+
+// Source identifies where a server address has been listed.
+type Source struct {
+	Kind SourceKind
+
+	// Name distinguishes sources of the same kind, e.g. the address of a master server or the URL of an HTTP master.
+	Name string
+}
+
+// String returns the source as kind:name, or only its kind if it has no name.
+func (s Source) String() string {
+	if s.Name == "" {
+		return s.Kind.String()
+	}
+	return s.Kind.String() + ":" + s.Name
+}
+
+// SourcedAddress is a server address together with the sources that listed it.
+type SourcedAddress struct {
+	ServerAddress
+	Sources []Source
+}
+
+// NewSourcedAddresses attributes every address to the source src.
+func NewSourcedAddresses(addrs []ServerAddress, src Source) []SourcedAddress {
+	sourced := make([]SourcedAddress, 0, len(addrs))
+	for _, addr := range addrs {
+		sourced = append(sourced, SourcedAddress{
+			ServerAddress: addr,
+			Sources:       []Source{src},
+		})
+	}
+	return sourced
+}
+
+// MergeSourced merges the lists into a single list that contains every address once.
+// An address that is listed multiple times gets the sources of all of its occurrences,
+// every source is contained only once. Addresses keep the order of their first occurrence.
+func MergeSourced(lists ...[]SourcedAddress) []SourcedAddress {
+	size := 0
+	for _, list := range lists {
+		size += len(list)
+	}
+
+	index := make(map[string]int, size)
+	merged := make([]SourcedAddress, 0, size)
+	for _, list := range lists {
+		for _, addr := range list {
+			key := addr.String()
+			idx, ok := index[key]
+			if !ok {
+				index[key] = len(merged)
+				merged = append(merged, SourcedAddress{ServerAddress: addr.ServerAddress})
+				idx = len(merged) - 1
+			}
+
+			for _, src := range addr.Sources {
+				if !hasSource(merged[idx].Sources, src) {
+					merged[idx].Sources = append(merged[idx].Sources, src)
+				}
+			}
+		}
+	}
+	return merged
+}
+
+func hasSource(sources []Source, src Source) bool {
+	for _, s := range sources {
+		if s == src {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package browser
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestMergeSourced(t *testing.T) {
+	a := ServerAddress{IP: net.IPv4(10, 0, 0, 1).To4(), Port: DefaultGamePort}
+	b := ServerAddress{IP: net.ParseIP("2001:db8::1"), Port: DefaultGamePort}
+	c := ServerAddress{IP: net.IPv4(192, 168, 0, 5).To4(), Port: 8305}
+	// the same address as a in 16 bytes
+	a16 := ServerAddress{IP: net.IPv4(10, 0, 0, 1), Port: DefaultGamePort}
+
+	master1 := Source{Kind: SourceMaster, Name: "master1.teeworlds.com:8283"}
+	master2 := Source{Kind: SourceMaster, Name: "master2.teeworlds.com:8283"}
+	http := Source{Kind: SourceHTTP, Name: "https://master1.ddnet.org/ddnet/15/servers.json"}
+	lan := Source{Kind: SourceLAN}
+
+	got := MergeSourced(
+		NewSourcedAddresses([]ServerAddress{a, b}, master1),
+		NewSourcedAddresses([]ServerAddress{b, a, a}, master2),
+		NewSourcedAddresses([]ServerAddress{a16}, http),
+		NewSourcedAddresses([]ServerAddress{c}, lan),
+		[]SourcedAddress{{ServerAddress: c, Sources: []Source{lan, master1}}},
+	)
+
+	want := []SourcedAddress{
+		{ServerAddress: a, Sources: []Source{master1, master2, http}},
+		{ServerAddress: b, Sources: []Source{master1, master2}},
+		{ServerAddress: c, Sources: []Source{lan, master1}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeSourced() = %v, want %v", got, want)
+	}
+
+	if s := http.String(); s != "http:https://master1.ddnet.org/ddnet/15/servers.json" {
+		t.Errorf("Source.String() = %q", s)
+	}
+	if s := lan.String(); s != "lan" {
+		t.Errorf("Source.String() = %q", s)
+	}
+}
+
+func TestMergeSourcedEmpty(t *testing.T) {
+	if got := MergeSourced(); len(got) != 0 {
+		t.Errorf("MergeSourced() = %v, want an empty list", got)
+	}
+}
+
+func TestMergeSourcedSameIP(t *testing.T) {
+	a := ServerAddress{IP: net.IPv4(10, 0, 0, 1).To4(), Port: 8303}
+	b := ServerAddress{IP: net.IPv4(10, 0, 0, 1).To4(), Port: 8304}
+	master := Source{Kind: SourceMaster, Name: "master1.teeworlds.com:8283"}
+
+	got := MergeSourced(
+		NewSourcedAddresses([]ServerAddress{a, b}, master),
+		NewSourcedAddresses([]ServerAddress{a}, master),
+	)
+
+	want := []SourcedAddress{
+		{ServerAddress: a, Sources: []Source{master}},
+		{ServerAddress: b, Sources: []Source{master}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeSourced() = %v, want %v", got, want)
+	}
+}
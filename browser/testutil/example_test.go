@@ -0,0 +1,79 @@
+package testutil_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jxsl13/twapi/browser"
+	"github.com/jxsl13/twapi/browser/testutil"
+)
+
+func ExampleMockServer() {
+	game, err := testutil.NewMockServer()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer game.Stop()
+	game.SetServerInfo(browser.ServerInfo{
+		Version:    "0.7.5",
+		Name:       "mock server",
+		Map:        "ctf5",
+		GameType:   "CTF",
+		MaxPlayers: 16,
+		MaxClients: 16,
+	})
+
+	master, err := testutil.NewMockServer()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer master.Stop()
+	master.SetServerList(browser.ServerList{game.Addr()})
+
+	game.Start()
+	master.Start()
+
+	infos, err := browser.ListServersWithInfo(context.Background(),
+		browser.WithMasterServers(master.Addr()),
+		browser.WithMasterServerTimeout(time.Second),
+		browser.WithQueryTimeout(time.Second),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, info := range infos {
+		fmt.Println(info.Name, info.Map, info.GameType)
+	}
+	// Output: mock server ctf5 CTF
+}
+
+func ExampleMockServer_QueueResponse() {
+	master, err := testutil.NewMockServer()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer master.Stop()
+	master.Start()
+
+	// a server list packet with a single server at 1.2.3.4:8303
+	master.QueueResponse(testutil.RequestServerList, []byte(
+		"\xff\xff\xff\xfflis2"+
+			"\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\xff\xff\x01\x02\x03\x04\x20\x6f",
+	))
+
+	ms, err := browser.NewMasterServer(master.Addr().String())
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ms.Close()
+
+	list, err := ms.GetServerList()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(list[0])
+	// Output: 1.2.3.4:8303
+}
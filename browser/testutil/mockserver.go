@@ -0,0 +1,92 @@
+// Package testutil provides a mock Teeworlds 0.7 server that can be used to test
+// code that queries master servers and game servers without network access.
+package testutil
+
+import (
+	"net"
+
+	"github.com/jxsl13/twapi/browser"
+	"github.com/jxsl13/twapi/internal/mockserver"
+)
+
+// ServerToken is the token that every MockServer hands out to its clients.
+// It never changes, which keeps the exchanged packets deterministic.
+const ServerToken = mockserver.DefaultToken
+
+var (
+	// ErrServerClosed is returned by Start if the server has already been stopped.
+	ErrServerClosed = mockserver.ErrServerClosed
+)
+
+// Request is the kind of a connectionless request that a MockServer answers.
+type Request = mockserver.Request
+
+const (
+	// RequestServerList is the request of a master server's server list.
+	RequestServerList = mockserver.RequestServerList
+	// RequestServerCount is the request of the number of servers registered at a master server.
+	RequestServerCount = mockserver.RequestServerCount
+	// RequestInfo is the request of a game server's server info.
+	RequestInfo = mockserver.RequestInfo
+)
+
+// MockServer emulates a master server and a game server on the loopback interface.
+// It answers token requests and responds to requests that carry its token with the
+// queued responses, falling back to the configured server list and server info.
+// The tests of package browser use the same server implementation.
+type MockServer struct {
+	srv *mockserver.Server
+}
+
+// NewMockServer creates a server that listens on a random port of the loopback interface.
+// It does not respond to any requests until Start is called.
+func NewMockServer() (*MockServer, error) {
+	srv, err := mockserver.New()
+	if err != nil {
+		return nil, err
+	}
+	return &MockServer{srv: srv}, nil
+}
+
+// Addr returns the address the server is listening on.
+func (ms *MockServer) Addr() *net.UDPAddr {
+	return ms.srv.Addr()
+}
+
+// Start starts serving requests in the background.
+// Calling Start on a running server does nothing.
+func (ms *MockServer) Start() error {
+	return ms.srv.Start()
+}
+
+// Stop closes the server and waits for it to shut down.
+func (ms *MockServer) Stop() error {
+	return ms.srv.Stop()
+}
+
+// SetServerList sets the list that is sent in response to server list requests,
+// unless a response has been queued. The list is split into packets of at most
+// 75 servers, like master servers do.
+func (ms *MockServer) SetServerList(servers browser.ServerList) {
+	ms.srv.SetServerList(servers)
+}
+
+// SetServerInfo sets the info that is sent in response to server info requests,
+// unless a response has been queued.
+func (ms *MockServer) SetServerInfo(info browser.ServerInfo) {
+	data, _ := info.MarshalBinary()
+	ms.srv.SetServerInfo(data)
+}
+
+// QueueResponse queues a response to the next request of the passed kind.
+// Each payload is sent as a separate packet after the token prefix, which is why a payload
+// starts with the response header, e.g. "\xff\xff\xff\xfflis2". Queued responses are used
+// in the order they were queued. Queuing a response without any payloads drops the request.
+func (ms *MockServer) QueueResponse(r Request, payloads ...[]byte) {
+	ms.srv.QueueResponse(r, payloads...)
+}
+
+// Requests returns the number of received requests of the passed kind that carried a valid token.
+func (ms *MockServer) Requests(r Request) int {
+	return ms.srv.Requests(r)
+}
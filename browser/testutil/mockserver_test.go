@@ -0,0 +1,131 @@
+package testutil
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jxsl13/twapi/browser"
+	"github.com/jxsl13/twapi/internal/mockserver"
+)
+
+func newStartedMockServer(t *testing.T) *MockServer {
+	ms, err := NewMockServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.Start(); err != nil {
+		t.Fatal(err)
+	}
+	return ms
+}
+
+func TestMockServerServerList(t *testing.T) {
+	mock := newStartedMockServer(t)
+	defer mock.Stop()
+
+	servers := make(browser.ServerList, 0, 2*mockserver.MaxServersPerPacket+1)
+	for i := 0; i < cap(servers); i++ {
+		servers = append(servers, &net.UDPAddr{IP: net.IPv4(10, 0, byte(i>>8), byte(i)), Port: browser.DefaultGamePort})
+	}
+	mock.SetServerList(servers)
+
+	ms, err := browser.NewMasterServer(mock.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ms.Close()
+
+	list, err := ms.GetServerList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != len(servers) {
+		t.Errorf("expected %d servers, got %d", len(servers), len(list))
+	}
+	if n := mock.Requests(RequestServerList); n != 1 {
+		t.Errorf("expected a single server list request, got %d", n)
+	}
+}
+
+func TestMockServerQueueResponse(t *testing.T) {
+	mock := newStartedMockServer(t)
+	defer mock.Stop()
+
+	info := browser.ServerInfo{Version: "0.7.5", Name: "default", Map: "dm1", GameType: "DM", MaxPlayers: 8, MaxClients: 8}
+	mock.SetServerInfo(info)
+
+	queued := info
+	queued.Name = "queued"
+	data, _ := queued.MarshalBinary()
+	mock.QueueResponse(RequestInfo, append(append([]byte{}, mockserver.SendInfoHeader...), append([]byte{0}, data...)...))
+
+	query := func() browser.ServerInfo {
+		t.Helper()
+		got, err := browser.QueryServerInfoHost(mock.Addr().String(), browser.WithQueryTimeout(time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	if got := query(); got.Name != "queued" {
+		t.Errorf("expected the queued response, got %q", got.Name)
+	}
+	if got := query(); got.Name != "default" {
+		t.Errorf("expected the default response, got %q", got.Name)
+	}
+	if n := mock.Requests(RequestInfo); n != 2 {
+		t.Errorf("expected two info requests, got %d", n)
+	}
+}
+
+func TestMockServerDroppedRequest(t *testing.T) {
+	mock := newStartedMockServer(t)
+	defer mock.Stop()
+
+	mock.SetServerInfo(browser.ServerInfo{Version: "0.7.5", Name: "retried"})
+	mock.QueueResponse(RequestInfo)
+
+	// the client repeats the dropped request
+	got, err := browser.QueryServerInfoHost(mock.Addr().String(), browser.WithQueryTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "retried" {
+		t.Errorf("expected the default response, got %q", got.Name)
+	}
+	if n := mock.Requests(RequestInfo); n < 2 {
+		t.Errorf("expected at least two info requests, got %d", n)
+	}
+}
+
+func TestMockServerStartStop(t *testing.T) {
+	mock, err := NewMockServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.SetServerList(browser.ServerList{})
+
+	// not started yet
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err = browser.ListServersWithInfo(ctx, browser.WithMasterServers(mock.Addr()), browser.WithMasterServerTimeout(100*time.Millisecond))
+	if err == nil {
+		t.Error("expected an error, as the server has not been started")
+	}
+
+	if err := mock.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.Start(); err != nil {
+		t.Errorf("expected a second Start to do nothing, got %v", err)
+	}
+	if err := mock.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.Start(); err != ErrServerClosed {
+		t.Errorf("expected %v, got %v", ErrServerClosed, err)
+	}
+}
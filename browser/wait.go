@@ -0,0 +1,113 @@
+package browser
+
+import (
+	"sync"
+	"time"
+)
+
+// WaitStrategy decides how long a query waits for a response before it sends its request again,
+// and when it gives up. Every query sends its requests in bursts that grow with every attempt.
+type WaitStrategy interface {
+	// Wait returns the time that is waited for a response after the requests of the attempt have been sent.
+	// The first attempt is 0, elapsed is the time since the query started and timeout is the query's timeout.
+	// A duration that is not positive ends the query with ErrTimeout.
+	Wait(attempt int, elapsed, timeout time.Duration) time.Duration
+}
+
+// RTTObserver can be implemented by a WaitStrategy in order to be notified about the round trip time
+// of every successful attempt, which is measured from sending its requests until receiving a response.
+type RTTObserver interface {
+	ObserveRTT(rtt time.Duration)
+}
+
+// TimeoutWait is the default WaitStrategy. It waits 60ms for the first response and doubles the time
+// with every attempt until the query's timeout elapsed.
+type TimeoutWait struct{}
+
+// Wait implements WaitStrategy.
+func (TimeoutWait) Wait(attempt int, elapsed, timeout time.Duration) time.Duration {
+	return ExponentialWait{Initial: minTimeout}.Wait(attempt, elapsed, timeout)
+}
+
+// ExponentialWait waits Initial for the first response and doubles the time with every attempt.
+// It gives up after MaxAttempts attempts, even if the query's timeout did not elapse yet,
+// e.g. in order to not wait for servers that do not respond to a few requests anyway.
+type ExponentialWait struct {
+	Initial time.Duration
+
+	// MaxAttempts is the maximum number of attempts, values below 1 only limit the query by its timeout.
+	MaxAttempts int
+}
+
+// Wait implements WaitStrategy.
+func (w ExponentialWait) Wait(attempt int, elapsed, timeout time.Duration) time.Duration {
+	if w.MaxAttempts > 0 && attempt >= w.MaxAttempts {
+		return 0
+	}
+
+	wait := w.Initial
+	if wait <= 0 {
+		wait = minTimeout
+	}
+	for i := 0; i < attempt && wait < timeout; i++ {
+		wait *= 2
+	}
+	return limitWait(wait, elapsed, timeout)
+}
+
+// AdaptiveWait waits Factor times the smoothed round trip time of the previous queries for the first response
+// and doubles the time with every attempt until the query's timeout elapsed. Until the first round trip time
+// has been observed, it waits like TimeoutWait. It is meant to be shared by the queries of a scan
+// and is safe for concurrent use.
+type AdaptiveWait struct {
+	// Factor scales the smoothed round trip time, values below 1 default to 3.
+	Factor float64
+
+	mu   sync.Mutex
+	srtt time.Duration
+}
+
+// Wait implements WaitStrategy.
+func (w *AdaptiveWait) Wait(attempt int, elapsed, timeout time.Duration) time.Duration {
+	w.mu.Lock()
+	srtt := w.srtt
+	w.mu.Unlock()
+
+	if srtt == 0 {
+		return TimeoutWait{}.Wait(attempt, elapsed, timeout)
+	}
+
+	factor := w.Factor
+	if factor < 1 {
+		factor = 3
+	}
+	return ExponentialWait{Initial: time.Duration(factor * float64(srtt))}.Wait(attempt, elapsed, timeout)
+}
+
+// ObserveRTT implements RTTObserver. The round trip time is smoothed like TCP does,
+// every new sample is weighted with 1/8.
+func (w *AdaptiveWait) ObserveRTT(rtt time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.srtt == 0 {
+		w.srtt = rtt
+		return
+	}
+	w.srtt += (rtt - w.srtt) / 8
+}
+
+// limitWait shortens wait to the remaining time of the query.
+func limitWait(wait, elapsed, timeout time.Duration) time.Duration {
+	if left := timeout - elapsed; left < wait {
+		return left
+	}
+	return wait
+}
+
+// observeRTT notifies the strategy about the round trip time, if it is an RTTObserver.
+func observeRTT(wait WaitStrategy, rtt time.Duration) {
+	if o, ok := wait.(RTTObserver); ok {
+		o.ObserveRTT(rtt)
+	}
+}
@@ -0,0 +1,110 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingWait waits a fixed time for at most maxAttempts attempts and records every wait.
+type countingWait struct {
+	mu          sync.Mutex
+	wait        time.Duration
+	maxAttempts int
+	waited      time.Duration
+	attempts    int
+	rtts        []time.Duration
+}
+
+func (w *countingWait) Wait(attempt int, elapsed, timeout time.Duration) time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if attempt >= w.maxAttempts {
+		return 0
+	}
+	w.attempts++
+	w.waited += w.wait
+	return w.wait
+}
+
+func (w *countingWait) ObserveRTT(rtt time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.rtts = append(w.rtts, rtt)
+}
+
+func TestWithWaitStrategy(t *testing.T) {
+	// a server that sends its token, but never its info
+	silent := newFakeServer(t)
+	silent.start()
+	defer silent.Close()
+
+	wait := &countingWait{wait: 20 * time.Millisecond, maxAttempts: 3}
+	o := newOptions(WithWaitStrategy(wait), WithQueryTimeout(5*time.Second))
+
+	begin := time.Now()
+	_, err := queryServerInfo(context.Background(), silent.Addr(), o)
+	elapsed := time.Since(begin)
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected %v, got %v", ErrTimeout, err)
+	}
+
+	wait.mu.Lock()
+	defer wait.mu.Unlock()
+
+	// the token is received with the first attempt, the info is never received
+	if wait.attempts != 1+3 {
+		t.Errorf("expected 4 attempts, got %d", wait.attempts)
+	}
+	if len(wait.rtts) != 1 {
+		t.Errorf("expected the round trip time of the token to be observed, got %v", wait.rtts)
+	}
+	if elapsed < 3*wait.wait || elapsed >= time.Second {
+		t.Errorf("expected the query to give up after waiting %v, it took %v", wait.waited, elapsed)
+	}
+}
+
+func TestExponentialWait(t *testing.T) {
+	w := ExponentialWait{Initial: 10 * time.Millisecond, MaxAttempts: 4}
+	tests := []struct {
+		attempt int
+		elapsed time.Duration
+		want    time.Duration
+	}{
+		{0, 0, 10 * time.Millisecond},
+		{1, 10 * time.Millisecond, 20 * time.Millisecond},
+		{2, 30 * time.Millisecond, 40 * time.Millisecond},
+		// limited by the remaining time
+		{3, 70 * time.Millisecond, 30 * time.Millisecond},
+		// limited by the number of attempts
+		{4, 70 * time.Millisecond, 0},
+	}
+	for _, tt := range tests {
+		if got := w.Wait(tt.attempt, tt.elapsed, 100*time.Millisecond); got != tt.want {
+			t.Errorf("Wait(%d, %v) = %v, want %v", tt.attempt, tt.elapsed, got, tt.want)
+		}
+	}
+
+	if got := (TimeoutWait{}).Wait(0, time.Second, time.Second); got > 0 {
+		t.Errorf("expected TimeoutWait to give up once the timeout elapsed, got %v", got)
+	}
+}
+
+func TestAdaptiveWait(t *testing.T) {
+	w := &AdaptiveWait{Factor: 2}
+	if got := w.Wait(0, 0, time.Second); got != minTimeout {
+		t.Errorf("expected the default wait without round trip times, got %v", got)
+	}
+
+	w.ObserveRTT(8 * time.Millisecond)
+	if got := w.Wait(0, 0, time.Second); got != 16*time.Millisecond {
+		t.Errorf("Wait() = %v, want %v", got, 16*time.Millisecond)
+	}
+
+	w.ObserveRTT(16 * time.Millisecond)
+	if got := w.Wait(1, 0, time.Second); got != 36*time.Millisecond {
+		t.Errorf("Wait() = %v, want %v", got, 36*time.Millisecond)
+	}
+}
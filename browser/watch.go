@@ -0,0 +1,160 @@
+package browser
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// EventType describes what changed between two server infos.
+type EventType int
+
+const (
+	// EventInfoChanged is emitted if the server's general info changed, e.g. its name or game type.
+	// It is also emitted for the first info that is received, which contains the initial state.
+	EventInfoChanged EventType = iota
+	// EventMapChanged is emitted if the server changed its map.
+	EventMapChanged
+	// EventPlayerJoined is emitted for every player that joined the server.
+	EventPlayerJoined
+	// EventPlayerLeft is emitted for every player that left the server.
+	EventPlayerLeft
+	// EventScoreChanged is emitted for every player whose score changed.
+	EventScoreChanged
+)
+
+// String returns the name of the event type.
+func (t EventType) String() string {
+	switch t {
+	case EventInfoChanged:
+		return "info changed"
+	case EventMapChanged:
+		return "map changed"
+	case EventPlayerJoined:
+		return "player joined"
+	case EventPlayerLeft:
+		return "player left"
+	case EventScoreChanged:
+		return "score changed"
+	default:
+		return "unknown"
+	}
+}
+
+// ServerInfoEvent is a single change of a server's info.
+type ServerInfoEvent struct {
+	Type EventType
+
+	// Info is the server info after the change.
+	Info ServerInfo
+
+	// Player is the player that joined, left or whose score changed.
+	// It is only set for player events and contains the player's current score.
+	Player PlayerInfo
+
+	// PreviousScore is the score of the player before an EventScoreChanged.
+	PreviousScore int
+}
+
+// Diff returns the events that describe the changes between the server infos from and to.
+// Players are identified by their names, which are unique on a server.
+// If the infos differ only in fields that have no dedicated event, e.g. the clan of a player,
+// a single EventInfoChanged is returned. Equal infos result in no events at all.
+func Diff(from, to ServerInfo) []ServerInfoEvent {
+	events := make([]ServerInfoEvent, 0, 2)
+
+	if from.Map != to.Map {
+		events = append(events, ServerInfoEvent{Type: EventMapChanged, Info: to})
+	}
+
+	oldPlayers := make(map[string]PlayerInfo, len(from.Players))
+	for _, p := range from.Players {
+		oldPlayers[p.Name] = p
+	}
+	newPlayers := make(map[string]bool, len(to.Players))
+
+	for _, p := range to.Players {
+		newPlayers[p.Name] = true
+
+		before, ok := oldPlayers[p.Name]
+		if !ok {
+			events = append(events, ServerInfoEvent{Type: EventPlayerJoined, Info: to, Player: p})
+		} else if before.Score != p.Score {
+			events = append(events, ServerInfoEvent{Type: EventScoreChanged, Info: to, Player: p, PreviousScore: before.Score})
+		}
+	}
+
+	for _, p := range from.Players {
+		if !newPlayers[p.Name] {
+			events = append(events, ServerInfoEvent{Type: EventPlayerLeft, Info: to, Player: p})
+		}
+	}
+
+	generalChanged := from.Address != to.Address || from.Version != to.Version || from.Name != to.Name ||
+		from.Hostname != to.Hostname || from.GameType != to.GameType || from.ServerFlags != to.ServerFlags ||
+		from.SkillLevel != to.SkillLevel || from.MaxPlayers != to.MaxPlayers || from.MaxClients != to.MaxClients
+
+	if generalChanged || (len(events) == 0 && !from.Equal(to)) {
+		events = append(events, ServerInfoEvent{Type: EventInfoChanged, Info: to})
+	}
+	return events
+}
+
+// WatchServer queries the server info of the game server at addr, e.g. "127.0.0.1:8303", every interval
+// and sends an event for every change that is detected by Diff. The first received info is sent as EventInfoChanged.
+// addr may contain a hostname instead of an IP address, see ParseServerAddress for the accepted forms.
+// Failed queries are skipped, the server is queried again after the next interval.
+// The channel is closed once ctx is done. The options are the same that ListServersWithInfo accepts,
+// the query timeout should be shorter than the interval.
+func WatchServer(ctx context.Context, addr string, interval time.Duration, opts ...Option) (<-chan ServerInfoEvent, error) {
+	if interval <= 0 {
+		return nil, ErrInvalidInterval
+	}
+
+	srv, err := resolveUDPAddr(addr, DefaultGamePort)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan ServerInfoEvent)
+	go watchServer(ctx, srv, interval, newOptions(opts...), events)
+	return events, nil
+}
+
+func watchServer(ctx context.Context, srv *net.UDPAddr, interval time.Duration, o options, events chan<- ServerInfoEvent) {
+	defer close(events)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var (
+		last     ServerInfo
+		received bool
+	)
+	for {
+		info, err := queryServerInfo(ctx, srv, o)
+		if err == nil {
+			var changes []ServerInfoEvent
+			if received {
+				changes = Diff(last, info)
+			} else {
+				changes = []ServerInfoEvent{{Type: EventInfoChanged, Info: info}}
+			}
+			last, received = info, true
+
+			for _, event := range changes {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package browser
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	base := ServerInfo{
+		Name:     "server",
+		Map:      "ctf5",
+		GameType: "CTF",
+		Players:  []PlayerInfo{{Name: "alice", Score: 1}, {Name: "bob", Score: 2}},
+	}
+
+	tests := []struct {
+		name   string
+		change func(info *ServerInfo)
+		want   []EventType
+	}{
+		{"equal", func(info *ServerInfo) {}, nil},
+		{"reordered players", func(info *ServerInfo) {
+			info.Players = []PlayerInfo{info.Players[1], info.Players[0]}
+		}, nil},
+		{"map", func(info *ServerInfo) { info.Map = "ctf2" }, []EventType{EventMapChanged}},
+		{"join", func(info *ServerInfo) {
+			info.Players = append(info.Players, PlayerInfo{Name: "carol"})
+		}, []EventType{EventPlayerJoined}},
+		{"leave", func(info *ServerInfo) { info.Players = info.Players[:1] }, []EventType{EventPlayerLeft}},
+		{"score", func(info *ServerInfo) {
+			info.Players = []PlayerInfo{{Name: "alice", Score: 5}, info.Players[1]}
+		}, []EventType{EventScoreChanged}},
+		{"name", func(info *ServerInfo) { info.Name = "renamed" }, []EventType{EventInfoChanged}},
+		{"clan", func(info *ServerInfo) {
+			info.Players = []PlayerInfo{{Name: "alice", Clan: "clan", Score: 1}, info.Players[1]}
+		}, []EventType{EventInfoChanged}},
+		{"map and players", func(info *ServerInfo) {
+			info.Map = "ctf2"
+			info.Players = []PlayerInfo{{Name: "carol"}}
+		}, []EventType{EventMapChanged, EventPlayerJoined, EventPlayerLeft, EventPlayerLeft}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			changed := base
+			changed.Players = append([]PlayerInfo{}, base.Players...)
+			tt.change(&changed)
+
+			events := Diff(base, changed)
+			if len(events) != len(tt.want) {
+				t.Fatalf("Diff() = %v, want %v", events, tt.want)
+			}
+			for idx, event := range events {
+				if event.Type != tt.want[idx] {
+					t.Errorf("event %d: got %v, want %v", idx, event.Type, tt.want[idx])
+				}
+			}
+		})
+	}
+
+	events := Diff(base, ServerInfo{Name: "server", Map: "ctf5", GameType: "CTF", Players: []PlayerInfo{{Name: "alice", Score: 7}, {Name: "bob", Score: 2}}})
+	if len(events) != 1 || events[0].Player.Name != "alice" || events[0].Player.Score != 7 || events[0].PreviousScore != 1 {
+		t.Errorf("unexpected score event: %+v", events)
+	}
+}
+
+func TestWatchServer(t *testing.T) {
+	infos := []ServerInfo{
+		{Version: "0.7.5", Name: "watched", Map: "ctf5", GameType: "CTF", MaxPlayers: 16, MaxClients: 16},
+		{Version: "0.7.5", Name: "watched", Map: "ctf5", GameType: "CTF", MaxPlayers: 16, MaxClients: 16},
+		{Version: "0.7.5", Name: "watched", Map: "ctf2", GameType: "CTF", MaxPlayers: 16, MaxClients: 16},
+		{Version: "0.7.5", Name: "watched", Map: "ctf2", GameType: "CTF", MaxPlayers: 16, MaxClients: 16, NumPlayers: 1,
+			Players: []PlayerInfo{{Name: "alice", Type: 0}}},
+		{Version: "0.7.5", Name: "watched", Map: "ctf2", GameType: "CTF", MaxPlayers: 16, MaxClients: 16, NumPlayers: 1,
+			Players: []PlayerInfo{{Name: "alice", Type: 0, Score: 3}}},
+		{Version: "0.7.5", Name: "watched", Map: "ctf2", GameType: "CTF", MaxPlayers: 16, MaxClients: 16},
+	}
+
+	fs := newFakeServer(t)
+	fs.info = &infos[0]
+	polls := 0
+	fs.beforeResponse = func(fs *fakeServer, addr *net.UDPAddr) {
+		// the last info is kept once all infos have been served
+		if polls < len(infos) {
+			fs.info = &infos[polls]
+		}
+		polls++
+	}
+	fs.start()
+	defer fs.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := WatchServer(ctx, fs.Addr().String(), 10*time.Millisecond, WithQueryTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []EventType{EventInfoChanged, EventMapChanged, EventPlayerJoined, EventScoreChanged, EventPlayerLeft}
+	for _, typ := range want {
+		select {
+		case event := <-events:
+			if event.Type != typ {
+				t.Fatalf("expected %v, got %v", typ, event.Type)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected %v, got no event", typ)
+		}
+	}
+
+	cancel()
+	for event := range events {
+		t.Errorf("unexpected event after the last change: %v", event.Type)
+	}
+}
+
+func TestWatchServerInvalidInterval(t *testing.T) {
+	if _, err := WatchServer(context.Background(), "127.0.0.1:8303", 0); err != ErrInvalidInterval {
+		t.Errorf("expected %v, got %v", ErrInvalidInterval, err)
+	}
+}
@@ -0,0 +1,81 @@
+package message
+
+import (
+	"sync"
+
+	"github.com/jxsl13/twapi/compression"
+)
+
+// HandlerFunc handles a single message. The unpacker is positioned at the first field of the message.
+type HandlerFunc func(u *compression.Unpacker)
+
+// DefaultHandlerFunc handles messages that no handler was registered for.
+type DefaultHandlerFunc func(header Header, u *compression.Unpacker)
+
+// Dispatcher routes received message payloads to the handlers that were registered for their message type,
+// which allows to build event driven bots instead of decoding every message manually.
+// The zero value is ready to use and ignores all messages. It is safe for concurrent use.
+type Dispatcher struct {
+	mu             sync.RWMutex
+	handlers       map[Header]HandlerFunc
+	defaultHandler DefaultHandlerFunc
+}
+
+// Handle registers the handler for the game message id, e.g. NetMsgTypeSvChat.
+// A previously registered handler is replaced, a nil handler removes it.
+func (d *Dispatcher) Handle(id int, handler HandlerFunc) {
+	d.handle(Header{ID: id}, handler)
+}
+
+// HandleSystem registers the handler for the system message id, e.g. NetMsgMapChange.
+func (d *Dispatcher) HandleSystem(id int, handler HandlerFunc) {
+	d.handle(Header{ID: id, System: true}, handler)
+}
+
+// HandleExtended registers the handler for the extended message that is identified by uuid.
+func (d *Dispatcher) HandleExtended(uuid [16]byte, system bool, handler HandlerFunc) {
+	d.handle(Header{ID: compression.MessageIDExtended, System: system, UUID: uuid}, handler)
+}
+
+// HandleDefault registers the handler for all messages without a registered handler.
+func (d *Dispatcher) HandleDefault(handler DefaultHandlerFunc) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.defaultHandler = handler
+}
+
+func (d *Dispatcher) handle(header Header, handler HandlerFunc) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if handler == nil {
+		delete(d.handlers, header)
+		return
+	}
+	if d.handlers == nil {
+		d.handlers = make(map[Header]HandlerFunc)
+	}
+	d.handlers[header] = handler
+}
+
+// Dispatch decodes the header of payload and calls the matching handler.
+// An error is only returned if the header cannot be decoded.
+func (d *Dispatcher) Dispatch(payload []byte) error {
+	header, u, err := DecodeMessageHeader(payload)
+	if err != nil {
+		return err
+	}
+
+	d.mu.RLock()
+	handler, ok := d.handlers[header]
+	defaultHandler := d.defaultHandler
+	d.mu.RUnlock()
+
+	switch {
+	case ok:
+		handler(u)
+	case defaultHandler != nil:
+		defaultHandler(header, u)
+	}
+	return nil
+}
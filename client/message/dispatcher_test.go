@@ -0,0 +1,69 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/jxsl13/twapi/compression"
+)
+
+func TestDispatcher(t *testing.T) {
+	var d Dispatcher
+
+	var chat []string
+	d.Handle(NetMsgTypeSvChat, func(u *compression.Unpacker) {
+		u.Accumulate = true
+		u.NextInt() // mode
+		u.NextInt() // client id
+		u.NextInt() // target id
+		msg, _ := u.NextString()
+		if u.Err() != nil {
+			t.Error(u.Err())
+		}
+		chat = append(chat, msg)
+	})
+
+	var unhandled []Header
+	d.HandleDefault(func(header Header, u *compression.Unpacker) {
+		unhandled = append(unhandled, header)
+	})
+
+	// chat message of client 5 to everyone
+	var p compression.Packer
+	p.AddMessageID(NetMsgTypeSvChat, false)
+	p.MustAdd(1)
+	p.MustAdd(5)
+	p.MustAdd(-1)
+	p.MustAdd("hello")
+
+	if err := d.Dispatch(p.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if len(chat) != 1 || chat[0] != "hello" {
+		t.Errorf("chat handler received %q, want [hello]", chat)
+	}
+
+	// the system message with the same id is not a chat message
+	p.Reset()
+	p.AddMessageID(NetMsgTypeSvChat, true)
+	if err := d.Dispatch(p.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if len(chat) != 1 {
+		t.Errorf("chat handler received a system message")
+	}
+	if len(unhandled) != 1 || unhandled[0] != (Header{ID: NetMsgTypeSvChat, System: true}) {
+		t.Errorf("default handler received %v", unhandled)
+	}
+
+	if err := d.Dispatch(nil); err == nil {
+		t.Error("expected an error for an empty payload")
+	}
+
+	d.Handle(NetMsgTypeSvChat, nil)
+	p.Reset()
+	p.AddMessageID(NetMsgTypeSvChat, false)
+	d.Dispatch(p.Bytes())
+	if len(chat) != 1 || len(unhandled) != 2 {
+		t.Errorf("removed handler was called")
+	}
+}
@@ -0,0 +1,24 @@
+package message
+
+import (
+	"github.com/jxsl13/twapi/compression"
+)
+
+// DecodeMOTD decodes the message of the day that the server sends after a client connected.
+// Line breaks are kept, an empty MOTD hides a previously displayed one.
+func DecodeMOTD(payload []byte) (string, error) {
+	u, err := decodeGameMessage(payload, NetMsgTypeSvMotd)
+	if err != nil {
+		return "", err
+	}
+	return u.NextStringMode(compression.StringSanitize)
+}
+
+// DecodeBroadcast decodes a broadcast message that is displayed in the center of the screen.
+func DecodeBroadcast(payload []byte) (string, error) {
+	u, err := decodeGameMessage(payload, NetMsgTypeSvBroadcast)
+	if err != nil {
+		return "", err
+	}
+	return u.NextStringMode(compression.StringSanitize)
+}
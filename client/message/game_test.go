@@ -0,0 +1,52 @@
+package message
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jxsl13/twapi/compression"
+)
+
+func TestDecodeMOTD(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload []byte
+		want    string
+		wantErr error
+	}{
+		{"motd", append(append([]byte{0x02}, "Welcome!\nHave fun."...), 0), "Welcome!\nHave fun.", nil},
+		{"control characters", append(append([]byte{0x02}, "a\x01b"...), 0), "a b", nil},
+		{"empty", []byte{0x02, 0}, "", nil},
+		{"broadcast", append(append([]byte{0x04}, "hello"...), 0), "", ErrUnexpectedMessage},
+		{"system message", append(append([]byte{0x03}, "hello"...), 0), "", ErrUnexpectedMessage},
+		{"truncated", append([]byte{0x02}, "Welcome"...), "", compression.ErrNoStringToUnpack},
+		{"no data", nil, "", compression.ErrNoDataToUnpack},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeMOTD(tt.payload)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DecodeMOTD() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DecodeMOTD() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeBroadcast(t *testing.T) {
+	payload := append(append([]byte{0x04}, "Round starts in 3 seconds"...), 0)
+	got, err := DecodeBroadcast(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Round starts in 3 seconds" {
+		t.Errorf("DecodeBroadcast() = %q, want %q", got, "Round starts in 3 seconds")
+	}
+
+	if _, err := DecodeBroadcast([]byte{0x02, 0}); !errors.Is(err, ErrUnexpectedMessage) {
+		t.Errorf("expected %v, got %v", ErrUnexpectedMessage, err)
+	}
+}
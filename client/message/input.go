@@ -0,0 +1,66 @@
+package message
+
+import (
+	"github.com/jxsl13/twapi/compression"
+)
+
+// PlayerInputSize is the size of CNetObj_PlayerInput in bytes, which precedes the input in NetMsgInput.
+const PlayerInputSize = 10 * 4
+
+// PlayerInput is the input of a player as sent by the client, its fields are in the order of CNetObj_PlayerInput.
+type PlayerInput struct {
+	// Direction is -1 for walking left, 1 for walking right and 0 for standing still.
+	Direction int
+
+	// TargetX and TargetY are the cursor position relative to the player.
+	TargetX int
+	TargetY int
+
+	Jump int
+
+	// Fire is incremented on every press and release of the fire button,
+	// which is why an odd value means that the button is being held.
+	Fire int
+	Hook int
+
+	PlayerFlags  int
+	WantedWeapon int
+	NextWeapon   int
+	PrevWeapon   int
+}
+
+// PackInput packs the input as variable integers like the client does in NetMsgInput.
+func PackInput(in PlayerInput) []byte {
+	var p compression.Packer
+	p.Add(in.Direction)
+	p.AddVector(in.TargetX, in.TargetY)
+	p.Add(in.Jump)
+	p.Add(in.Fire)
+	p.Add(in.Hook)
+	p.Add(in.PlayerFlags)
+	p.Add(in.WantedWeapon)
+	p.Add(in.NextWeapon)
+	p.Add(in.PrevWeapon)
+	return p.Bytes()
+}
+
+// UnpackInput unpacks an input that has been packed with PackInput.
+func UnpackInput(b []byte) (PlayerInput, error) {
+	u := compression.NewUnpacker(b, true)
+
+	var in PlayerInput
+	in.Direction, _ = u.NextInt()
+	in.TargetX, in.TargetY, _ = u.NextVector()
+	in.Jump, _ = u.NextInt()
+	in.Fire, _ = u.NextInt()
+	in.Hook, _ = u.NextInt()
+	in.PlayerFlags, _ = u.NextInt()
+	in.WantedWeapon, _ = u.NextInt()
+	in.NextWeapon, _ = u.NextInt()
+	in.PrevWeapon, _ = u.NextInt()
+
+	if err := u.Err(); err != nil {
+		return PlayerInput{}, err
+	}
+	return in, nil
+}
@@ -0,0 +1,42 @@
+package message
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jxsl13/twapi/compression"
+)
+
+func TestPackInput(t *testing.T) {
+	inputs := []PlayerInput{
+		{},
+		{
+			Direction:    -1,
+			TargetX:      -320,
+			TargetY:      128,
+			Jump:         1,
+			Fire:         7,
+			Hook:         1,
+			PlayerFlags:  3,
+			WantedWeapon: 2,
+			NextWeapon:   4,
+			PrevWeapon:   5,
+		},
+		{Direction: 1, TargetX: 1 << 20, TargetY: -(1 << 20), Fire: 1 << 30},
+	}
+
+	for _, want := range inputs {
+		got, err := UnpackInput(PackInput(want))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("UnpackInput(PackInput()) = %+v, want %+v", got, want)
+		}
+	}
+
+	packed := PackInput(inputs[1])
+	if _, err := UnpackInput(packed[:len(packed)-1]); !errors.Is(err, compression.ErrNoDataToUnpack) {
+		t.Errorf("expected %v, got %v", compression.ErrNoDataToUnpack, err)
+	}
+}
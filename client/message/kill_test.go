@@ -0,0 +1,54 @@
+package message
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jxsl13/twapi/compression"
+)
+
+func TestDecodeKillMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		decode  func([]byte) (KillEvent, error)
+		payload []byte
+		want    KillEvent
+		wantErr error
+	}{
+		// killer 3 killed the flag carrier 7 with a laser
+		{"0.7", DecodeKillMessage, []byte{0x0a, 0x03, 0x07, 0x04, 0x02}, KillEvent{Killer: 3, Victim: 7, Weapon: WeaponLaser, ModeSpecial: KillFlagVictimHadFlag}, nil},
+		// client 1 fell into a death tile
+		{"0.7 world", DecodeKillMessage, []byte{0x0a, 0x01, 0x01, 0x40, 0x00}, KillEvent{Killer: 1, Victim: 1, Weapon: WeaponWorld}, nil},
+		// killer 0 killed 2 with a grenade while carrying the flag
+		{"0.6", DecodeKillMessage06, []byte{0x08, 0x00, 0x02, 0x03, 0x01}, KillEvent{Killer: 0, Victim: 2, Weapon: WeaponGrenade, ModeSpecial: KillFlagKillerHadFlag}, nil},
+		{"0.6 as 0.7", DecodeKillMessage, []byte{0x08, 0x00, 0x02, 0x03, 0x01}, KillEvent{}, ErrUnexpectedMessage},
+		{"0.7 as 0.6", DecodeKillMessage06, []byte{0x0a, 0x03, 0x07, 0x04, 0x02}, KillEvent{}, ErrUnexpectedMessage},
+		{"truncated", DecodeKillMessage, []byte{0x0a, 0x03, 0x07}, KillEvent{}, compression.ErrNoDataToUnpack},
+		{"unknown weapon", DecodeKillMessage, []byte{0x0a, 0x03, 0x07, 0x06, 0x00}, KillEvent{}, compression.ErrInvalidEnumValue},
+		{"unknown special weapon", DecodeKillMessage06, []byte{0x08, 0x00, 0x02, 0x43, 0x00}, KillEvent{}, compression.ErrInvalidEnumValue},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.decode(tt.payload)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKillEvent(t *testing.T) {
+	e := KillEvent{Killer: 4, Victim: 4, Weapon: WeaponSelf, ModeSpecial: KillFlagKillerHadFlag | KillFlagVictimHadFlag}
+	if !e.Suicide() || !e.KillerHadFlag() || !e.VictimHadFlag() {
+		t.Errorf("unexpected flags of %+v", e)
+	}
+
+	e = KillEvent{Killer: 1, Victim: 2, Weapon: WeaponHammer}
+	if e.Suicide() || e.KillerHadFlag() || e.VictimHadFlag() {
+		t.Errorf("unexpected flags of %+v", e)
+	}
+}
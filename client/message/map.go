@@ -0,0 +1,53 @@
+package message
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// downloadedMapsDir is the directory of the client's storage that downloaded maps are saved to.
+const downloadedMapsDir = "downloadedmaps"
+
+var (
+	// ErrInvalidMapName is returned if a map name contains a path separator, which would let a server
+	// choose a file outside of the directory of the downloaded maps. The reference client rejects such names, too.
+	ErrInvalidMapName = errors.New("invalid map name")
+)
+
+// MapReference identifies a map file by its name and checksums, e.g. the map of a map change message
+// or of a server info. The same name may refer to different versions of a map, which is why the client
+// stores downloaded maps under a file name that contains the checksum.
+type MapReference struct {
+	Name string
+	CRC  uint32
+
+	// SHA256 is the hexadecimal SHA256 checksum of the map, which is only sent by DDNet servers.
+	SHA256 string
+}
+
+// FileName returns the file name that the client stores the downloaded map as, which is "name_sha256.map"
+// if the SHA256 checksum is known like DDNet clients name their maps, otherwise "name_crc.map" like
+// vanilla clients name their maps, the CRC being formatted as eight hexadecimal digits.
+// An error that wraps ErrInvalidMapName is returned if the name contains a slash or a backslash.
+func (m MapReference) FileName() (string, error) {
+	if strings.ContainsAny(m.Name, `/\`) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidMapName, m.Name)
+	}
+
+	if m.SHA256 != "" {
+		return m.Name + "_" + strings.ToLower(m.SHA256) + ".map", nil
+	}
+	return fmt.Sprintf("%s_%08x.map", m.Name, m.CRC), nil
+}
+
+// Path returns the path of the downloaded map relative to the client's storage, e.g. "downloadedmaps/ctf5_b8387b5b.map".
+// The path always uses forward slashes. Invalid names are rejected like FileName rejects them.
+func (m MapReference) Path() (string, error) {
+	name, err := m.FileName()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(downloadedMapsDir, name), nil
+}
@@ -0,0 +1,45 @@
+package message
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMapReference(t *testing.T) {
+	tests := []struct {
+		name     string
+		ref      MapReference
+		fileName string
+	}{
+		{"vanilla", MapReference{Name: "ctf5", CRC: 0xb8387b5b}, "ctf5_b8387b5b.map"},
+		{"leading zeros", MapReference{Name: "dm1", CRC: 0x00c0ffee}, "dm1_00c0ffee.map"},
+		{
+			"ddnet",
+			MapReference{Name: "Kobra 4", CRC: 0xb838f25b, SHA256: "2B1BBB3FD2FF4D9B4E0BF5C6BA9EDDCA3C5D2B7A6B8B4C5F3A0D6A7F4E2C9D81"},
+			"Kobra 4_2b1bbb3fd2ff4d9b4e0bf5c6ba9eddca3c5d2b7a6b8b4c5f3a0d6a7f4e2c9d81.map",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := tt.ref.FileName(); err != nil || got != tt.fileName {
+				t.Errorf("FileName() = %q, %v, want %q", got, err, tt.fileName)
+			}
+			if got, err := tt.ref.Path(); err != nil || got != "downloadedmaps/"+tt.fileName {
+				t.Errorf("Path() = %q, %v, want %q", got, err, "downloadedmaps/"+tt.fileName)
+			}
+		})
+	}
+}
+
+func TestMapReferenceInvalidName(t *testing.T) {
+	for _, name := range []string{"../../x", "maps/ctf5", `..\..\x`, "/etc/passwd"} {
+		ref := MapReference{Name: name, CRC: 0xb8387b5b}
+		if _, err := ref.FileName(); !errors.Is(err, ErrInvalidMapName) {
+			t.Errorf("FileName() of %q: expected %v, got %v", name, ErrInvalidMapName, err)
+		}
+		if _, err := ref.Path(); !errors.Is(err, ErrInvalidMapName) {
+			t.Errorf("Path() of %q: expected %v, got %v", name, ErrInvalidMapName, err)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Package message decodes and encodes the game and system messages that are exchanged
+// between a 0.7 client and a game server within the vital and non-vital chunks.
+package message
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jxsl13/twapi/compression"
+)
+
+// game message types of the 0.7 protocol
+const (
+	NetMsgTypeInvalid = iota
+	NetMsgTypeSvMotd
+	NetMsgTypeSvBroadcast
+	NetMsgTypeSvChat
+	NetMsgTypeSvTeam
+	NetMsgTypeSvKillMsg
+	NetMsgTypeSvTuneParams
+	NetMsgTypeSvExtraProjectile
+	NetMsgTypeSvReadyToEnter
+	NetMsgTypeSvWeaponPickup
+	NetMsgTypeSvEmoticon
+	NetMsgTypeSvVoteClearOptions
+	NetMsgTypeSvVoteOptionListAdd
+	NetMsgTypeSvVoteOptionAdd
+	NetMsgTypeSvVoteOptionRemove
+	NetMsgTypeSvVoteSet
+	NetMsgTypeSvVoteStatus
+	NetMsgTypeSvServerSettings
+	NetMsgTypeSvClientInfo
+	NetMsgTypeSvGameInfo
+	NetMsgTypeSvClientDrop
+	NetMsgTypeSvGameMsg
+	NetMsgTypeDeClientEnter
+	NetMsgTypeDeClientLeave
+	NetMsgTypeClSay
+	NetMsgTypeClSetTeam
+	NetMsgTypeClSetSpectatorMode
+	NetMsgTypeClStartInfo
+	NetMsgTypeClKill
+	NetMsgTypeClReadyChange
+	NetMsgTypeClEmoticon
+	NetMsgTypeClVote
+	NetMsgTypeClCallVote
+)
+
+// system message types of the 0.7 protocol
+const (
+	NetMsgNull = iota
+	NetMsgInfo
+	NetMsgMapChange
+	NetMsgMapData
+	NetMsgServerInfo
+	NetMsgConReady
+	NetMsgSnap
+	NetMsgSnapEmpty
+	NetMsgSnapSingle
+	NetMsgSnapSmall
+	NetMsgInputTiming
+	NetMsgRconAuthOn
+	NetMsgRconAuthOff
+	NetMsgRconLine
+	NetMsgRconCmdAdd
+	NetMsgRconCmdRem
+	NetMsgAuthChallenge
+	NetMsgAuthResult
+	NetMsgReady
+	NetMsgEnterGame
+	NetMsgInput
+	NetMsgRconCmd
+	NetMsgRconAuth
+	NetMsgRequestMapData
+	NetMsgAuthStart
+	NetMsgAuthResponse
+	NetMsgPing
+	NetMsgPingReply
+	NetMsgError
+	NetMsgMaplistEntryAdd
+	NetMsgMaplistEntryRem
+)
+
+var (
+	// ErrUnexpectedMessage is returned if a payload is decoded as a message of a different type.
+	ErrUnexpectedMessage = errors.New("unexpected message type")
+)
+
+// Header identifies the type of a message.
+type Header struct {
+	ID int
+
+	// System is true for messages of the engine, e.g. map downloads or snapshots,
+	// and false for the messages of the game, e.g. chat messages.
+	System bool
+
+	// UUID identifies extended messages, whose ID is compression.MessageIDExtended.
+	UUID [16]byte
+}
+
+// DecodeMessageHeader decodes the header that every message payload starts with.
+// The returned unpacker is positioned at the first field of the message.
+func DecodeMessageHeader(payload []byte) (Header, *compression.Unpacker, error) {
+	u := compression.NewUnpacker(payload, false)
+	id, system, uuid, err := u.NextMessageHeader()
+	if err != nil {
+		return Header{}, nil, err
+	}
+	return Header{ID: id, System: system, UUID: uuid}, u, nil
+}
+
+// decodeGameMessage decodes the header of payload and verifies that it is the game message id.
+func decodeGameMessage(payload []byte, id int) (*compression.Unpacker, error) {
+	header, u, err := DecodeMessageHeader(payload)
+	if err != nil {
+		return nil, err
+	}
+	if header.System || header.ID != id {
+		return nil, fmt.Errorf("%w: %d (system: %t), want %d", ErrUnexpectedMessage, header.ID, header.System, id)
+	}
+	return u, nil
+}
+
+// decodeSystemMessage decodes the header of payload and verifies that it is the system message id.
+func decodeSystemMessage(payload []byte, id int) (*compression.Unpacker, error) {
+	header, u, err := DecodeMessageHeader(payload)
+	if err != nil {
+		return nil, err
+	}
+	if !header.System || header.ID != id {
+		return nil, fmt.Errorf("%w: %d (system: %t), want %d", ErrUnexpectedMessage, header.ID, header.System, id)
+	}
+	return u, nil
+}
@@ -0,0 +1,144 @@
+package message
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jxsl13/twapi/client/network"
+	"github.com/jxsl13/twapi/compression"
+)
+
+const (
+	// MaxSnapshotParts is the maximum number of NetMsgSnap messages a snapshot delta is split into.
+	MaxSnapshotParts = 64
+
+	// MaxSnapshotPartSize is the maximum size of the delta data in a single snapshot message.
+	MaxSnapshotPartSize = 900
+)
+
+var (
+	// ErrInvalidSnapshotPart is returned if a snapshot message contains an invalid part number or size.
+	ErrInvalidSnapshotPart = errors.New("invalid snapshot part")
+)
+
+// SnapshotMessage is one of the system messages NetMsgSnap, NetMsgSnapSingle and NetMsgSnapEmpty
+// that transport the delta between the snapshot of Tick and the snapshot of DeltaTick.
+// A DeltaTick of -1 means that the delta is based on an empty snapshot.
+type SnapshotMessage struct {
+	ID        int
+	Tick      int
+	DeltaTick int
+
+	// NumParts is the number of NetMsgSnap messages the delta is split into and Part the index of this part.
+	// NetMsgSnapSingle and NetMsgSnapEmpty consist of a single part.
+	NumParts int
+	Part     int
+
+	// Crc is the checksum of the snapshot of Tick, it is not set for NetMsgSnapEmpty.
+	Crc int
+
+	// Data is this part of the variable integer compressed delta, NetMsgSnapEmpty does not contain any data,
+	// because the snapshot of Tick equals the snapshot of DeltaTick.
+	Data []byte
+}
+
+// DecodeSnapshotMessage decodes any of the three snapshot messages, which are distinguished by their message id.
+func DecodeSnapshotMessage(payload []byte) (SnapshotMessage, error) {
+	header, u, err := DecodeMessageHeader(payload)
+	if err != nil {
+		return SnapshotMessage{}, err
+	}
+	if !header.System || (header.ID != NetMsgSnap && header.ID != NetMsgSnapSingle && header.ID != NetMsgSnapEmpty) {
+		return SnapshotMessage{}, fmt.Errorf("%w: %d (system: %t), want a snapshot message", ErrUnexpectedMessage, header.ID, header.System)
+	}
+
+	msg := SnapshotMessage{
+		ID:       header.ID,
+		NumParts: 1,
+	}
+
+	u.Accumulate = true
+	msg.Tick, _ = u.NextInt()
+	msg.DeltaTick, _ = u.NextTick(msg.Tick)
+
+	if header.ID == NetMsgSnap {
+		msg.NumParts, _ = u.NextInt()
+		msg.Part, _ = u.NextInt()
+	}
+
+	size := 0
+	if header.ID != NetMsgSnapEmpty {
+		msg.Crc, _ = u.NextInt()
+		size, _ = u.NextInt()
+	}
+	if u.Err() != nil {
+		return SnapshotMessage{}, u.Err()
+	}
+
+	if msg.NumParts < 1 || msg.NumParts > MaxSnapshotParts || msg.Part < 0 || msg.Part >= msg.NumParts ||
+		size < 0 || size > MaxSnapshotPartSize {
+		return SnapshotMessage{}, fmt.Errorf("%w: part %d of %d with %d bytes", ErrInvalidSnapshotPart, msg.Part, msg.NumParts, size)
+	}
+
+	msg.Data, err = u.NextBytes(size)
+	if err != nil {
+		return SnapshotMessage{}, err
+	}
+	return msg, nil
+}
+
+// SnapshotAssembler joins the parts of a snapshot delta that is split into multiple NetMsgSnap messages.
+// Only the parts of the most recent tick are kept, incomplete deltas of older ticks are discarded.
+// The zero value is ready to use.
+type SnapshotAssembler struct {
+	tick     int
+	parts    [][]byte
+	received int
+}
+
+// Add adds a decoded snapshot message. Once the delta of a tick is complete, it is returned with complete being true.
+// NetMsgSnapSingle and NetMsgSnapEmpty messages are complete on their own,
+// the delta of a NetMsgSnapEmpty message is empty, i.e. the snapshot only advances its tick.
+func (a *SnapshotAssembler) Add(msg SnapshotMessage) (delta []byte, complete bool) {
+	if msg.NumParts == 1 {
+		return msg.Data, true
+	}
+
+	if a.parts == nil || msg.Tick != a.tick || len(a.parts) != msg.NumParts {
+		if a.parts != nil && msg.Tick < a.tick {
+			// late part of an outdated snapshot
+			return nil, false
+		}
+		a.tick = msg.Tick
+		a.parts = make([][]byte, msg.NumParts)
+		a.received = 0
+	}
+
+	if a.parts[msg.Part] == nil {
+		a.parts[msg.Part] = msg.Data
+		a.received++
+	}
+	if a.received < len(a.parts) {
+		return nil, false
+	}
+
+	delta = network.JoinChunks(a.parts)
+	a.parts = nil
+	return delta, true
+}
+
+// DecompressSnapshotDelta unpacks the variable integer compressed delta of a snapshot message into its integers.
+func DecompressSnapshotDelta(delta []byte) ([]int, error) {
+	// every integer takes at least one byte
+	ints := make([]int, 0, len(delta))
+
+	v := compression.NewVarIntFrom(delta)
+	for v.Size() > 0 {
+		i, err := v.Unpack()
+		if err != nil {
+			return nil, err
+		}
+		ints = append(ints, i)
+	}
+	return ints, nil
+}
@@ -0,0 +1,126 @@
+package message
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jxsl13/twapi/compression"
+)
+
+// packSnapshotMessage packs a snapshot message like the server does.
+func packSnapshotMessage(msg SnapshotMessage) []byte {
+	var p compression.Packer
+	p.AddMessageID(msg.ID, true)
+	p.MustAdd(msg.Tick)
+	p.MustAdd(msg.Tick - msg.DeltaTick)
+	if msg.ID == NetMsgSnap {
+		p.MustAdd(msg.NumParts)
+		p.MustAdd(msg.Part)
+	}
+	if msg.ID != NetMsgSnapEmpty {
+		p.MustAdd(msg.Crc)
+		p.MustAdd(len(msg.Data))
+		p.MustAdd(msg.Data)
+	}
+	return p.Bytes()
+}
+
+func TestDecodeSnapshotMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  SnapshotMessage
+	}{
+		{"snap", SnapshotMessage{ID: NetMsgSnap, Tick: 1000, DeltaTick: 990, NumParts: 3, Part: 1, Crc: 123456, Data: []byte{1, 2, 3}}},
+		{"single", SnapshotMessage{ID: NetMsgSnapSingle, Tick: 1000, DeltaTick: -1, NumParts: 1, Crc: -42, Data: []byte{4, 5}}},
+		{"empty", SnapshotMessage{ID: NetMsgSnapEmpty, Tick: 1001, DeltaTick: 1000, NumParts: 1, Data: []byte{}}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeSnapshotMessage(packSnapshotMessage(tt.msg))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.msg) {
+				t.Errorf("DecodeSnapshotMessage() = %+v, want %+v", got, tt.msg)
+			}
+		})
+	}
+}
+
+func TestDecodeSnapshotMessageInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload []byte
+		wantErr error
+	}{
+		{"game message", []byte{0x02, 0}, ErrUnexpectedMessage},
+		{"map change", packSnapshotMessage(SnapshotMessage{ID: NetMsgMapChange}), ErrUnexpectedMessage},
+		{"part out of range", packSnapshotMessage(SnapshotMessage{ID: NetMsgSnap, NumParts: 2, Part: 2}), ErrInvalidSnapshotPart},
+		{"too many parts", packSnapshotMessage(SnapshotMessage{ID: NetMsgSnap, NumParts: MaxSnapshotParts + 1}), ErrInvalidSnapshotPart},
+		{"truncated", packSnapshotMessage(SnapshotMessage{ID: NetMsgSnapSingle, Data: []byte{1, 2}})[:6], compression.ErrNotEnoughDataToUnpack},
+		{"missing fields", []byte{NetMsgSnapEmpty<<1 | 1, 10}, compression.ErrNoDataToUnpack},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeSnapshotMessage(tt.payload); !errors.Is(err, tt.wantErr) {
+				t.Errorf("DecodeSnapshotMessage() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSnapshotAssembler(t *testing.T) {
+	var a SnapshotAssembler
+
+	// parts arrive out of order, with a duplicate and a late part of an older tick
+	messages := []SnapshotMessage{
+		{ID: NetMsgSnap, Tick: 50, NumParts: 3, Part: 2, Data: []byte{7, 8}},
+		{ID: NetMsgSnap, Tick: 50, NumParts: 3, Part: 0, Data: []byte{1, 2, 3}},
+		{ID: NetMsgSnap, Tick: 49, NumParts: 2, Part: 1, Data: []byte{9}},
+		{ID: NetMsgSnap, Tick: 50, NumParts: 3, Part: 0, Data: []byte{1, 2, 3}},
+	}
+	for _, msg := range messages {
+		if _, complete := a.Add(msg); complete {
+			t.Fatalf("SnapshotAssembler.Add() completed without part 1")
+		}
+	}
+
+	delta, complete := a.Add(SnapshotMessage{ID: NetMsgSnap, Tick: 50, NumParts: 3, Part: 1, Data: []byte{4, 5, 6}})
+	if !complete {
+		t.Fatal("SnapshotAssembler.Add() did not complete")
+	}
+	if !bytes.Equal(delta, []byte{1, 2, 3, 4, 5, 6, 7, 8}) {
+		t.Errorf("SnapshotAssembler.Add() = %v", delta)
+	}
+
+	delta, complete = a.Add(SnapshotMessage{ID: NetMsgSnapSingle, Tick: 51, NumParts: 1, Data: []byte{1}})
+	if !complete || !bytes.Equal(delta, []byte{1}) {
+		t.Errorf("SnapshotAssembler.Add() = %v, %t for a single snapshot", delta, complete)
+	}
+
+	delta, complete = a.Add(SnapshotMessage{ID: NetMsgSnapEmpty, Tick: 52, NumParts: 1, Data: []byte{}})
+	if !complete || len(delta) != 0 {
+		t.Errorf("SnapshotAssembler.Add() = %v, %t for an empty snapshot", delta, complete)
+	}
+}
+
+func TestDecompressSnapshotDelta(t *testing.T) {
+	want := []int{0, 3, -1, 64, 123456, -7}
+
+	var p compression.Packer
+	for _, i := range want {
+		p.MustAdd(i)
+	}
+
+	got, err := DecompressSnapshotDelta(p.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DecompressSnapshotDelta() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,162 @@
+package message
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jxsl13/twapi/compression"
+)
+
+var (
+	// ErrFieldMismatch is returned if a field is added to a MessagePacker that does not match
+	// the type of the next field of the message spec, or if fields are missing or superfluous.
+	ErrFieldMismatch = errors.New("field does not match the message spec")
+)
+
+// FieldType is the type of a message field as it is packed.
+type FieldType int
+
+const (
+	// FieldInt is a variable integer.
+	FieldInt FieldType = iota
+	// FieldString is a zero terminated string.
+	FieldString
+	// FieldBool is packed as variable integer that is either 0 or 1.
+	FieldBool
+)
+
+// String returns the name of the field type.
+func (t FieldType) String() string {
+	switch t {
+	case FieldInt:
+		return "int"
+	case FieldString:
+		return "string"
+	case FieldBool:
+		return "bool"
+	default:
+		return "unknown"
+	}
+}
+
+// FieldSpec describes a single field of a message.
+type FieldSpec struct {
+	Name string
+	Type FieldType
+}
+
+// MessageSpec describes the id and the field order of a message.
+type MessageSpec struct {
+	Name   string
+	ID     int
+	System bool
+	Fields []FieldSpec
+}
+
+// specs of common messages that are sent by 0.7 clients
+var (
+	SpecInfo = MessageSpec{Name: "Info", ID: NetMsgInfo, System: true, Fields: []FieldSpec{
+		{"Version", FieldString},
+		{"Password", FieldString},
+		{"ClientVersion", FieldInt},
+	}}
+	SpecClSay = MessageSpec{Name: "Cl_Say", ID: NetMsgTypeClSay, Fields: []FieldSpec{
+		{"Mode", FieldInt},
+		{"Target", FieldInt},
+		{"Message", FieldString},
+	}}
+	SpecClSetTeam = MessageSpec{Name: "Cl_SetTeam", ID: NetMsgTypeClSetTeam, Fields: []FieldSpec{
+		{"Team", FieldInt},
+	}}
+	SpecClSetSpectatorMode = MessageSpec{Name: "Cl_SetSpectatorMode", ID: NetMsgTypeClSetSpectatorMode, Fields: []FieldSpec{
+		{"SpecMode", FieldInt},
+		{"SpectatorID", FieldInt},
+	}}
+	SpecClKill     = MessageSpec{Name: "Cl_Kill", ID: NetMsgTypeClKill}
+	SpecClEmoticon = MessageSpec{Name: "Cl_Emoticon", ID: NetMsgTypeClEmoticon, Fields: []FieldSpec{
+		{"Emoticon", FieldInt},
+	}}
+	SpecClVote = MessageSpec{Name: "Cl_Vote", ID: NetMsgTypeClVote, Fields: []FieldSpec{
+		{"Vote", FieldInt},
+	}}
+	SpecClCallVote = MessageSpec{Name: "Cl_CallVote", ID: NetMsgTypeClCallVote, Fields: []FieldSpec{
+		{"Type", FieldString},
+		{"Value", FieldString},
+		{"Reason", FieldString},
+		{"Force", FieldBool},
+	}}
+)
+
+// MessagePacker packs a message and verifies that its fields are added in the order and with the types of its spec.
+// The first mismatch is returned by the failing Add call as well as by every following call, including Bytes.
+type MessagePacker struct {
+	spec  MessageSpec
+	p     compression.Packer
+	field int
+	err   error
+}
+
+// NewMessagePacker creates a packer for messages of spec, the message id is packed right away.
+func NewMessagePacker(spec MessageSpec) *MessagePacker {
+	mp := &MessagePacker{spec: spec}
+	mp.p.AddMessageID(spec.ID, spec.System)
+	return mp
+}
+
+// next verifies that the next field of the spec is of type typ.
+func (mp *MessagePacker) next(typ FieldType) error {
+	if mp.err != nil {
+		return mp.err
+	}
+	if mp.field >= len(mp.spec.Fields) {
+		mp.err = fmt.Errorf("%w: %s has only %d fields, got an additional %s", ErrFieldMismatch, mp.spec.Name, len(mp.spec.Fields), typ)
+		return mp.err
+	}
+
+	field := mp.spec.Fields[mp.field]
+	if field.Type != typ {
+		mp.err = fmt.Errorf("%w: %s.%s is of type %s, got %s", ErrFieldMismatch, mp.spec.Name, field.Name, field.Type, typ)
+		return mp.err
+	}
+	mp.field++
+	return nil
+}
+
+// AddInt adds the next field, which must be an integer.
+func (mp *MessagePacker) AddInt(i int) error {
+	if err := mp.next(FieldInt); err != nil {
+		return err
+	}
+	return mp.p.Add(i)
+}
+
+// AddString adds the next field, which must be a string.
+func (mp *MessagePacker) AddString(s string) error {
+	if err := mp.next(FieldString); err != nil {
+		return err
+	}
+	return mp.p.Add(s)
+}
+
+// AddBool adds the next field, which must be a boolean.
+func (mp *MessagePacker) AddBool(b bool) error {
+	if err := mp.next(FieldBool); err != nil {
+		return err
+	}
+	i := 0
+	if b {
+		i = 1
+	}
+	return mp.p.Add(i)
+}
+
+// Bytes returns the packed message, or an error if a field did not match the spec or fields are missing.
+func (mp *MessagePacker) Bytes() ([]byte, error) {
+	if mp.err != nil {
+		return nil, mp.err
+	}
+	if mp.field < len(mp.spec.Fields) {
+		return nil, fmt.Errorf("%w: %s.%s is missing", ErrFieldMismatch, mp.spec.Name, mp.spec.Fields[mp.field].Name)
+	}
+	return mp.p.Bytes(), nil
+}
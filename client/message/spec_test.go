@@ -0,0 +1,99 @@
+package message
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMessagePacker(t *testing.T) {
+	mp := NewMessagePacker(SpecClSay)
+	if err := mp.AddInt(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := mp.AddInt(-1); err != nil {
+		t.Fatal(err)
+	}
+	if err := mp.AddString("gg"); err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := mp.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := decodeGameMessage(payload, NetMsgTypeClSay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mode, _ := u.NextInt()
+	target, _ := u.NextInt()
+	msg, _ := u.NextString()
+	if mode != 1 || target != -1 || msg != "gg" || u.Size() != 0 {
+		t.Errorf("decoded (%d, %d, %q) with %d remaining bytes", mode, target, msg, u.Size())
+	}
+}
+
+func TestMessagePackerMismatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		spec  MessageSpec
+		build func(mp *MessagePacker)
+	}{
+		{"wrong order", SpecClSay, func(mp *MessagePacker) {
+			mp.AddString("gg")
+			mp.AddInt(1)
+			mp.AddInt(-1)
+		}},
+		{"missing field", SpecClSay, func(mp *MessagePacker) {
+			mp.AddInt(1)
+			mp.AddInt(-1)
+		}},
+		{"additional field", SpecClSay, func(mp *MessagePacker) {
+			mp.AddInt(1)
+			mp.AddInt(-1)
+			mp.AddString("gg")
+			mp.AddString("gg")
+		}},
+		{"bool instead of int", SpecClVote, func(mp *MessagePacker) {
+			mp.AddBool(true)
+		}},
+		{"fields of an empty message", SpecClKill, func(mp *MessagePacker) {
+			mp.AddInt(0)
+		}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			mp := NewMessagePacker(tt.spec)
+			tt.build(mp)
+			if _, err := mp.Bytes(); !errors.Is(err, ErrFieldMismatch) {
+				t.Errorf("expected %v, got %v", ErrFieldMismatch, err)
+			}
+		})
+	}
+
+	// the first mismatch sticks
+	mp := NewMessagePacker(SpecClSay)
+	if err := mp.AddString("gg"); !errors.Is(err, ErrFieldMismatch) {
+		t.Fatalf("expected %v, got %v", ErrFieldMismatch, err)
+	}
+	if err := mp.AddInt(1); !errors.Is(err, ErrFieldMismatch) {
+		t.Errorf("expected %v after a mismatch, got %v", ErrFieldMismatch, err)
+	}
+}
+
+func TestMessagePackerSystem(t *testing.T) {
+	mp := NewMessagePacker(SpecInfo)
+	mp.AddString("0.7 802f1be60a05665f")
+	mp.AddString("")
+	mp.AddInt(0x0705)
+
+	payload, err := mp.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decodeSystemMessage(payload, NetMsgInfo); err != nil {
+		t.Error(err)
+	}
+}
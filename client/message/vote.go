@@ -0,0 +1,106 @@
+package message
+
+// types of NetMsgTypeSvVoteSet, a vote is either started or ended
+const (
+	VoteUnknown = iota
+	VoteStartOption
+	VoteStartKick
+	VoteStartSpec
+	VoteEndAbort
+	VoteEndPass
+	VoteEndFail
+)
+
+// types of votes that can be called with NewCallVote
+const (
+	CallVoteOption   = "option"
+	CallVoteKick     = "kick"
+	CallVoteSpectate = "spectate"
+)
+
+// VoteInfo is a vote that has been started or ended, as sent by the server in NetMsgTypeSvVoteSet.
+type VoteInfo struct {
+	// ClientID is the client that started the vote or -1 if the server started or ended it.
+	ClientID int
+	Type     int
+
+	// Timeout is the number of seconds until the vote ends, it is 0 if the vote ended.
+	Timeout     int
+	Description string
+	Reason      string
+}
+
+// Started returns true if the vote has been started.
+func (v VoteInfo) Started() bool {
+	return v.Type == VoteStartOption || v.Type == VoteStartKick || v.Type == VoteStartSpec
+}
+
+// Ended returns true if the vote has been aborted, passed or failed.
+func (v VoteInfo) Ended() bool {
+	return v.Type == VoteEndAbort || v.Type == VoteEndPass || v.Type == VoteEndFail
+}
+
+// VoteStatus is the number of votes of the running vote, as sent by the server in NetMsgTypeSvVoteStatus.
+type VoteStatus struct {
+	Yes  int
+	No   int
+	Pass int
+
+	// Total is the number of clients that are allowed to vote.
+	Total int
+}
+
+// NewCallVote packs a NetMsgTypeClCallVote message that calls a vote of type, e.g. CallVoteKick.
+// value is the description of the vote option or the client id of the player that is kicked or moved to the spectators.
+func NewCallVote(typ, value, reason string) []byte {
+	mp := NewMessagePacker(SpecClCallVote)
+	mp.AddString(typ)
+	mp.AddString(value)
+	mp.AddString(reason)
+	mp.AddBool(false)
+
+	// the fields match the spec, which is why packing cannot fail
+	payload, _ := mp.Bytes()
+	return payload
+}
+
+// DecodeVoteSet decodes a 0.7 vote set message.
+// An unknown vote type results in an error that wraps compression.ErrInvalidEnumValue.
+func DecodeVoteSet(payload []byte) (VoteInfo, error) {
+	u, err := decodeGameMessage(payload, NetMsgTypeSvVoteSet)
+	if err != nil {
+		return VoteInfo{}, err
+	}
+
+	u.Accumulate = true
+	var v VoteInfo
+	v.ClientID, _ = u.NextInt()
+	v.Type, _ = u.NextEnum(VoteUnknown, VoteStartOption, VoteStartKick, VoteStartSpec,
+		VoteEndAbort, VoteEndPass, VoteEndFail)
+	v.Timeout, _ = u.NextInt()
+	v.Description, _ = u.NextString()
+	v.Reason, _ = u.NextString()
+	if err := u.Err(); err != nil {
+		return VoteInfo{}, err
+	}
+	return v, nil
+}
+
+// DecodeVoteStatus decodes a 0.7 vote status message.
+func DecodeVoteStatus(payload []byte) (VoteStatus, error) {
+	u, err := decodeGameMessage(payload, NetMsgTypeSvVoteStatus)
+	if err != nil {
+		return VoteStatus{}, err
+	}
+
+	u.Accumulate = true
+	var s VoteStatus
+	s.Yes, _ = u.NextInt()
+	s.No, _ = u.NextInt()
+	s.Pass, _ = u.NextInt()
+	s.Total, _ = u.NextInt()
+	if err := u.Err(); err != nil {
+		return VoteStatus{}, err
+	}
+	return s, nil
+}
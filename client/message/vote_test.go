@@ -0,0 +1,92 @@
+package message
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jxsl13/twapi/compression"
+)
+
+func TestNewCallVote(t *testing.T) {
+	payload := NewCallVote(CallVoteKick, "3", "spam")
+
+	u, err := decodeGameMessage(payload, NetMsgTypeClCallVote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	typ, _ := u.NextString()
+	value, _ := u.NextString()
+	reason, _ := u.NextString()
+	force, _ := u.NextInt()
+	if err := u.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if typ != CallVoteKick || value != "3" || reason != "spam" || force != 0 || u.Size() != 0 {
+		t.Errorf("decoded (%q, %q, %q, %d) with %d remaining bytes", typ, value, reason, force, u.Size())
+	}
+}
+
+func TestDecodeVoteSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload []byte
+		want    VoteInfo
+		wantErr error
+	}{
+		// client 2 started a vote to kick a player
+		{"kick", append([]byte{0x1e, 0x02, 0x02, 0x14}, "Kick 'nameless tee'\x00spam\x00"...),
+			VoteInfo{ClientID: 2, Type: VoteStartKick, Timeout: 20, Description: "Kick 'nameless tee'", Reason: "spam"}, nil},
+		// the server ended the vote
+		{"passed", []byte{0x1e, 0x40, 0x05, 0x00, 0x00, 0x00},
+			VoteInfo{ClientID: -1, Type: VoteEndPass}, nil},
+		{"vote status", []byte{0x20, 0x01, 0x00, 0x00, 0x02}, VoteInfo{}, ErrUnexpectedMessage},
+		{"truncated", []byte{0x1e, 0x02, 0x02, 0x14}, VoteInfo{}, compression.ErrNoDataToUnpack},
+		{"unknown type", []byte{0x1e, 0x40, 0x07, 0x00, 0x00, 0x00}, VoteInfo{}, compression.ErrInvalidEnumValue},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeVoteSet(tt.payload)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeVoteStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload []byte
+		want    VoteStatus
+		wantErr error
+	}{
+		{"status", []byte{0x20, 0x03, 0x01, 0x00, 0x06}, VoteStatus{Yes: 3, No: 1, Pass: 0, Total: 6}, nil},
+		{"vote set", []byte{0x1e, 0x40, 0x05, 0x00, 0x00, 0x00}, VoteStatus{}, ErrUnexpectedMessage},
+		{"truncated", []byte{0x20, 0x03, 0x01}, VoteStatus{}, compression.ErrNoDataToUnpack},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeVoteStatus(tt.payload)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVoteInfo(t *testing.T) {
+	if v := (VoteInfo{Type: VoteStartOption}); !v.Started() || v.Ended() {
+		t.Errorf("unexpected state of %+v", v)
+	}
+	if v := (VoteInfo{Type: VoteEndFail}); v.Started() || !v.Ended() {
+		t.Errorf("unexpected state of %+v", v)
+	}
+}
@@ -0,0 +1,109 @@
+package network
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// captureHeaderSize is the size of the header that precedes every packet of a capture:
+// one byte for the direction followed by the packet's size as big endian uint16.
+const captureHeaderSize = 3
+
+// maxCapturePacketSize is the size of the largest packet that fits into a capture record.
+const maxCapturePacketSize = 0xffff
+
+var (
+	// ErrInvalidCapture is returned if a capture contains an unknown direction or
+	// if a packet is too large to be captured.
+	ErrInvalidCapture = errors.New("invalid capture")
+)
+
+// Direction tells whether a captured packet has been sent or received.
+type Direction byte
+
+const (
+	// DirectionSend is used for packets that are sent to the server.
+	DirectionSend Direction = iota + 1
+	// DirectionReceive is used for packets that are received from the server.
+	DirectionReceive
+)
+
+// String returns "send" or "receive".
+func (d Direction) String() string {
+	switch d {
+	case DirectionSend:
+		return "send"
+	case DirectionReceive:
+		return "receive"
+	default:
+		return fmt.Sprintf("unknown direction %d", byte(d))
+	}
+}
+
+// CaptureWriter records packets in the binary capture format that is read by ReplayCapture.
+// Every packet is written as a record consisting of its direction, its size as big endian uint16 and the packet itself.
+type CaptureWriter struct {
+	w io.Writer
+}
+
+// NewCaptureWriter creates a writer that records packets to w.
+func NewCaptureWriter(w io.Writer) *CaptureWriter {
+	return &CaptureWriter{w: w}
+}
+
+// WritePacket records a packet that has been sent or received.
+// The record is written with a single Write call, which is why a CaptureWriter can share a file with other writers.
+func (cw *CaptureWriter) WritePacket(dir Direction, pkt []byte) error {
+	if dir != DirectionSend && dir != DirectionReceive {
+		return fmt.Errorf("%w: %s", ErrInvalidCapture, dir)
+	}
+	if len(pkt) > maxCapturePacketSize {
+		return fmt.Errorf("%w: packet of %d bytes exceeds %d bytes", ErrInvalidCapture, len(pkt), maxCapturePacketSize)
+	}
+
+	record := make([]byte, captureHeaderSize+len(pkt))
+	record[0] = byte(dir)
+	binary.BigEndian.PutUint16(record[1:captureHeaderSize], uint16(len(pkt)))
+	copy(record[captureHeaderSize:], pkt)
+
+	_, err := cw.w.Write(record)
+	return err
+}
+
+// ReplayCapture reads a capture that has been recorded with a CaptureWriter and passes its packets
+// to handler in the order in which they have been recorded.
+// The packet must not be retained by handler, its buffer is reused for the next packet.
+// A capture that ends within a record returns io.ErrUnexpectedEOF, a capture that ends after a record is replayed completely.
+func ReplayCapture(r io.Reader, handler func(dir Direction, pkt []byte)) error {
+	var (
+		header [captureHeaderSize]byte
+		buf    = make([]byte, NetMaxPacketsize)
+	)
+	for {
+		_, err := io.ReadFull(r, header[:])
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		dir := Direction(header[0])
+		if dir != DirectionSend && dir != DirectionReceive {
+			return fmt.Errorf("%w: %s", ErrInvalidCapture, dir)
+		}
+
+		size := int(binary.BigEndian.Uint16(header[1:]))
+		if size > cap(buf) {
+			buf = make([]byte, size)
+		}
+		pkt := buf[:size]
+		if _, err := io.ReadFull(r, pkt); err == io.EOF {
+			return io.ErrUnexpectedEOF
+		} else if err != nil {
+			return err
+		}
+		handler(dir, pkt)
+	}
+}
@@ -0,0 +1,88 @@
+package network
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func TestReplayCapture(t *testing.T) {
+	nb := NewNetBase(nil)
+	packets := []struct {
+		dir  Direction
+		data []byte
+	}{
+		{DirectionSend, []byte{1, 2, 3, 4}},
+		{DirectionReceive, bytes.Repeat([]byte{0, 0, 0, 1}, 100)},
+		{DirectionReceive, []byte{}},
+		{DirectionSend, []byte{5, 6}},
+	}
+
+	var capture bytes.Buffer
+	cw := NewCaptureWriter(&capture)
+	for _, p := range packets {
+		if err := cw.WritePacket(p.dir, nb.packPacket(newTestPacket(p.data))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	i := 0
+	err := ReplayCapture(&capture, func(dir Direction, pkt []byte) {
+		if i >= len(packets) {
+			t.Fatalf("replayed more than %d packets", len(packets))
+		}
+		want := packets[i]
+		i++
+
+		var got NetPacketConstruct
+		if err := nb.UnpackPacket(pkt, &got); err != nil {
+			t.Fatalf("packet %d: %v", i, err)
+		}
+		if dir != want.dir || !bytes.Equal(got.ChunkData[:got.DataSize], want.data) {
+			t.Errorf("packet %d: got %s %v, want %s %v", i, dir, got.ChunkData[:got.DataSize], want.dir, want.data)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i != len(packets) {
+		t.Errorf("replayed %d packets, want %d", i, len(packets))
+	}
+}
+
+func TestReplayCaptureInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		capture []byte
+		wantErr error
+	}{
+		{"empty", []byte{}, nil},
+		{"unknown direction", []byte{3, 0, 1, 0xff}, ErrInvalidCapture},
+		{"truncated header", []byte{byte(DirectionSend), 0}, io.ErrUnexpectedEOF},
+		{"truncated packet", []byte{byte(DirectionSend), 0, 4, 1, 2}, io.ErrUnexpectedEOF},
+		{"missing packet", []byte{byte(DirectionReceive), 0, 4}, io.ErrUnexpectedEOF},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := ReplayCapture(bytes.NewReader(tt.capture), func(Direction, []byte) {
+				t.Error("unexpected packet")
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCaptureWriterInvalid(t *testing.T) {
+	cw := NewCaptureWriter(ioutil.Discard)
+	if err := cw.WritePacket(0, []byte{1}); !errors.Is(err, ErrInvalidCapture) {
+		t.Errorf("expected %v for an unknown direction, got %v", ErrInvalidCapture, err)
+	}
+	if err := cw.WritePacket(DirectionSend, make([]byte, maxCapturePacketSize+1)); !errors.Is(err, ErrInvalidCapture) {
+		t.Errorf("expected %v for an oversized packet, got %v", ErrInvalidCapture, err)
+	}
+}
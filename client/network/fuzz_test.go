@@ -0,0 +1,37 @@
+//go:build go1.18
+// +build go1.18
+
+package network
+
+import (
+	"bytes"
+	"testing"
+)
+
+func FuzzUnpackPacket(f *testing.F) {
+	nb := NewNetBase(nil)
+
+	// packets as they are sent by the reference implementation
+	f.Add(nb.packPacket(newTestPacket(bytes.Repeat([]byte{0, 0, 0, 1}, 100))))
+	f.Add(nb.packPacket(newTestPacket([]byte{0x40, 0x02, 0x01, 0x05})))
+	f.Add([]byte{0x04, 0x00, 0x00, 0xff, 0xff, 0xff, 0xff, NetCtrlMsgToken, 0xde, 0xad, 0xbe, 0xef})
+	f.Add([]byte{0x21, 1, 2, 3, 4, 5, 6, 7, 8, 0xff, 0xff, 0xff, 0xff, 'g', 'i', 'e', '3', 0x00})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var packet NetPacketConstruct
+		if err := nb.UnpackPacket(data, &packet); err != nil {
+			return
+		}
+		if packet.DataSize < 0 || packet.DataSize > len(packet.ChunkData) {
+			t.Fatalf("UnpackPacket() data size = %d", packet.DataSize)
+		}
+
+		headers, chunks, err := packet.UnpackChunks()
+		if err != nil {
+			return
+		}
+		if len(headers) != packet.NumChunks || len(chunks) != packet.NumChunks {
+			t.Fatalf("UnpackChunks() returned %d chunks, want %d", len(chunks), packet.NumChunks)
+		}
+	})
+}
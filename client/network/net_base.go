@@ -1,9 +1,18 @@
 package network
 
-import "net"
+import (
+	"errors"
+	"net"
+
+	"github.com/jxsl13/twapi/compression"
+)
 
 var (
 	netInitializer = NewNetInitializer()
+
+	// ErrInvalidPacket is returned if a received packet is too short, too large, has an unknown version
+	// or cannot be decompressed.
+	ErrInvalidPacket = errors.New("invalid packet")
 )
 
 type NetInitializer struct {
@@ -14,7 +23,148 @@ func NewNetInitializer() NetInitializer {
 }
 
 type NetBase struct {
-	Socket net.UDPConn
+	Socket *net.UDPConn
+
+	huffman     *compression.Huffman
+	compression CompressionMode
+}
+
+// NetBaseOption configures a NetBase.
+type NetBaseOption func(nb *NetBase)
+
+// WithCompression defines which outgoing packets are compressed.
+// The default is CompressionThreshold(NetCompressionThreshold).
+func WithCompression(mode CompressionMode) NetBaseOption {
+	return func(nb *NetBase) {
+		nb.compression = mode
+	}
+}
+
+// NewNetBase creates a new NetBase that sends its packets via the passed socket.
+// Compression of outgoing packets is enabled by default.
+func NewNetBase(socket *net.UDPConn, opts ...NetBaseOption) *NetBase {
+	nb := &NetBase{
+		Socket:      socket,
+		huffman:     compression.NewHuffman(),
+		compression: CompressionThreshold(NetCompressionThreshold),
+	}
+	for _, opt := range opts {
+		opt(nb)
+	}
+	return nb
+}
+
+// SetCompression enables or disables the Huffman compression of outgoing packets.
+// Enabling it restores the default CompressionThreshold(NetCompressionThreshold).
+func (nb *NetBase) SetCompression(enabled bool) {
+	if enabled {
+		nb.compression = CompressionThreshold(NetCompressionThreshold)
+	} else {
+		nb.compression = CompressionNever
+	}
+}
+
+// packPacket packs the packet and compresses it as defined by the compression mode.
+func (nb *NetBase) packPacket(packet *NetPacketConstruct) []byte {
+	if nb.huffman == nil {
+		nb.huffman = compression.NewHuffman()
+	}
+	return packet.pack(nb.huffman, nb.compression)
+}
+
+// SendPacket packs the packet and sends it to addr.
+func (nb *NetBase) SendPacket(addr *net.UDPAddr, packet *NetPacketConstruct) error {
+	_, err := nb.Socket.WriteToUDP(nb.packPacket(packet), addr)
+	return err
+}
+
+// ParsePacketHeader decodes the header of a 0.7 packet:
+//
+//	FFFFFFaa aaaaaaaa NNNNNNNN TTTTTTTT TTTTTTTT TTTTTTTT TTTTTTTT
+//
+// with F being the packet flags, a the acknowledged sequence number, N the number of chunks and T the token.
+// Connectionless packets only consist of the flags, the version and the token, ack and numChunks are zero.
+func ParsePacketHeader(b []byte) (flags byte, ack int, numChunks int, token int, err error) {
+	if len(b) < NetPacketHeaderSize {
+		return 0, 0, 0, 0, ErrInvalidPacket
+	}
+
+	flags = (b[0] & 0b11111100) >> 2
+	if flags&NetPacketFlagConnless != 0 {
+		return flags, 0, 0, int(unpackToken(b[1:5])), nil
+	}
+
+	ack = int(b[0]&0b11)<<8 | int(b[1])
+	numChunks = int(b[2])
+	token = int(unpackToken(b[3:7]))
+	return flags, ack, numChunks, token, nil
+}
+
+// UnpackPacket parses a received packet into packet and decompresses its chunk data, if needed.
+// For control packets that request a connection or a token, the response token is extracted from the chunk data.
+func (nb *NetBase) UnpackPacket(buffer []byte, packet *NetPacketConstruct) error {
+	size := len(buffer)
+	if size < NetPacketHeaderSize || size > NetMaxPacketsize {
+		return ErrInvalidPacket
+	}
+
+	packet.Flags = int(buffer[0]&0b11111100) >> 2
+	packet.ResponseToken = NetTokenNone
+
+	if packet.Flags&NetPacketFlagConnless != 0 {
+		if size < NetPacketHeaderSizeConnless || int(buffer[0]&0b11) != NetPacketversion {
+			return ErrInvalidPacket
+		}
+
+		packet.Flags = NetPacketFlagConnless
+		packet.Ack = 0
+		packet.NumChunks = 0
+		packet.Token = unpackToken(buffer[1:5])
+		packet.ResponseToken = unpackToken(buffer[5:9])
+
+		data := buffer[NetPacketHeaderSizeConnless:]
+		if len(data) > len(packet.ChunkData) {
+			return ErrInvalidPacket
+		}
+		packet.DataSize = copy(packet.ChunkData[:], data)
+		return nil
+	}
+
+	_, ack, numChunks, token, _ := ParsePacketHeader(buffer)
+	packet.Ack = ack
+	packet.NumChunks = numChunks
+	packet.Token = Token(token)
+
+	data := buffer[NetPacketHeaderSize:]
+	switch {
+	case packet.Flags&NetPacketFlagCompression != 0 && len(data) > 0:
+		if nb.huffman == nil {
+			nb.huffman = compression.NewHuffman()
+		}
+		decompressed := packet.ChunkData[:0]
+		if nb.huffman.Decompress(data, len(data), &decompressed, len(packet.ChunkData)) < 0 {
+			return ErrInvalidPacket
+		}
+		packet.DataSize = len(decompressed)
+	case len(data) > len(packet.ChunkData):
+		// the header of connection oriented packets is shorter than the maximum header size
+		return ErrInvalidPacket
+	default:
+		packet.DataSize = copy(packet.ChunkData[:], data)
+	}
+
+	// the response token is part of the control message
+	if packet.Flags&NetPacketFlagControl != 0 && packet.DataSize >= 5 {
+		msg := int(packet.ChunkData[0])
+		if msg == NetCtrlMsgConnect || msg == NetCtrlMsgToken {
+			packet.ResponseToken = unpackToken(packet.ChunkData[1:5])
+		}
+	}
+	return nil
+}
+
+func unpackToken(b []byte) Token {
+	return Token(b[0])<<24 | Token(b[1])<<16 | Token(b[2])<<8 | Token(b[3])
 }
 
 // TODO: Continue here when huffman is ready.
@@ -0,0 +1,240 @@
+package network
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jxsl13/twapi/compression"
+)
+
+func newTestPacket(data []byte) *NetPacketConstruct {
+	p := &NetPacketConstruct{
+		Token:     0x01020304,
+		Ack:       0x2ff,
+		NumChunks: 1,
+		DataSize:  len(data),
+	}
+	copy(p.ChunkData[:], data)
+	return p
+}
+
+func TestNetBase_packPacket(t *testing.T) {
+	large := bytes.Repeat([]byte{0, 0, 0, 1}, 100)
+	small := []byte{1, 2, 3, 4}
+
+	tests := []struct {
+		name           string
+		data           []byte
+		compression    bool
+		wantCompressed bool
+	}{
+		{"large payload", large, true, true},
+		{"small payload", small, true, false},
+		{"compression disabled", large, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nb := NewNetBase(nil)
+			nb.SetCompression(tt.compression)
+
+			packet := newTestPacket(tt.data)
+			b := nb.packPacket(packet)
+
+			flags := int(b[0] >> 2)
+			compressed := flags&NetPacketFlagCompression != 0
+			if compressed != tt.wantCompressed {
+				t.Fatalf("compression flag = %v, want %v", compressed, tt.wantCompressed)
+			}
+
+			ack := int(b[0]&0b11)<<8 | int(b[1])
+			if ack != packet.Ack || int(b[2]) != packet.NumChunks || !bytes.Equal(b[3:7], []byte{1, 2, 3, 4}) {
+				t.Fatalf("invalid header: %v", b[:NetPacketHeaderSize])
+			}
+
+			payload := b[NetPacketHeaderSize:]
+			if compressed {
+				if len(payload) >= len(tt.data) {
+					t.Fatalf("compressed payload is not smaller: %d >= %d", len(payload), len(tt.data))
+				}
+				decompressed := make([]byte, 0, NetMaxPayload)
+				if compression.NewHuffman().Decompress(payload, len(payload), &decompressed, NetMaxPayload) < 0 {
+					t.Fatal("failed to decompress payload")
+				}
+				payload = decompressed
+			}
+
+			if !bytes.Equal(payload, tt.data) {
+				t.Fatalf("payload = %v, want %v", payload, tt.data)
+			}
+		})
+	}
+}
+
+func TestWithCompression(t *testing.T) {
+	large := bytes.Repeat([]byte{0, 0, 0, 1}, 100)
+	small := []byte{1, 2, 3, 4}
+	// random data does not become smaller when being compressed
+	incompressible := []byte{0x9c, 0x3e, 0xf1, 0x07, 0xa5, 0x62, 0xd8, 0x4b}
+
+	tests := []struct {
+		name           string
+		mode           CompressionMode
+		data           []byte
+		wantCompressed bool
+	}{
+		{"never large", CompressionNever, large, false},
+		{"never small", CompressionNever, small, false},
+		{"always large", CompressionAlways, large, true},
+		{"always small", CompressionAlways, small, true},
+		{"always incompressible", CompressionAlways, incompressible, true},
+		{"always empty", CompressionAlways, []byte{}, false},
+		{"threshold reached", CompressionThreshold(len(large)), large, true},
+		{"threshold not reached", CompressionThreshold(len(large) + 1), large, false},
+		{"threshold incompressible", CompressionThreshold(1), incompressible, false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			nb := NewNetBase(nil, WithCompression(tt.mode))
+
+			packet := newTestPacket(tt.data)
+			b := nb.packPacket(packet)
+
+			compressed := int(b[0]>>2)&NetPacketFlagCompression != 0
+			if compressed != tt.wantCompressed {
+				t.Fatalf("compression flag = %v, want %v", compressed, tt.wantCompressed)
+			}
+
+			// the packet must be readable regardless of the mode
+			var got NetPacketConstruct
+			if err := nb.UnpackPacket(b, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got.ChunkData[:got.DataSize], tt.data) {
+				t.Errorf("UnpackPacket() data = %v, want %v", got.ChunkData[:got.DataSize], tt.data)
+			}
+		})
+	}
+
+	// control packets are never compressed
+	nb := NewNetBase(nil, WithCompression(CompressionAlways))
+	packet := newTestPacket(large)
+	packet.Flags = NetPacketFlagControl
+	if b := nb.packPacket(packet); int(b[0]>>2)&NetPacketFlagCompression != 0 {
+		t.Error("control packet has been compressed")
+	}
+}
+
+func TestNetBase_UnpackPacket(t *testing.T) {
+	large := bytes.Repeat([]byte{0, 0, 0, 1}, 100)
+
+	tests := []struct {
+		name        string
+		data        []byte
+		compression bool
+	}{
+		{"compressed", large, true},
+		{"uncompressed", large, false},
+		{"empty", []byte{}, false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			nb := NewNetBase(nil)
+			nb.SetCompression(tt.compression)
+
+			packet := newTestPacket(tt.data)
+			b := nb.packPacket(packet)
+
+			var got NetPacketConstruct
+			if err := nb.UnpackPacket(b, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Flags != packet.Flags || got.Ack != packet.Ack || got.NumChunks != packet.NumChunks || got.Token != packet.Token {
+				t.Errorf("UnpackPacket() header = %+v, want %+v", got, packet)
+			}
+			if got.ResponseToken != NetTokenNone {
+				t.Errorf("UnpackPacket() response token = %x, want none", got.ResponseToken)
+			}
+			if !bytes.Equal(got.ChunkData[:got.DataSize], tt.data) {
+				t.Errorf("UnpackPacket() data = %v, want %v", got.ChunkData[:got.DataSize], tt.data)
+			}
+		})
+	}
+}
+
+func TestNetBase_UnpackPacketConnless(t *testing.T) {
+	b := []byte{NetPacketFlagConnless<<2 | NetPacketversion, 1, 2, 3, 4, 5, 6, 7, 8, 0xff, 0xff, 0xff, 0xff, 'g', 'i', 'e', '3'}
+
+	var packet NetPacketConstruct
+	nb := NewNetBase(nil)
+	if err := nb.UnpackPacket(b, &packet); err != nil {
+		t.Fatal(err)
+	}
+	if packet.Flags != NetPacketFlagConnless || packet.Token != 0x01020304 || packet.ResponseToken != 0x05060708 {
+		t.Errorf("UnpackPacket() = %+v", packet)
+	}
+	if !bytes.Equal(packet.ChunkData[:packet.DataSize], b[NetPacketHeaderSizeConnless:]) {
+		t.Errorf("UnpackPacket() data = %v", packet.ChunkData[:packet.DataSize])
+	}
+
+	// unsupported version
+	b[0] = NetPacketFlagConnless<<2 | 3
+	if err := nb.UnpackPacket(b, &packet); err != ErrInvalidPacket {
+		t.Errorf("expected %v, got %v", ErrInvalidPacket, err)
+	}
+}
+
+func TestNetBase_UnpackPacketControl(t *testing.T) {
+	packet := newTestPacket([]byte{NetCtrlMsgToken, 0xde, 0xad, 0xbe, 0xef})
+	packet.Flags = NetPacketFlagControl
+	packet.NumChunks = 0
+
+	nb := NewNetBase(nil)
+	var got NetPacketConstruct
+	if err := nb.UnpackPacket(nb.packPacket(packet), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ResponseToken != 0xdeadbeef {
+		t.Errorf("UnpackPacket() response token = %x, want %x", got.ResponseToken, 0xdeadbeef)
+	}
+
+	for _, b := range [][]byte{make([]byte, NetPacketHeaderSize-1), make([]byte, NetMaxPacketsize+1)} {
+		if err := nb.UnpackPacket(b, &got); err != ErrInvalidPacket {
+			t.Errorf("expected %v for %d bytes, got %v", ErrInvalidPacket, len(b), err)
+		}
+	}
+}
+
+func TestParsePacketHeader(t *testing.T) {
+	tests := []struct {
+		name          string
+		header        []byte
+		wantFlags     byte
+		wantAck       int
+		wantNumChunks int
+		wantToken     int
+	}{
+		// captured vital packet with two chunks of a compressed snapshot
+		{"compressed", []byte{0x12, 0x34, 0x02, 0x5e, 0x6f, 0xa1, 0x0c}, NetPacketFlagCompression, 0x234, 2, 0x5e6fa10c},
+		{"control", []byte{0x04, 0x00, 0x00, 0xff, 0xff, 0xff, 0xff}, NetPacketFlagControl, 0, 0, 0xffffffff},
+		{"connless", []byte{0x21, 0x12, 0x34, 0x56, 0x78, 1, 2, 3, 4}, NetPacketFlagConnless, 0, 0, 0x12345678},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			flags, ack, numChunks, token, err := ParsePacketHeader(tt.header)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if flags != tt.wantFlags || ack != tt.wantAck || numChunks != tt.wantNumChunks || token != tt.wantToken {
+				t.Errorf("ParsePacketHeader() = %d, %#x, %d, %#x, want %d, %#x, %d, %#x",
+					flags, ack, numChunks, token, tt.wantFlags, tt.wantAck, tt.wantNumChunks, tt.wantToken)
+			}
+		})
+	}
+
+	if _, _, _, _, err := ParsePacketHeader([]byte{0x12, 0x34}); err != ErrInvalidPacket {
+		t.Errorf("expected %v, got %v", ErrInvalidPacket, err)
+	}
+}
@@ -2,6 +2,9 @@ package network
 
 import "net"
 
+// NetMaxChunkSize is the largest chunk size that can be encoded in a chunk header.
+const NetMaxChunkSize = 1<<12 - 1
+
 type NetChunk struct {
 	ClientID int
 	Address  net.UDPAddr
@@ -9,3 +12,37 @@ type NetChunk struct {
 	DataSize int
 	Data     []byte
 }
+
+// SplitIntoChunks splits a payload that is too large for a single chunk into parts of at most maxChunkSize bytes.
+// maxChunkSize is limited to the space that is left in a packet after the chunk header.
+// The parts are not copied, they are subslices of the payload.
+func SplitIntoChunks(payload []byte, maxChunkSize int) [][]byte {
+	if maxChunkSize <= 0 || maxChunkSize > NetMaxPayload-NetMaxChunkHeaderSize {
+		maxChunkSize = NetMaxPayload - NetMaxChunkHeaderSize
+	}
+
+	chunks := make([][]byte, 0, len(payload)/maxChunkSize+1)
+	for len(payload) > maxChunkSize {
+		chunks = append(chunks, payload[:maxChunkSize:maxChunkSize])
+		payload = payload[maxChunkSize:]
+	}
+	return append(chunks, payload)
+}
+
+// JoinChunks reassembles the parts of a payload that was split with SplitIntoChunks, the parts being in their original order.
+// It is a helper for the receivers of split messages, UnpackPacket and UnpackChunks do not use it: the network layer does
+// not fragment messages, every chunk carries a complete message. Payloads that do not fit into a chunk are split by
+// the messages themselves, e.g. the parts of NetMsgSnap, which carry the part number that only their receiver
+// can interpret, see message.SnapshotAssembler.
+func JoinChunks(chunks [][]byte) []byte {
+	size := 0
+	for _, chunk := range chunks {
+		size += len(chunk)
+	}
+
+	payload := make([]byte, 0, size)
+	for _, chunk := range chunks {
+		payload = append(payload, chunk...)
+	}
+	return payload
+}
@@ -1,16 +1,53 @@
 package network
 
+import "errors"
+
+var (
+	// ErrChunkHeaderTooShort is returned if there is not enough data to unpack a chunk header
+	ErrChunkHeaderTooShort = errors.New("chunk header too short")
+
+	// ErrChunkDataTooShort is returned if a chunk header announces more data than there is left in the packet
+	ErrChunkDataTooShort = errors.New("chunk data too short")
+)
+
 type NetChunkHeader struct {
 	Flags    int
 	Size     int
 	Sequence int
 }
 
-// TODO: implement
-func (nch *NetChunkHeader) Pack(data []byte) byte {
-	return 0
+// Pack appends the packed chunk header to data.
+// Only vital chunks contain a sequence number.
+func (nch *NetChunkHeader) Pack(data []byte) []byte {
+	data = append(data,
+		byte((nch.Flags&0b11)<<6)|byte((nch.Size>>6)&0b00111111),
+		byte(nch.Size&0b00111111),
+	)
+
+	if nch.Flags&NetChunkFlagVital != 0 {
+		data[len(data)-1] |= byte((nch.Sequence >> 2) & 0b11000000)
+		data = append(data, byte(nch.Sequence))
+	}
+	return data
 }
 
-func (nch *NetChunkHeader) Unpack(data []byte) byte {
-	return 0
+// Unpack unpacks the chunk header from data and returns the remaining data.
+func (nch *NetChunkHeader) Unpack(data []byte) ([]byte, error) {
+	if len(data) < 2 {
+		return data, ErrChunkHeaderTooShort
+	}
+
+	nch.Flags = int(data[0]>>6) & 0b11
+	nch.Size = int(data[0]&0b00111111)<<6 | int(data[1]&0b00111111)
+	nch.Sequence = -1
+
+	if nch.Flags&NetChunkFlagVital == 0 {
+		return data[2:], nil
+	}
+
+	if len(data) < 3 {
+		return data, ErrChunkHeaderTooShort
+	}
+	nch.Sequence = int(data[1]&0b11000000)<<2 | int(data[2])
+	return data[3:], nil
 }
@@ -0,0 +1,71 @@
+package network
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestSplitIntoChunks(t *testing.T) {
+	payload := make([]byte, 3*NetMaxPayload)
+	rand.New(rand.NewSource(1)).Read(payload)
+
+	parts := SplitIntoChunks(payload, 1000)
+	if len(parts) != 5 {
+		t.Fatalf("SplitIntoChunks() returned %d parts, want %d", len(parts), 5)
+	}
+
+	// send every part as vital chunk, filling as many packets as needed
+	var sender, receiver NetConnection
+	now := time.Now()
+
+	packets := []*NetPacketConstruct{{}}
+	for _, part := range parts {
+		chunk := sender.QueueChunk(NetChunkFlagVital, part, now)
+		if !packets[len(packets)-1].AddChunk(chunk) {
+			packets = append(packets, &NetPacketConstruct{})
+			if !packets[len(packets)-1].AddChunk(chunk) {
+				t.Fatal("chunk does not fit into an empty packet")
+			}
+		}
+	}
+	if len(packets) < 2 {
+		t.Fatalf("expected the payload to be split across multiple packets, got %d", len(packets))
+	}
+
+	// receive the chunks in order and reassemble the payload
+	received := make([][]byte, 0, len(parts))
+	for _, packet := range packets {
+		headers, chunks, err := packet.UnpackChunks()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for idx, header := range headers {
+			if !receiver.ReceiveChunk(header) {
+				t.Fatalf("chunk with sequence %d was dropped", header.Sequence)
+			}
+			received = append(received, chunks[idx])
+		}
+	}
+
+	if !bytes.Equal(JoinChunks(received), payload) {
+		t.Error("reassembled payload differs from the original payload")
+	}
+
+	// a chunk that is larger than the packet is split into the largest possible chunks
+	parts = SplitIntoChunks(payload, NetMaxChunkSize)
+	if len(parts[0]) != NetMaxPayload-NetMaxChunkHeaderSize {
+		t.Errorf("expected the chunk size to be limited to %d, got %d", NetMaxPayload-NetMaxChunkHeaderSize, len(parts[0]))
+	}
+}
+
+func TestNetPacketConstruct_UnpackChunksTooShort(t *testing.T) {
+	var p NetPacketConstruct
+	header := NetChunkHeader{Size: 10}
+	p.AddChunk(header.Pack(nil))
+
+	if _, _, err := p.UnpackChunks(); err != ErrChunkDataTooShort {
+		t.Errorf("expected %v, got %v", ErrChunkDataTooShort, err)
+	}
+}
@@ -0,0 +1,166 @@
+package network
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// NetResendInterval is the time after which a vital chunk that was not acknowledged is resent.
+	NetResendInterval = time.Second
+
+	// NetVitalTimeout is the time after which a connection is considered to be lost,
+	// if the oldest vital chunk has still not been acknowledged by the peer.
+	NetVitalTimeout = 10 * time.Second
+)
+
+var (
+	// ErrConnectionTooWeak is returned if a vital chunk has not been acknowledged within NetVitalTimeout.
+	ErrConnectionTooWeak = errors.New("too weak connection (not acked for 10 seconds)")
+)
+
+// NetConnection keeps track of the vital chunks of a connection.
+// Every vital chunk is sent with a sequence number and kept in a resend buffer
+// until the peer acknowledges it. Received vital chunks are acknowledged in order.
+type NetConnection struct {
+	// Sequence is the sequence number of the last sent vital chunk.
+	Sequence int
+
+	// Ack is the sequence number of the last vital chunk that was received in order.
+	// It is sent to the peer with every packet.
+	Ack int
+
+	// Flags contains packet flags that need to be set on the next outgoing packet.
+	// NetPacketFlagResend is set if the peer has to resend its vital chunks.
+	Flags int
+
+	resendBuffer []NetChunkResend
+}
+
+// QueueChunk packs the header and the data of an outgoing chunk.
+// Vital chunks get the next sequence number and are stored until they are acknowledged.
+func (c *NetConnection) QueueChunk(flags int, data []byte, now time.Time) []byte {
+	header := NetChunkHeader{
+		Flags: flags,
+		Size:  len(data),
+	}
+
+	if flags&NetChunkFlagVital != 0 {
+		c.Sequence = (c.Sequence + 1) % NetMaxSequence
+		header.Sequence = c.Sequence
+
+		c.resendBuffer = append(c.resendBuffer, NetChunkResend{
+			Flags:         flags,
+			DataSize:      len(data),
+			Data:          append([]byte(nil), data...),
+			Sequence:      c.Sequence,
+			LastSendTime:  now.UnixNano(),
+			FirstSendTime: now.UnixNano(),
+		})
+	}
+
+	return packChunk(header, data)
+}
+
+// PendingChunks returns the number of vital chunks that have not been acknowledged yet.
+func (c *NetConnection) PendingChunks() int {
+	return len(c.resendBuffer)
+}
+
+// AckChunks removes all vital chunks from the resend buffer that
+// the peer acknowledged with ack.
+func (c *NetConnection) AckChunks(ack int) {
+	idx := 0
+	for idx < len(c.resendBuffer) && isSeqInBackroom(c.resendBuffer[idx].Sequence, ack) {
+		idx++
+	}
+	c.resendBuffer = c.resendBuffer[idx:]
+}
+
+// ReceiveChunk handles the sequence number of a received chunk and returns true
+// if the chunk is to be processed.
+// Vital chunks that were already received are dropped. If a vital chunk is missing,
+// all following vital chunks are dropped and the peer is asked to resend them.
+func (c *NetConnection) ReceiveChunk(header NetChunkHeader) bool {
+	if header.Flags&NetChunkFlagVital == 0 {
+		return true
+	}
+
+	if header.Sequence == (c.Ack+1)%NetMaxSequence {
+		// in sequence
+		c.Ack = header.Sequence
+		return true
+	}
+
+	// old chunk that we already got
+	if isSeqInBackroom(header.Sequence, c.Ack) {
+		return false
+	}
+
+	// out of sequence, request resend
+	c.SignalResend()
+	return false
+}
+
+// SignalResend asks the peer to resend its vital chunks with the next packet.
+func (c *NetConnection) SignalResend() {
+	c.Flags |= NetPacketFlagResend
+}
+
+// ResendChunks packs all vital chunks that have not been acknowledged yet.
+// This is done when the peer requests a resend.
+func (c *NetConnection) ResendChunks(now time.Time) [][]byte {
+	chunks := make([][]byte, 0, len(c.resendBuffer))
+	for idx := range c.resendBuffer {
+		chunks = append(chunks, c.resendChunk(&c.resendBuffer[idx], now))
+	}
+	return chunks
+}
+
+// Update returns the vital chunks that have not been acknowledged within the NetResendInterval
+// and need to be resent.
+// ErrConnectionTooWeak is returned if the oldest chunk has not been acknowledged within NetVitalTimeout.
+func (c *NetConnection) Update(now time.Time) ([][]byte, error) {
+	if len(c.resendBuffer) == 0 {
+		return nil, nil
+	}
+
+	if now.UnixNano()-c.resendBuffer[0].FirstSendTime > int64(NetVitalTimeout) {
+		return nil, ErrConnectionTooWeak
+	}
+
+	chunks := make([][]byte, 0, len(c.resendBuffer))
+	for idx := range c.resendBuffer {
+		resend := &c.resendBuffer[idx]
+		if now.UnixNano()-resend.LastSendTime > int64(NetResendInterval) {
+			chunks = append(chunks, c.resendChunk(resend, now))
+		}
+	}
+	return chunks, nil
+}
+
+func (c *NetConnection) resendChunk(resend *NetChunkResend, now time.Time) []byte {
+	resend.LastSendTime = now.UnixNano()
+
+	header := NetChunkHeader{
+		Flags:    resend.Flags | NetChunkFlagResend,
+		Size:     resend.DataSize,
+		Sequence: resend.Sequence,
+	}
+	return packChunk(header, resend.Data)
+}
+
+func packChunk(header NetChunkHeader, data []byte) []byte {
+	chunk := header.Pack(make([]byte, 0, NetMaxChunkHeaderSize+len(data)))
+	return append(chunk, data...)
+}
+
+// isSeqInBackroom returns true if seq is older than or equal to ack,
+// taking the wrap around of sequence numbers into account.
+func isSeqInBackroom(seq, ack int) bool {
+	bottom := ack - NetMaxSequence/2
+	if bottom < 0 {
+		return seq <= ack || seq >= bottom+NetMaxSequence
+	}
+	return seq <= ack && seq >= bottom
+}
@@ -0,0 +1,149 @@
+package network
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func unpackChunk(t *testing.T, chunk []byte) (NetChunkHeader, []byte) {
+	var header NetChunkHeader
+	data, err := header.Unpack(chunk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != header.Size {
+		t.Fatalf("chunk size mismatch: header %d data %d", header.Size, len(data))
+	}
+	return header, data
+}
+
+func TestNetConnection_DroppedVitalChunk(t *testing.T) {
+	var sender, receiver NetConnection
+	now := time.Now()
+
+	chunks := make([][]byte, 0, 3)
+	for i := 1; i <= 3; i++ {
+		chunks = append(chunks, sender.QueueChunk(NetChunkFlagVital, []byte(fmt.Sprintf("message %d", i)), now))
+	}
+
+	received := make([][]byte, 0, 3)
+	deliver := func(chunk []byte) {
+		header, data := unpackChunk(t, chunk)
+		if receiver.ReceiveChunk(header) {
+			received = append(received, data)
+		}
+	}
+
+	// the second chunk gets lost
+	deliver(chunks[0])
+	deliver(chunks[2])
+
+	if len(received) != 1 || receiver.Ack != 1 {
+		t.Fatalf("expected only the first chunk to be received, got %q ack %d", received, receiver.Ack)
+	}
+	if receiver.Flags&NetPacketFlagResend == 0 {
+		t.Fatal("expected receiver to request a resend")
+	}
+
+	// the receiver's next packet acknowledges the first chunk and requests a resend
+	sender.AckChunks(receiver.Ack)
+	if sender.PendingChunks() != 2 {
+		t.Fatalf("expected 2 pending chunks, got %d", sender.PendingChunks())
+	}
+
+	for _, chunk := range sender.ResendChunks(now) {
+		header, _ := unpackChunk(t, chunk)
+		if header.Flags&NetChunkFlagResend == 0 {
+			t.Fatal("expected resent chunk to have the resend flag")
+		}
+		deliver(chunk)
+	}
+
+	for idx, data := range received {
+		want := []byte(fmt.Sprintf("message %d", idx+1))
+		if !bytes.Equal(data, want) {
+			t.Errorf("received %q, want %q", data, want)
+		}
+	}
+
+	sender.AckChunks(receiver.Ack)
+	if receiver.Ack != 3 || sender.PendingChunks() != 0 {
+		t.Fatalf("expected all chunks to be acknowledged, ack %d pending %d", receiver.Ack, sender.PendingChunks())
+	}
+
+	// duplicates are dropped
+	deliver(chunks[1])
+	if len(received) != 3 {
+		t.Fatalf("expected duplicate chunk to be dropped, got %d chunks", len(received))
+	}
+}
+
+func TestNetConnection_Update(t *testing.T) {
+	var c NetConnection
+	now := time.Now()
+	c.QueueChunk(NetChunkFlagVital, []byte("vital"), now)
+	c.QueueChunk(0, []byte("not vital"), now)
+
+	chunks, err := c.Update(now.Add(NetResendInterval / 2))
+	if err != nil || len(chunks) != 0 {
+		t.Fatalf("expected nothing to resend, got %d chunks, %v", len(chunks), err)
+	}
+
+	chunks, err = c.Update(now.Add(NetResendInterval + time.Millisecond))
+	if err != nil || len(chunks) != 1 {
+		t.Fatalf("expected one chunk to resend, got %d chunks, %v", len(chunks), err)
+	}
+
+	_, err = c.Update(now.Add(NetVitalTimeout + time.Millisecond))
+	if err != ErrConnectionTooWeak {
+		t.Fatalf("expected %v, got %v", ErrConnectionTooWeak, err)
+	}
+}
+
+func TestNetChunkHeader_PackUnpack(t *testing.T) {
+	tests := []NetChunkHeader{
+		{Flags: 0, Size: 0, Sequence: -1},
+		{Flags: 0, Size: 4095, Sequence: -1},
+		{Flags: NetChunkFlagVital, Size: 1234, Sequence: 1023},
+		{Flags: NetChunkFlagVital | NetChunkFlagResend, Size: 1, Sequence: 256},
+	}
+	for _, want := range tests {
+		packed := want.Pack(nil)
+
+		var got NetChunkHeader
+		rest, err := got.Unpack(packed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rest) != 0 || got != want {
+			t.Errorf("NetChunkHeader.Unpack() = %+v, want %+v", got, want)
+		}
+	}
+
+	var h NetChunkHeader
+	if _, err := h.Unpack([]byte{0b01000000, 0}); err != ErrChunkHeaderTooShort {
+		t.Errorf("expected %v, got %v", ErrChunkHeaderTooShort, err)
+	}
+}
+
+func TestIsSeqInBackroom(t *testing.T) {
+	tests := []struct {
+		seq, ack int
+		want     bool
+	}{
+		{5, 10, true},
+		{10, 10, true},
+		{11, 10, false},
+		{1020, 3, true},
+		{500, 3, false},
+		{3, 1020, false},
+		{600, 1020, true},
+	}
+	for _, tt := range tests {
+		if got := isSeqInBackroom(tt.seq, tt.ack); got != tt.want {
+			t.Errorf("isSeqInBackroom(%d, %d) = %v, want %v", tt.seq, tt.ack, got, tt.want)
+		}
+	}
+}
@@ -1,5 +1,43 @@
 package network
 
+import "github.com/jxsl13/twapi/compression"
+
+// NetCompressionThreshold is the minimum payload size in bytes that is worth
+// being compressed. Smaller payloads are always sent uncompressed.
+const NetCompressionThreshold = 32
+
+// CompressionMode defines which outgoing packets are Huffman compressed.
+// Control packets are never compressed.
+type CompressionMode struct {
+	never     bool
+	always    bool
+	threshold int
+}
+
+var (
+	// CompressionNever sends every packet uncompressed, e.g. in order to inspect the packets while debugging.
+	CompressionNever = CompressionMode{never: true}
+
+	// CompressionAlways compresses every packet that contains any data, even if the compressed data
+	// is not smaller than the uncompressed data.
+	CompressionAlways = CompressionMode{always: true}
+)
+
+// CompressionThreshold compresses packets with at least n bytes of chunk data,
+// given that the compressed data is smaller than the uncompressed data.
+// This is the default with a threshold of NetCompressionThreshold.
+func CompressionThreshold(n int) CompressionMode {
+	return CompressionMode{threshold: n}
+}
+
+// compresses returns true if chunk data of the passed size is to be compressed.
+func (m CompressionMode) compresses(size int) bool {
+	if m.never || size == 0 {
+		return false
+	}
+	return m.always || size >= m.threshold
+}
+
 type NetPacketConstruct struct {
 	Token         Token
 	ResponseToken Token
@@ -10,3 +48,80 @@ type NetPacketConstruct struct {
 	DataSize  int
 	ChunkData [NetMaxPayload]byte
 }
+
+// Pack creates the packet that is sent over the network, consisting of the packet header
+// followed by the chunk data.
+// If huffman is not nil, the chunk data of non control packets exceeding the NetCompressionThreshold
+// is compressed and the compression flag is set, given that the compressed data is smaller than the
+// uncompressed data. Otherwise the compression flag is removed.
+func (p *NetPacketConstruct) Pack(huffman *compression.Huffman) []byte {
+	return p.pack(huffman, CompressionThreshold(NetCompressionThreshold))
+}
+
+// pack creates the packet like Pack does, but compresses the chunk data as defined by mode.
+func (p *NetPacketConstruct) pack(huffman *compression.Huffman, mode CompressionMode) []byte {
+	buffer := make([]byte, NetPacketHeaderSize, NetMaxPacketsize)
+	data := p.ChunkData[:p.DataSize]
+
+	compressedSize := -1
+	if huffman != nil && p.Flags&NetPacketFlagControl == 0 && mode.compresses(p.DataSize) {
+		compressed := buffer[NetPacketHeaderSize:]
+		compressedSize = huffman.Compress(data, len(data), &compressed, NetMaxPayload)
+	}
+
+	if compressedSize > 0 && (mode.always || compressedSize < p.DataSize) {
+		p.Flags |= NetPacketFlagCompression
+		buffer = buffer[:NetPacketHeaderSize+compressedSize]
+	} else {
+		p.Flags &^= NetPacketFlagCompression
+		buffer = append(buffer, data...)
+	}
+
+	buffer[0] = byte((p.Flags<<2)&0b11111100) | byte((p.Ack>>8)&0b00000011)
+	buffer[1] = byte(p.Ack)
+	buffer[2] = byte(p.NumChunks)
+	buffer[3] = byte(p.Token >> 24)
+	buffer[4] = byte(p.Token >> 16)
+	buffer[5] = byte(p.Token >> 8)
+	buffer[6] = byte(p.Token)
+	return buffer
+}
+
+// AddChunk appends a packed chunk, e.g. created by NetConnection.QueueChunk, to the chunk data.
+// Returns false if the chunk does not fit into the packet anymore, in which case
+// the packet needs to be sent and the chunk is to be added to the next packet.
+func (p *NetPacketConstruct) AddChunk(chunk []byte) bool {
+	if p.DataSize+len(chunk) > NetMaxPayload || p.NumChunks >= NetMaxPacketChunks-1 {
+		return false
+	}
+
+	copy(p.ChunkData[p.DataSize:], chunk)
+	p.DataSize += len(chunk)
+	p.NumChunks++
+	return true
+}
+
+// UnpackChunks splits the (uncompressed) chunk data into its NumChunks chunks.
+// The returned data slices reference the packet's chunk data.
+func (p *NetPacketConstruct) UnpackChunks() ([]NetChunkHeader, [][]byte, error) {
+	headers := make([]NetChunkHeader, 0, p.NumChunks)
+	chunks := make([][]byte, 0, p.NumChunks)
+
+	data := p.ChunkData[:p.DataSize]
+	for i := 0; i < p.NumChunks; i++ {
+		var header NetChunkHeader
+		rest, err := header.Unpack(data)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if header.Size > len(rest) {
+			return nil, nil, ErrChunkDataTooShort
+		}
+
+		headers = append(headers, header)
+		chunks = append(chunks, rest[:header.Size:header.Size])
+		data = rest[header.Size:]
+	}
+	return headers, chunks, nil
+}
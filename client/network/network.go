@@ -45,6 +45,13 @@ const (
 	NetTokenCachePacketexpiry  = 5
 )
 
+// address types of the reference implementation's NETADDR
+const (
+	NetTypeInvalid = 0
+	NetTypeIPv4    = 1
+	NetTypeIPv6    = 2
+)
+
 const (
 	NetTokenMax  = 0xffffffff
 	NetTokenNone = NetTokenMax
@@ -1,5 +1,58 @@
 package network
 
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"net"
+)
+
+// netAddrSize is the size of the reference implementation's NETADDR including its padding:
+// the uint32 address type, 16 bytes of ip, the uint16 port and two bytes of padding.
+const netAddrSize = 24
+
 // Token is sent to packages in order to verify a client's identity.
 // This is used to prevent ip spoofing
 type Token uint32
+
+// ComputeSecurityToken derives the token that a server hands out to the client at addr, like
+// CNetTokenManager::GenerateToken of the Teeworlds 0.7.x server (0.7.0 up to 0.7.5) does.
+// The port of addr is not part of the token. seed is the server's current seed, which is
+// an int64 that is renewed every NetSeedTime seconds, in the memory layout of the server,
+// i.e. 8 bytes in little endian byte order on x86 servers.
+// The token is the xor of the four little endian uint32 words of the md5 hash of the NETADDR
+// followed by the seed. NetTokenNone is never returned, it is replaced with NetTokenNone-1.
+// Addresses that are not IP addresses result in the token of the zero address.
+func ComputeSecurityToken(addr net.Addr, seed []byte) Token {
+	buf := make([]byte, netAddrSize, netAddrSize+len(seed))
+
+	var ip net.IP
+	switch a := addr.(type) {
+	case *net.UDPAddr:
+		ip = a.IP
+	case *net.TCPAddr:
+		ip = a.IP
+	case *net.IPAddr:
+		ip = a.IP
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		binary.LittleEndian.PutUint32(buf[0:4], NetTypeIPv4)
+		copy(buf[4:20], ip4)
+	} else if ip16 := ip.To16(); ip16 != nil {
+		binary.LittleEndian.PutUint32(buf[0:4], NetTypeIPv6)
+		copy(buf[4:20], ip16)
+	}
+	buf = append(buf, seed...)
+
+	digest := md5.Sum(buf)
+	result := binary.LittleEndian.Uint32(digest[0:4]) ^
+		binary.LittleEndian.Uint32(digest[4:8]) ^
+		binary.LittleEndian.Uint32(digest[8:12]) ^
+		binary.LittleEndian.Uint32(digest[12:16])
+
+	token := Token(result & NetTokenMask)
+	if token == NetTokenNone {
+		token--
+	}
+	return token
+}
@@ -0,0 +1,46 @@
+package network
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+)
+
+func TestComputeSecurityToken(t *testing.T) {
+	seed1 := make([]byte, 8)
+	binary.LittleEndian.PutUint64(seed1, 0x0123456789abcdef)
+	seed2 := make([]byte, 8)
+	seed := int64(-42)
+	binary.LittleEndian.PutUint64(seed2, uint64(seed))
+
+	tests := []struct {
+		name  string
+		addr  net.Addr
+		seed  []byte
+		token Token
+	}{
+		{"ipv4 loopback", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8303}, seed1, 0x01f7656a},
+		{"ipv4 loopback other seed", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8303}, seed2, 0x8dc5382f},
+		{"ipv4", &net.UDPAddr{IP: net.IPv4(192, 168, 1, 10), Port: 50000}, seed1, 0xf9a09cb6},
+		{"ipv4 other seed", &net.UDPAddr{IP: net.IPv4(192, 168, 1, 10)}, seed2, 0xf113111a},
+		{"ipv6 loopback", &net.UDPAddr{IP: net.IPv6loopback, Port: 8303}, seed1, 0x5ff8de8f},
+		{"ipv6", &net.UDPAddr{IP: net.ParseIP("2001:db8::1")}, seed2, 0x9e12cf14},
+		{"ip addr", &net.IPAddr{IP: net.ParseIP("2001:db8::1")}, seed1, 0x37230df7},
+		{"no ip", &net.UnixAddr{Name: "/tmp/socket", Net: "unix"}, seed1, 0x558a017c},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ComputeSecurityToken(tt.addr, tt.seed); got != tt.token {
+				t.Errorf("ComputeSecurityToken() = %#08x, want %#08x", got, tt.token)
+			}
+		})
+	}
+
+	// the port is not part of the token
+	a := ComputeSecurityToken(&net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1}, seed1)
+	b := ComputeSecurityToken(&net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 2}, seed1)
+	if a != b {
+		t.Errorf("tokens of different ports differ: %#08x != %#08x", a, b)
+	}
+}
@@ -0,0 +1,183 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+)
+
+// event types of the 0.7 protocol, events are items that are only contained in a single snapshot
+const (
+	NetEventTypeCommon = iota + NetObjTypeDeTuneParams + 1
+	NetEventTypeExplosion
+	NetEventTypeSpawn
+	NetEventTypeHammerHit
+	NetEventTypeDeath
+	NetEventTypeSoundWorld
+	NetEventTypeDamage
+)
+
+// deltaHeaderSize is the number of integers that precede the deleted keys of a delta:
+// the number of deleted items, the number of updated items and the unused number of temporary items.
+const deltaHeaderSize = 3
+
+var (
+	// ErrInvalidDelta is returned if a snapshot delta is truncated or inconsistent with its base.
+	ErrInvalidDelta = errors.New("invalid snapshot delta")
+
+	// ErrSnapshotCRCMismatch is returned if the checksum of a reconstructed snapshot differs from the checksum
+	// that the server sent along with the delta, which means that the client lost track of the game state.
+	ErrSnapshotCRCMismatch = errors.New("snapshot crc mismatch")
+
+	// ErrMissingBase is returned if the snapshot that a delta is based on is not stored (anymore).
+	ErrMissingBase = errors.New("missing base snapshot")
+)
+
+// itemSizes contains the number of data integers of the 0.7 item types whose size is not sent in deltas.
+var itemSizes = map[int]int{
+	NetObjTypePlayerInput:   10,
+	NetObjTypeProjectile:    6,
+	NetObjTypeLaser:         5,
+	NetObjTypePickup:        3,
+	NetObjTypeFlag:          3,
+	NetObjTypeGameData:      3,
+	NetObjTypeGameDataTeam:  2,
+	NetObjTypeGameDataFlag:  4,
+	NetObjTypeCharacterCore: 15,
+	NetObjTypeCharacter:     22,
+	NetObjTypePlayerInfo:    3,
+	NetObjTypeSpectatorInfo: 4,
+	NetObjTypeDeClientInfo:  58,
+	NetObjTypeDeGameInfo:    5,
+	NetObjTypeDeTuneParams:  32,
+	NetEventTypeCommon:      2,
+	NetEventTypeExplosion:   2,
+	NetEventTypeSpawn:       2,
+	NetEventTypeHammerHit:   2,
+	NetEventTypeDeath:       3,
+	NetEventTypeSoundWorld:  3,
+	NetEventTypeDamage:      7,
+}
+
+// Crc returns the checksum of the snapshot, which is the sum of the data of all items without their keys.
+func (s *Snapshot) Crc() int {
+	var crc int32
+	for _, it := range s.Items {
+		for _, v := range it.Data {
+			crc += int32(v)
+		}
+	}
+	return int(crc)
+}
+
+// UnpackDelta reconstructs a snapshot from the snapshot base and the decompressed delta of a snapshot message,
+// see message.DecompressSnapshotDelta. A nil base is an empty snapshot, which deltas with a DeltaTick of -1 are based on.
+// The delta consists of the keys of the items that are removed from base and the items that are added or changed,
+// the data of changed items is the difference to the item of base.
+// The checksum of the reconstructed snapshot is compared to crc, the checksum that has been sent along with the delta,
+// a mismatch returns an error that wraps ErrSnapshotCRCMismatch. An empty delta, as it is sent by NetMsgSnapEmpty,
+// does not change the snapshot and is not verified, because NetMsgSnapEmpty does not contain a checksum.
+func UnpackDelta(base *Snapshot, delta []int, crc int) (*Snapshot, error) {
+	if base == nil {
+		base = &Snapshot{}
+	}
+	if len(delta) == 0 {
+		return &Snapshot{Items: append([]Item(nil), base.Items...)}, nil
+	}
+
+	if len(delta) < deltaHeaderSize {
+		return nil, fmt.Errorf("%w: missing header", ErrInvalidDelta)
+	}
+	numDeleted, numUpdated := delta[0], delta[1]
+	data := delta[deltaHeaderSize:]
+	if numDeleted < 0 || numUpdated < 0 || numUpdated > MaxItems || numDeleted > len(data) {
+		return nil, fmt.Errorf("%w: %d deleted and %d updated items", ErrInvalidDelta, numDeleted, numUpdated)
+	}
+
+	deleted := make(map[int]bool, numDeleted)
+	for _, key := range data[:numDeleted] {
+		deleted[key] = true
+	}
+	data = data[numDeleted:]
+
+	snap := &Snapshot{Items: make([]Item, 0, len(base.Items)+numUpdated)}
+	index := make(map[int]int, len(base.Items)+numUpdated)
+	baseItems := make(map[int]Item, len(base.Items))
+	for _, it := range base.Items {
+		baseItems[it.Key()] = it
+		if deleted[it.Key()] {
+			continue
+		}
+		index[it.Key()] = len(snap.Items)
+		snap.Items = append(snap.Items, it)
+	}
+
+	for i := 0; i < numUpdated; i++ {
+		if len(data) < 2 {
+			return nil, fmt.Errorf("%w: item %d is truncated", ErrInvalidDelta, i)
+		}
+		typ, id := data[0], data[1]
+		data = data[2:]
+
+		size, ok := itemSizes[typ]
+		if !ok {
+			if len(data) < 1 {
+				return nil, fmt.Errorf("%w: item %d is truncated", ErrInvalidDelta, i)
+			}
+			size = data[0]
+			data = data[1:]
+		}
+		if typ < 0 || typ > 0xffff || id < 0 || id > 0xffff || size < 0 || size > len(data) {
+			return nil, fmt.Errorf("%w: item %d of type %d with id %d and %d integers", ErrInvalidDelta, i, typ, id, size)
+		}
+
+		it := Item{Type: typ, ID: id, Data: make([]int, size)}
+		copy(it.Data, data[:size])
+		data = data[size:]
+
+		if from, ok := baseItems[it.Key()]; ok {
+			if len(from.Data) != size {
+				return nil, fmt.Errorf("%w: item of type %d with id %d changes its size from %d to %d",
+					ErrInvalidDelta, typ, id, len(from.Data), size)
+			}
+			for j, v := range from.Data {
+				it.Data[j] = int(int32(v + it.Data[j]))
+			}
+		}
+
+		if idx, ok := index[it.Key()]; ok {
+			snap.Items[idx] = it
+			continue
+		}
+		if len(snap.Items) == MaxItems {
+			return nil, fmt.Errorf("%w: more than %d items", ErrInvalidDelta, MaxItems)
+		}
+		index[it.Key()] = len(snap.Items)
+		snap.Items = append(snap.Items, it)
+	}
+
+	if actual := snap.Crc(); actual != crc {
+		return nil, fmt.Errorf("%w: expected %d, got %d", ErrSnapshotCRCMismatch, crc, actual)
+	}
+	return snap, nil
+}
+
+// UnpackDelta reconstructs the snapshot of tick from a delta that is based on the stored snapshot of deltaTick,
+// see UnpackDelta, and stores it. An error that wraps ErrMissingBase is returned if the base is not stored,
+// a deltaTick below 0 is based on an empty snapshot.
+func (s *SnapshotStorage) UnpackDelta(tick, deltaTick int, delta []int, crc int) (*Snapshot, error) {
+	var base *Snapshot
+	if deltaTick >= 0 {
+		var ok bool
+		base, ok = s.Get(deltaTick)
+		if !ok {
+			return nil, fmt.Errorf("%w: tick %d", ErrMissingBase, deltaTick)
+		}
+	}
+
+	snap, err := UnpackDelta(base, delta, crc)
+	if err != nil {
+		return nil, err
+	}
+	s.Add(tick, snap)
+	return snap, nil
+}
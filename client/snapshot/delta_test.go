@@ -0,0 +1,143 @@
+package snapshot
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// packDelta creates the delta integers that turn from into to. Items are diffed against the items
+// of from with the same key, the size is only added for item types of an unknown size.
+func packDelta(from, to *Snapshot) []int {
+	var deleted, updated []int
+	numUpdated := 0
+	for _, it := range from.Items {
+		if _, ok := to.Find(it.Type, it.ID); !ok {
+			deleted = append(deleted, it.Key())
+		}
+	}
+	for _, it := range to.Items {
+		data := append([]int(nil), it.Data...)
+		if prev, ok := from.Find(it.Type, it.ID); ok {
+			for i := range data {
+				data[i] -= prev.Data[i]
+			}
+		}
+
+		updated = append(updated, it.Type, it.ID)
+		if _, ok := itemSizes[it.Type]; !ok {
+			updated = append(updated, len(data))
+		}
+		updated = append(updated, data...)
+		numUpdated++
+	}
+
+	delta := []int{len(deleted), numUpdated, 0}
+	delta = append(delta, deleted...)
+	return append(delta, updated...)
+}
+
+func TestUnpackDelta(t *testing.T) {
+	base := &Snapshot{Items: []Item{
+		{Type: NetObjTypeGameData, ID: 0, Data: []int{100, 0, 0}},
+		{Type: NetObjTypePlayerInfo, ID: 3, Data: []int{0, 7, 25}},
+		{Type: NetObjTypePlayerInfo, ID: 5, Data: []int{0, -1, 40}},
+	}}
+	want := &Snapshot{Items: []Item{
+		{Type: NetObjTypeGameData, ID: 0, Data: []int{100, 0, 0}},
+		{Type: NetObjTypePlayerInfo, ID: 3, Data: []int{0, 8, 31}},
+		// an item of a mod with an unknown size
+		{Type: 100, ID: 1, Data: []int{1, 2, 3, 4}},
+	}}
+
+	// player 5 left, player 3 scored and the mod item was added
+	got, err := UnpackDelta(base, packDelta(base, want), want.Crc())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnpackDelta() = %v, want %v", got, want)
+	}
+
+	// the first snapshot is based on an empty snapshot
+	got, err = UnpackDelta(nil, packDelta(&Snapshot{}, base), base.Crc())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, base) {
+		t.Errorf("UnpackDelta() without base = %v, want %v", got, base)
+	}
+
+	// NetMsgSnapEmpty does not change the snapshot
+	got, err = UnpackDelta(base, nil, 0)
+	if err != nil || !reflect.DeepEqual(got, base) {
+		t.Errorf("UnpackDelta() of an empty delta = %v, %v, want %v", got, err, base)
+	}
+}
+
+func TestUnpackDeltaCorrupted(t *testing.T) {
+	base := &Snapshot{Items: []Item{{Type: NetObjTypeFlag, ID: 0, Data: []int{800, 600, TeamRed}}}}
+	next := &Snapshot{Items: []Item{{Type: NetObjTypeFlag, ID: 0, Data: []int{832, 600, TeamRed}}}}
+
+	delta := packDelta(base, next)
+	// the x coordinate's difference is corrupted
+	delta[len(delta)-3]++
+
+	_, err := UnpackDelta(base, delta, next.Crc())
+	if !errors.Is(err, ErrSnapshotCRCMismatch) {
+		t.Fatalf("expected %v, got %v", ErrSnapshotCRCMismatch, err)
+	}
+	if want := "snapshot crc mismatch: expected 1432, got 1433"; err.Error() != want {
+		t.Errorf("error = %q, want %q", err, want)
+	}
+}
+
+func TestUnpackDeltaInvalid(t *testing.T) {
+	base := &Snapshot{Items: []Item{{Type: 100, ID: 0, Data: []int{1, 2}}}}
+
+	tests := []struct {
+		name  string
+		delta []int
+	}{
+		{"missing header", []int{0, 1}},
+		{"negative number of items", []int{-1, 0, 0}},
+		{"missing deleted keys", []int{2, 0, 0, 1}},
+		{"truncated item", []int{0, 1, 0, NetObjTypePickup, 0, 1, 2}},
+		{"missing size", []int{0, 1, 0, 100, 0}},
+		{"negative size", []int{0, 1, 0, 100, 1, -1}},
+		{"changed size", []int{0, 1, 0, 100, 0, 1, 1}},
+		{"invalid type", []int{0, 1, 0, 0x10000, 0, 0}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := UnpackDelta(base, tt.delta, 0); !errors.Is(err, ErrInvalidDelta) {
+				t.Errorf("expected %v, got %v", ErrInvalidDelta, err)
+			}
+		})
+	}
+}
+
+func TestSnapshotStorage_UnpackDelta(t *testing.T) {
+	first := &Snapshot{Items: []Item{{Type: NetObjTypePlayerInfo, ID: 0, Data: []int{0, 1, 20}}}}
+	second := &Snapshot{Items: []Item{{Type: NetObjTypePlayerInfo, ID: 0, Data: []int{0, 2, 20}}}}
+
+	s := NewSnapshotStorage(2)
+	if _, err := s.UnpackDelta(10, -1, packDelta(&Snapshot{}, first), first.Crc()); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.UnpackDelta(12, 10, packDelta(first, second), second.Crc())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored, ok := s.Get(12); !ok || stored != got || !reflect.DeepEqual(got, second) {
+		t.Errorf("Get(12) = %v, %t, want %v", stored, ok, second)
+	}
+
+	// the base has been evicted
+	s.Add(13, second)
+	s.Add(14, second)
+	if _, err := s.UnpackDelta(15, 10, packDelta(first, second), second.Crc()); !errors.Is(err, ErrMissingBase) {
+		t.Errorf("expected %v, got %v", ErrMissingBase, err)
+	}
+}
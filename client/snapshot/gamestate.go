@@ -0,0 +1,181 @@
+package snapshot
+
+import (
+	"time"
+)
+
+// TickSpeed is the number of ticks per second of a 0.7 server.
+const TickSpeed = 50
+
+// teams of the 0.7 protocol
+const (
+	TeamSpectators = -1
+	TeamRed        = 0
+	TeamBlue       = 1
+)
+
+// special flag carriers, any other carrier is the client id of the player that carries the flag
+const (
+	FlagMissing = -3
+	FlagAtStand = -2
+	FlagTaken   = -1
+)
+
+// game flags of NetObjTypeDeGameInfo
+const (
+	GameFlagTeams = 1 << iota
+	GameFlagFlags
+	GameFlagSurvival
+	GameFlagRace
+)
+
+// game state flags of NetObjTypeGameData
+const (
+	GameStateFlagWarmup = 1 << iota
+	GameStateFlagSuddenDeath
+	GameStateFlagRoundOver
+	GameStateFlagGameOver
+	GameStateFlagPaused
+	GameStateFlagStartCountdown
+)
+
+// PlayerState is the state of a connected client, its name and team are sent in separate messages.
+type PlayerState struct {
+	ClientID    int
+	PlayerFlags int
+	Score       int
+	Latency     int
+}
+
+// FlagState is the state of a team's flag in capture the flag games.
+type FlagState struct {
+	Team int
+	X, Y int
+
+	// Carrier is the client id of the player carrying the flag, or one of FlagMissing, FlagAtStand and FlagTaken.
+	Carrier int
+
+	// DropTick is the tick at which the flag has been dropped, if it is neither carried nor at its stand.
+	DropTick int
+}
+
+// GameInfo is the state of the current round.
+type GameInfo struct {
+	GameFlags      int
+	GameStateFlags int
+	ScoreLimit     int
+	TimeLimit      int // in minutes, 0 if there is no time limit
+	MatchNum       int
+	MatchCurrent   int
+
+	// TeamScoreRed and TeamScoreBlue are only set in team games.
+	TeamScoreRed  int
+	TeamScoreBlue int
+
+	// RoundTime is the time that has passed since the round started.
+	RoundTime time.Duration
+
+	// TimeLeft is the remaining time of the round if there is a time limit.
+	TimeLeft time.Duration
+}
+
+// GameState is a high level view of a snapshot, e.g. for scoreboards.
+type GameState struct {
+	tick int
+	snap *Snapshot
+}
+
+// NewGameState creates a view of snap, which is the snapshot of tick.
+func NewGameState(tick int, snap *Snapshot) *GameState {
+	return &GameState{
+		tick: tick,
+		snap: snap,
+	}
+}
+
+// Players returns the states of all clients in the snapshot, including spectators.
+func (gs *GameState) Players() []PlayerState {
+	items := gs.snap.ItemsOfType(NetObjTypePlayerInfo)
+	players := make([]PlayerState, 0, len(items))
+	for _, it := range items {
+		if len(it.Data) < 3 {
+			continue
+		}
+		players = append(players, PlayerState{
+			ClientID:    it.ID,
+			PlayerFlags: it.Data[0],
+			Score:       it.Data[1],
+			Latency:     it.Data[2],
+		})
+	}
+	return players
+}
+
+// Flags returns the state of the flags, which only exist in capture the flag games.
+func (gs *GameState) Flags() []FlagState {
+	carriers := [2]int{FlagMissing, FlagMissing}
+	dropTicks := [2]int{}
+	if it, ok := gs.snap.Find(NetObjTypeGameDataFlag, 0); ok && len(it.Data) >= 4 {
+		carriers = [2]int{it.Data[0], it.Data[1]}
+		dropTicks = [2]int{it.Data[2], it.Data[3]}
+	}
+
+	items := gs.snap.ItemsOfType(NetObjTypeFlag)
+	flags := make([]FlagState, 0, len(items))
+	for _, it := range items {
+		if len(it.Data) < 3 {
+			continue
+		}
+
+		flag := FlagState{
+			X:       it.Data[0],
+			Y:       it.Data[1],
+			Team:    it.Data[2],
+			Carrier: FlagMissing,
+		}
+		if flag.Team == TeamRed || flag.Team == TeamBlue {
+			flag.Carrier = carriers[flag.Team]
+			flag.DropTick = dropTicks[flag.Team]
+		}
+		flags = append(flags, flag)
+	}
+	return flags
+}
+
+// GameInfo returns the state of the current round.
+func (gs *GameState) GameInfo() GameInfo {
+	var info GameInfo
+
+	if it, ok := gs.snap.Find(NetObjTypeDeGameInfo, 0); ok && len(it.Data) >= 5 {
+		info.GameFlags = it.Data[0]
+		info.ScoreLimit = it.Data[1]
+		info.TimeLimit = it.Data[2]
+		info.MatchNum = it.Data[3]
+		info.MatchCurrent = it.Data[4]
+	}
+
+	if it, ok := gs.snap.Find(NetObjTypeGameDataTeam, 0); ok && len(it.Data) >= 2 {
+		info.TeamScoreRed = it.Data[0]
+		info.TeamScoreBlue = it.Data[1]
+	}
+
+	if it, ok := gs.snap.Find(NetObjTypeGameData, 0); ok && len(it.Data) >= 3 {
+		startTick := it.Data[0]
+		info.GameStateFlags = it.Data[1]
+
+		if gs.tick > startTick {
+			info.RoundTime = ticksToDuration(gs.tick - startTick)
+		}
+		if info.TimeLimit > 0 {
+			info.TimeLeft = time.Duration(info.TimeLimit)*time.Minute - info.RoundTime
+			if info.TimeLeft < 0 {
+				info.TimeLeft = 0
+			}
+		}
+	}
+	return info
+}
+
+func ticksToDuration(ticks int) time.Duration {
+	return time.Duration(ticks) * time.Second / TickSpeed
+}
@@ -0,0 +1,69 @@
+package snapshot
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGameState(t *testing.T) {
+	const startTick = 1000
+	const tick = startTick + 90*TickSpeed
+
+	// a CTF round on ctf5, the red flag is carried by client 2, the blue flag has been dropped
+	data := packSnapshot(
+		Item{Type: NetObjTypeDeGameInfo, ID: 0, Data: []int{3, 1000, 10, 0, 1}},
+		Item{Type: NetObjTypeGameData, ID: 0, Data: []int{startTick, 0, 0}},
+		Item{Type: NetObjTypeGameDataTeam, ID: 0, Data: []int{200, 100}},
+		Item{Type: NetObjTypeGameDataFlag, ID: 0, Data: []int{2, FlagTaken, 0, tick - 50}},
+		Item{Type: NetObjTypeFlag, ID: TeamRed, Data: []int{512, 640, TeamRed}},
+		Item{Type: NetObjTypeFlag, ID: TeamBlue, Data: []int{1200, 900, TeamBlue}},
+		Item{Type: NetObjTypeCharacter, ID: 2, Data: make([]int, 22)},
+		Item{Type: NetObjTypePlayerInfo, ID: 0, Data: []int{0, 12, 30}},
+		Item{Type: NetObjTypePlayerInfo, ID: 2, Data: []int{8, 25, 45}},
+		Item{Type: NetObjTypePlayerInfo, ID: 7, Data: []int{0, 0, 999}},
+	)
+	snap, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := NewGameState(tick, snap)
+
+	wantPlayers := []PlayerState{
+		{ClientID: 0, Score: 12, Latency: 30},
+		{ClientID: 2, PlayerFlags: 8, Score: 25, Latency: 45},
+		{ClientID: 7, Score: 0, Latency: 999},
+	}
+	if got := gs.Players(); !reflect.DeepEqual(got, wantPlayers) {
+		t.Errorf("Players() = %v, want %v", got, wantPlayers)
+	}
+
+	wantFlags := []FlagState{
+		{Team: TeamRed, X: 512, Y: 640, Carrier: 2},
+		{Team: TeamBlue, X: 1200, Y: 900, Carrier: FlagTaken, DropTick: tick - 50},
+	}
+	if got := gs.Flags(); !reflect.DeepEqual(got, wantFlags) {
+		t.Errorf("Flags() = %v, want %v", got, wantFlags)
+	}
+
+	wantInfo := GameInfo{
+		GameFlags:     3,
+		ScoreLimit:    1000,
+		TimeLimit:     10,
+		MatchCurrent:  1,
+		TeamScoreRed:  200,
+		TeamScoreBlue: 100,
+		RoundTime:     90 * time.Second,
+		TimeLeft:      8*time.Minute + 30*time.Second,
+	}
+	if got := gs.GameInfo(); got != wantInfo {
+		t.Errorf("GameInfo() = %+v, want %+v", got, wantInfo)
+	}
+}
+
+func TestGameStateEmpty(t *testing.T) {
+	gs := NewGameState(0, &Snapshot{})
+	if len(gs.Players()) != 0 || len(gs.Flags()) != 0 || gs.GameInfo() != (GameInfo{}) {
+		t.Error("expected an empty game state")
+	}
+}
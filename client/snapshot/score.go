@@ -0,0 +1,134 @@
+package snapshot
+
+import (
+	"sort"
+	"time"
+)
+
+// object types of the 0.6 protocol that contain the scores, the 0.6 protocol numbers its objects differently.
+const (
+	NetObjTypeGameData06   = 7
+	NetObjTypePlayerInfo06 = 10
+)
+
+// ScoreKind tells how the scores of the players are to be interpreted.
+type ScoreKind int
+
+const (
+	// ScorePoints are scores that are points, e.g. kills. Higher scores are ranked first.
+	ScorePoints ScoreKind = iota
+	// ScoreTime are scores that are finish times, e.g. of race or DDRace servers. Faster times are ranked first.
+	ScoreTime
+)
+
+const (
+	// noTime06 is the score of a DDRace player that has not finished yet.
+	noTime06 = -9999
+	// noTime is the score of a 0.7 race player that has not finished yet.
+	noTime = -1
+)
+
+// PlayerScore is the score of a single player.
+type PlayerScore struct {
+	ClientID int
+
+	// Team is only set by Scoreboard06, 0.7 servers send the teams in separate messages.
+	Team int
+
+	// Score is the score as it has been sent.
+	Score int
+
+	// Time is the finish time of ScoreTime scores, Finished is false if the player did not finish yet.
+	Time     time.Duration
+	Finished bool
+}
+
+// Scoreboard contains the scores of the teams and the players, ordered by their rank.
+type Scoreboard struct {
+	Kind ScoreKind
+
+	// TeamScoreRed and TeamScoreBlue are only set in team games.
+	TeamScoreRed  int
+	TeamScoreBlue int
+
+	Players []PlayerScore
+}
+
+// Scoreboard returns the scores of the 0.7 snapshot. The scores are times if the game flags
+// contain GameFlagRace, which 0.7 race servers send as milliseconds, -1 if the player did not finish yet.
+func (gs *GameState) Scoreboard() Scoreboard {
+	info := gs.GameInfo()
+
+	sb := Scoreboard{
+		Kind:          ScorePoints,
+		TeamScoreRed:  info.TeamScoreRed,
+		TeamScoreBlue: info.TeamScoreBlue,
+	}
+	if info.GameFlags&GameFlagRace != 0 {
+		sb.Kind = ScoreTime
+	}
+
+	for _, p := range gs.Players() {
+		score := PlayerScore{
+			ClientID: p.ClientID,
+			Score:    p.Score,
+		}
+		if sb.Kind == ScoreTime && p.Score != noTime && p.Score >= 0 {
+			score.Time = time.Duration(p.Score) * time.Millisecond
+			score.Finished = true
+		}
+		sb.Players = append(sb.Players, score)
+	}
+	sb.sort()
+	return sb
+}
+
+// Scoreboard06 returns the scores of a 0.6 snapshot. Unlike 0.7 snapshots, 0.6 snapshots do not tell
+// whether the scores are times, which is why the kind has to be passed, e.g. ScoreTime if the server info
+// has the timescore flag. DDRace servers send times as negative seconds, -9999 if the player did not finish yet.
+func Scoreboard06(snap *Snapshot, kind ScoreKind) Scoreboard {
+	sb := Scoreboard{Kind: kind}
+	if it, ok := snap.Find(NetObjTypeGameData06, 0); ok && len(it.Data) >= 2 {
+		sb.TeamScoreRed = it.Data[0]
+		sb.TeamScoreBlue = it.Data[1]
+	}
+
+	// local, client id, team, score, latency
+	for _, it := range snap.ItemsOfType(NetObjTypePlayerInfo06) {
+		if len(it.Data) < 5 {
+			continue
+		}
+		score := PlayerScore{
+			ClientID: it.Data[1],
+			Team:     it.Data[2],
+			Score:    it.Data[3],
+		}
+		if kind == ScoreTime && score.Score != noTime06 && score.Score <= 0 {
+			score.Time = time.Duration(-score.Score) * time.Second
+			score.Finished = true
+		}
+		sb.Players = append(sb.Players, score)
+	}
+	sb.sort()
+	return sb
+}
+
+// sort orders the players by their rank, players with equal scores by their client id.
+// Players without a finish time and 0.6 spectators are ranked last.
+func (sb *Scoreboard) sort() {
+	sort.SliceStable(sb.Players, func(i, j int) bool {
+		a, b := sb.Players[i], sb.Players[j]
+		if (a.Team == TeamSpectators) != (b.Team == TeamSpectators) {
+			return b.Team == TeamSpectators
+		}
+		switch {
+		case sb.Kind == ScoreTime && a.Finished != b.Finished:
+			return a.Finished
+		case sb.Kind == ScoreTime && a.Time != b.Time:
+			return a.Time < b.Time
+		case sb.Kind == ScorePoints && a.Score != b.Score:
+			return a.Score > b.Score
+		}
+		return a.ClientID < b.ClientID
+	})
+}
@@ -0,0 +1,125 @@
+package snapshot
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGameStateScoreboard(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []Item
+		want  Scoreboard
+	}{
+		{
+			"ctf",
+			[]Item{
+				{Type: NetObjTypeDeGameInfo, ID: 0, Data: []int{GameFlagTeams | GameFlagFlags, 1000, 10, 0, 1}},
+				{Type: NetObjTypeGameDataTeam, ID: 0, Data: []int{200, 100}},
+				{Type: NetObjTypePlayerInfo, ID: 0, Data: []int{0, 12, 30}},
+				{Type: NetObjTypePlayerInfo, ID: 2, Data: []int{0, 25, 45}},
+				{Type: NetObjTypePlayerInfo, ID: 7, Data: []int{0, 12, 999}},
+			},
+			Scoreboard{
+				Kind:          ScorePoints,
+				TeamScoreRed:  200,
+				TeamScoreBlue: 100,
+				Players: []PlayerScore{
+					{ClientID: 2, Score: 25},
+					{ClientID: 0, Score: 12},
+					{ClientID: 7, Score: 12},
+				},
+			},
+		},
+		{
+			"race",
+			[]Item{
+				{Type: NetObjTypeDeGameInfo, ID: 0, Data: []int{GameFlagRace, 0, 0, 0, 0}},
+				{Type: NetObjTypePlayerInfo, ID: 0, Data: []int{0, -1, 30}},
+				{Type: NetObjTypePlayerInfo, ID: 1, Data: []int{0, 95250, 30}},
+				{Type: NetObjTypePlayerInfo, ID: 3, Data: []int{0, 61020, 30}},
+			},
+			Scoreboard{
+				Kind: ScoreTime,
+				Players: []PlayerScore{
+					{ClientID: 3, Score: 61020, Time: 61020 * time.Millisecond, Finished: true},
+					{ClientID: 1, Score: 95250, Time: 95250 * time.Millisecond, Finished: true},
+					{ClientID: 0, Score: -1},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			snap, err := Parse(packSnapshot(tt.items...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := NewGameState(0, snap).Scoreboard(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Scoreboard() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreboard06(t *testing.T) {
+	tests := []struct {
+		name  string
+		kind  ScoreKind
+		items []Item
+		want  Scoreboard
+	}{
+		{
+			"vanilla ctf",
+			ScorePoints,
+			[]Item{
+				{Type: NetObjTypeGameData06, ID: 0, Data: []int{3, 5, -2, 4}},
+				// local, client id, team, score, latency
+				{Type: NetObjTypePlayerInfo06, ID: 0, Data: []int{1, 0, TeamRed, 8, 20}},
+				{Type: NetObjTypePlayerInfo06, ID: 4, Data: []int{0, 4, TeamBlue, 11, 35}},
+				{Type: NetObjTypePlayerInfo06, ID: 5, Data: []int{0, 5, TeamSpectators, 30, 60}},
+			},
+			Scoreboard{
+				Kind:          ScorePoints,
+				TeamScoreRed:  3,
+				TeamScoreBlue: 5,
+				Players: []PlayerScore{
+					{ClientID: 4, Team: TeamBlue, Score: 11},
+					{ClientID: 0, Team: TeamRed, Score: 8},
+					{ClientID: 5, Team: TeamSpectators, Score: 30},
+				},
+			},
+		},
+		{
+			"ddrace",
+			ScoreTime,
+			[]Item{
+				{Type: NetObjTypePlayerInfo06, ID: 0, Data: []int{1, 0, TeamRed, -9999, 20}},
+				{Type: NetObjTypePlayerInfo06, ID: 1, Data: []int{0, 1, TeamRed, -754, 35}},
+				{Type: NetObjTypePlayerInfo06, ID: 2, Data: []int{0, 2, TeamRed, -312, 60}},
+			},
+			Scoreboard{
+				Kind: ScoreTime,
+				Players: []PlayerScore{
+					{ClientID: 2, Team: TeamRed, Score: -312, Time: 312 * time.Second, Finished: true},
+					{ClientID: 1, Team: TeamRed, Score: -754, Time: 754 * time.Second, Finished: true},
+					{ClientID: 0, Team: TeamRed, Score: -9999},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			snap, err := Parse(packSnapshot(tt.items...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := Scoreboard06(snap, tt.kind); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Scoreboard06() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
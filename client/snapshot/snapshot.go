@@ -0,0 +1,122 @@
+// Package snapshot provides access to the items of 0.7 snapshots,
+// which contain the state of the game world that the server sends every few ticks.
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+)
+
+// object types of the 0.7 protocol
+const (
+	NetObjTypeInvalid = iota
+	NetObjTypePlayerInput
+	NetObjTypeProjectile
+	NetObjTypeLaser
+	NetObjTypePickup
+	NetObjTypeFlag
+	NetObjTypeGameData
+	NetObjTypeGameDataTeam
+	NetObjTypeGameDataFlag
+	NetObjTypeCharacterCore
+	NetObjTypeCharacter
+	NetObjTypePlayerInfo
+	NetObjTypeSpectatorInfo
+	NetObjTypeDeClientInfo
+	NetObjTypeDeGameInfo
+	NetObjTypeDeTuneParams
+)
+
+const (
+	// MaxItems is the maximum number of items in a single snapshot.
+	MaxItems = 1024
+
+	// MaxSize is the maximum size of a snapshot's data in bytes.
+	MaxSize = 64 * 1024
+)
+
+var (
+	// ErrInvalidSnapshot is returned if the snapshot data is inconsistent, e.g. its items exceed its data.
+	ErrInvalidSnapshot = errors.New("invalid snapshot")
+)
+
+// Item is a single object of the game world, e.g. a character or a flag.
+// The ID distinguishes multiple items of the same type, e.g. it is the client id of player items.
+type Item struct {
+	Type int
+	ID   int
+	Data []int
+}
+
+// Key returns the key that uniquely identifies the item within a snapshot.
+func (it Item) Key() int {
+	return it.Type<<16 | it.ID
+}
+
+// Snapshot is the state of the game world at a single tick.
+type Snapshot struct {
+	Items []Item
+}
+
+// Parse parses a snapshot from its 32 bit words, which consist of the size of the item data in bytes,
+// the number of items, the byte offsets of the items and the items, which are their key followed by their data.
+func Parse(data []int) (*Snapshot, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("%w: missing header", ErrInvalidSnapshot)
+	}
+
+	size, numItems := data[0], data[1]
+	if numItems < 0 || numItems > MaxItems || size < 0 || size > MaxSize || size%4 != 0 {
+		return nil, fmt.Errorf("%w: %d items with %d bytes", ErrInvalidSnapshot, numItems, size)
+	}
+
+	offsets := data[2:]
+	if len(offsets) < numItems {
+		return nil, fmt.Errorf("%w: missing item offsets", ErrInvalidSnapshot)
+	}
+	items := offsets[numItems:]
+	offsets = offsets[:numItems]
+	if len(items) != size/4 {
+		return nil, fmt.Errorf("%w: %d bytes of item data, want %d", ErrInvalidSnapshot, 4*len(items), size)
+	}
+
+	snap := &Snapshot{Items: make([]Item, 0, numItems)}
+	for idx, offset := range offsets {
+		end := size
+		if idx+1 < numItems {
+			end = offsets[idx+1]
+		}
+		if offset < 0 || offset%4 != 0 || end%4 != 0 || end < offset+4 || end > size {
+			return nil, fmt.Errorf("%w: item %d from byte %d to %d", ErrInvalidSnapshot, idx, offset, end)
+		}
+
+		key := items[offset/4]
+		snap.Items = append(snap.Items, Item{
+			Type: key >> 16 & 0xffff,
+			ID:   key & 0xffff,
+			Data: items[offset/4+1 : end/4],
+		})
+	}
+	return snap, nil
+}
+
+// Find returns the item with the passed type and id.
+func (s *Snapshot) Find(typ, id int) (Item, bool) {
+	for _, it := range s.Items {
+		if it.Type == typ && it.ID == id {
+			return it, true
+		}
+	}
+	return Item{}, false
+}
+
+// ItemsOfType returns all items of the passed type in the order of the snapshot.
+func (s *Snapshot) ItemsOfType(typ int) []Item {
+	items := make([]Item, 0, 1)
+	for _, it := range s.Items {
+		if it.Type == typ {
+			items = append(items, it)
+		}
+	}
+	return items
+}
@@ -0,0 +1,74 @@
+package snapshot
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// packSnapshot creates the 32 bit words of a snapshot that contains the passed items.
+func packSnapshot(items ...Item) []int {
+	offsets := make([]int, 0, len(items))
+	data := make([]int, 0, 4*len(items))
+	for _, it := range items {
+		offsets = append(offsets, 4*len(data))
+		data = append(data, it.Key())
+		data = append(data, it.Data...)
+	}
+
+	words := []int{4 * len(data), len(items)}
+	words = append(words, offsets...)
+	return append(words, data...)
+}
+
+func TestParse(t *testing.T) {
+	items := []Item{
+		{Type: NetObjTypeGameData, ID: 0, Data: []int{100, 0, 0}},
+		{Type: NetObjTypePlayerInfo, ID: 3, Data: []int{0, 7, 25}},
+		{Type: NetObjTypePlayerInfo, ID: 5, Data: []int{0, -1, 40}},
+		{Type: NetObjTypeSpectatorInfo, ID: 0, Data: []int{}},
+	}
+
+	snap, err := Parse(packSnapshot(items...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(snap.Items, items) {
+		t.Errorf("Parse() = %v, want %v", snap.Items, items)
+	}
+
+	if it, ok := snap.Find(NetObjTypePlayerInfo, 5); !ok || it.Data[2] != 40 {
+		t.Errorf("Find() = %v, %t", it, ok)
+	}
+	if _, ok := snap.Find(NetObjTypePlayerInfo, 4); ok {
+		t.Error("Find() found a missing item")
+	}
+	if got := snap.ItemsOfType(NetObjTypePlayerInfo); len(got) != 2 {
+		t.Errorf("ItemsOfType() = %v, want 2 items", got)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	valid := packSnapshot(Item{Type: NetObjTypeFlag, ID: 0, Data: []int{1, 2, 0}})
+
+	tests := []struct {
+		name string
+		data []int
+	}{
+		{"empty", nil},
+		{"negative number of items", []int{0, -1}},
+		{"too many items", []int{0, MaxItems + 1}},
+		{"missing offsets", []int{16, 1}},
+		{"size mismatch", append([]int{20}, valid[1:]...)},
+		{"truncated", valid[:len(valid)-1]},
+		{"offset out of range", []int{16, 1, 16, 5 << 16, 1, 2, 0}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.data); !errors.Is(err, ErrInvalidSnapshot) {
+				t.Errorf("expected %v, got %v", ErrInvalidSnapshot, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package snapshot
+
+// DefaultStorageSize is the number of snapshots a SnapshotStorage keeps by default,
+// which are the snapshots of the last second at the server's 50 ticks per second.
+const DefaultStorageSize = 50
+
+// SnapshotStorage keeps the snapshots of the most recent ticks, which the deltas of following
+// snapshots are based on. It is a ring buffer of a fixed size: once it is full, adding a snapshot
+// evicts the snapshot that has been added first.
+// A SnapshotStorage is not safe for concurrent use.
+type SnapshotStorage struct {
+	ticks []int
+	snaps []*Snapshot
+	first int // index of the oldest snapshot
+	len   int
+}
+
+// NewSnapshotStorage creates a storage that keeps the snapshots of up to size ticks.
+// Sizes below 1 are replaced by DefaultStorageSize.
+func NewSnapshotStorage(size int) *SnapshotStorage {
+	if size < 1 {
+		size = DefaultStorageSize
+	}
+	return &SnapshotStorage{
+		ticks: make([]int, size),
+		snaps: make([]*Snapshot, size),
+	}
+}
+
+// Add stores the snapshot of tick. A previously stored snapshot of the same tick is replaced,
+// otherwise the oldest snapshot is evicted if the storage is full.
+func (s *SnapshotStorage) Add(tick int, snap *Snapshot) {
+	if idx, ok := s.index(tick); ok {
+		s.snaps[idx] = snap
+		return
+	}
+
+	idx := (s.first + s.len) % len(s.snaps)
+	if s.len == len(s.snaps) {
+		// overwrite the oldest snapshot
+		s.first = (s.first + 1) % len(s.snaps)
+	} else {
+		s.len++
+	}
+	s.ticks[idx] = tick
+	s.snaps[idx] = snap
+}
+
+// Get returns the snapshot of tick. ok is false if it has not been added or has already been evicted.
+func (s *SnapshotStorage) Get(tick int) (snap *Snapshot, ok bool) {
+	idx, ok := s.index(tick)
+	if !ok {
+		return nil, false
+	}
+	return s.snaps[idx], true
+}
+
+// PurgeUntil removes the snapshots of all ticks before tick,
+// e.g. once the server acknowledged that it bases its deltas on tick.
+func (s *SnapshotStorage) PurgeUntil(tick int) {
+	kept := 0
+	for i := 0; i < s.len; i++ {
+		idx := (s.first + i) % len(s.snaps)
+		if s.ticks[idx] < tick {
+			s.snaps[idx] = nil
+			continue
+		}
+
+		to := (s.first + kept) % len(s.snaps)
+		s.ticks[to], s.snaps[to] = s.ticks[idx], s.snaps[idx]
+		if to != idx {
+			s.snaps[idx] = nil
+		}
+		kept++
+	}
+	s.len = kept
+}
+
+// Len returns the number of stored snapshots.
+func (s *SnapshotStorage) Len() int {
+	return s.len
+}
+
+// index returns the index of the snapshot of tick.
+func (s *SnapshotStorage) index(tick int) (int, bool) {
+	for i := 0; i < s.len; i++ {
+		idx := (s.first + i) % len(s.snaps)
+		if s.ticks[idx] == tick {
+			return idx, true
+		}
+	}
+	return 0, false
+}
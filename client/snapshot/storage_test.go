@@ -0,0 +1,85 @@
+package snapshot
+
+import "testing"
+
+func TestSnapshotStorage(t *testing.T) {
+	s := NewSnapshotStorage(3)
+	snaps := make([]*Snapshot, 5)
+	for tick := range snaps {
+		snaps[tick] = &Snapshot{Items: []Item{{Type: NetObjTypeGameData, Data: []int{tick}}}}
+	}
+
+	for tick, snap := range snaps[:3] {
+		s.Add(tick, snap)
+	}
+	if s.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", s.Len())
+	}
+
+	// the ring buffer wraps around and evicts the oldest ticks
+	s.Add(3, snaps[3])
+	s.Add(4, snaps[4])
+	if s.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", s.Len())
+	}
+	for tick, snap := range snaps {
+		got, ok := s.Get(tick)
+		if wantOK := tick >= 2; ok != wantOK || (ok && got != snap) {
+			t.Errorf("Get(%d) = %v, %t, want %v, %t", tick, got, ok, snap, wantOK)
+		}
+	}
+
+	// replacing a tick does not evict another one
+	replaced := &Snapshot{}
+	s.Add(3, replaced)
+	if got, _ := s.Get(3); got != replaced {
+		t.Errorf("Get(3) = %v, want the replaced snapshot", got)
+	}
+	if _, ok := s.Get(2); !ok {
+		t.Error("Get(2) is missing after replacing tick 3")
+	}
+}
+
+func TestSnapshotStorage_MissingBase(t *testing.T) {
+	s := NewSnapshotStorage(0)
+	if got, ok := s.Get(0); ok || got != nil {
+		t.Errorf("Get() of an empty storage = %v, %t", got, ok)
+	}
+
+	for tick := 0; tick < DefaultStorageSize+1; tick++ {
+		s.Add(tick, &Snapshot{})
+	}
+	if _, ok := s.Get(0); ok {
+		t.Error("Get() returned an evicted snapshot")
+	}
+	if _, ok := s.Get(DefaultStorageSize + 1); ok {
+		t.Error("Get() returned a snapshot of a future tick")
+	}
+}
+
+func TestSnapshotStorage_PurgeUntil(t *testing.T) {
+	s := NewSnapshotStorage(4)
+	for tick := 10; tick < 16; tick++ {
+		s.Add(tick, &Snapshot{})
+	}
+
+	s.PurgeUntil(14)
+	if s.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", s.Len())
+	}
+	for tick := 10; tick < 16; tick++ {
+		if _, ok := s.Get(tick); ok != (tick >= 14) {
+			t.Errorf("Get(%d) = %t after purging until 14", tick, ok)
+		}
+	}
+
+	// the purged slots are reused
+	for tick := 16; tick < 18; tick++ {
+		s.Add(tick, &Snapshot{})
+	}
+	for tick := 14; tick < 18; tick++ {
+		if _, ok := s.Get(tick); !ok {
+			t.Errorf("Get(%d) is missing", tick)
+		}
+	}
+}
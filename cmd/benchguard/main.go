@@ -0,0 +1,57 @@
+// Command benchguard reads the output of "go test -bench" from stdin and fails if
+// a benchmark became slower than its stored baseline allows.
+//
+//	go test -run '^$' -bench . -count 5 ./compression | go run ./cmd/benchguard -baseline compression/testdata/benchmarks.txt
+//
+// Repeated results of a benchmark are reduced to their median, which is why -count should be greater than 1
+// in order to not fail because of a single disturbed run. A new baseline is created by saving the benchmark
+// output to the baseline file. The numbers are only comparable if the baseline has been measured on the same
+// machine with the same toolchain, which is why CI measures the baseline of the base commit in the same job.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jxsl13/twapi/internal/benchguard"
+)
+
+func main() {
+	baselinePath := flag.String("baseline", "", "file that contains the baseline output of go test -bench")
+	threshold := flag.Float64("threshold", 0.25, "allowed relative slowdown, e.g. 0.25 for 25%")
+	flag.Parse()
+
+	if err := run(*baselinePath, *threshold); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(baselinePath string, threshold float64) error {
+	f, err := os.Open(baselinePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	baseline, err := benchguard.Parse(f)
+	if err != nil {
+		return fmt.Errorf("baseline: %w", err)
+	}
+
+	current, err := benchguard.Parse(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("stdin: %w", err)
+	}
+
+	regressions := benchguard.Compare(baseline, current, threshold)
+	for _, r := range regressions {
+		fmt.Println(r)
+	}
+	if len(regressions) > 0 {
+		return fmt.Errorf("%d of %d benchmarks regressed by more than %.0f%%", len(regressions), len(baseline), threshold*100)
+	}
+	fmt.Printf("%d benchmarks within %.0f%% of their baseline\n", len(current), threshold*100)
+	return nil
+}
@@ -14,6 +14,18 @@ var (
 
 	// ErrNotEnoughDataToUnpack is used when the user tries to retrieve more data with NextBytes() than there is available.
 	ErrNotEnoughDataToUnpack = errors.New("you are trying to read more data than is available")
+
+	// ErrInvalidCount is returned if a packed number of elements is negative or exceeds the available data.
+	ErrInvalidCount = errors.New("invalid number of elements")
+
+	// ErrInvalidEnumValue is returned if an unpacked integer is not one of the expected values.
+	ErrInvalidEnumValue = errors.New("invalid enum value")
+
+	// ErrInvalidUTF8 is returned in strict mode if an unpacked string is not valid UTF-8.
+	ErrInvalidUTF8 = errors.New("invalid utf-8 string")
+
+	// ErrMapDataSizeMismatch is returned if a decompressed map data section does not have the expected size.
+	ErrMapDataSizeMismatch = errors.New("map data size mismatch")
 )
 
 const (
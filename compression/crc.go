@@ -0,0 +1,11 @@
+package compression
+
+import "hash/crc32"
+
+// CRC calculates the checksum that Teeworlds uses to identify maps and to verify demos.
+// The game uses zlib's crc32, which is the CRC-32 with the IEEE polynomial,
+// computed over the data in the order it is stored in the file.
+// Every checksum calculation in this module should use this function.
+func CRC(data []byte) uint32 {
+	return crc32.ChecksumIEEE(data)
+}
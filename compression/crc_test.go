@@ -0,0 +1,25 @@
+package compression
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCRC(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want uint32
+	}{
+		{"empty", []byte{}, 0},
+		{"check value", []byte("123456789"), 0xcbf43926},
+		{"map data", bytes.Repeat([]byte("teeworlds map data "), 4), 0x2f70023f},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CRC(tt.data); got != tt.want {
+				t.Errorf("CRC() = %#x, want %#x", got, tt.want)
+			}
+		})
+	}
+}
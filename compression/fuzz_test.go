@@ -0,0 +1,56 @@
+//go:build go1.18
+// +build go1.18
+
+package compression
+
+import (
+	"testing"
+)
+
+func FuzzUnpackVarInt(f *testing.F) {
+	var p Packer
+	for _, i := range []int{0, 1, -1, 63, 64, -64, 1 << 20, -(1 << 31), 1<<31 - 1} {
+		p.MustAdd(i)
+	}
+	f.Add(p.Bytes())
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	f.Add([]byte{0x80})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		v := NewVarIntFrom(data)
+		for v.Size() > 0 {
+			before := v.Size()
+			if _, err := v.Unpack(); err != nil {
+				return
+			}
+			if consumed := before - v.Size(); consumed < 1 || consumed > maxBytesInVarInt {
+				t.Fatalf("Unpack() consumed %d bytes", consumed)
+			}
+		}
+	})
+}
+
+func FuzzHuffmanDecompress(f *testing.F) {
+	huffman := NewHuffman()
+
+	message := []byte("\x00\x00\x00\x01 snapshot-like data with many zeros \x00\x00\x00\x00\x00\x00")
+	compressed := make([]byte, 0, 2*len(message))
+	huffman.Compress(message, len(message), &compressed, cap(compressed))
+	f.Add(compressed)
+	f.Add([]byte{})
+	f.Add([]byte{0xff, 0xff, 0xff})
+
+	const maxOutput = 1400
+	f.Fuzz(func(t *testing.T, data []byte) {
+		output := make([]byte, 0, maxOutput)
+		if huffman.Decompress(data, len(data), &output, maxOutput) > 0 && len(output) > maxOutput {
+			t.Fatalf("Decompress() wrote %d bytes into a buffer of %d bytes", len(output), maxOutput)
+		}
+
+		// TryDecompress must either return the data or its decompressed form.
+		out, wasCompressed, err := huffman.TryDecompress(data)
+		if err == nil && !wasCompressed && len(out) != len(data) {
+			t.Fatalf("TryDecompress() modified uncompressed data")
+		}
+	})
+}
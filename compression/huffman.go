@@ -1,6 +1,7 @@
 package compression
 
 import (
+	"bytes"
 	"errors"
 )
 
@@ -44,6 +45,10 @@ func NewHuffman(frequencies ...[]uint) *Huffman {
 	return h
 }
 
+// Huffman is a codec that is constructed once from symbol frequencies.
+// Compress and Decompress only read the constructed tree and keep their state local to the call,
+// which is why a single Huffman can be shared and used by multiple goroutines at the same time.
+// Reset must not be called while the codec is in use.
 type Huffman struct {
 	Nodes     [HuffmanMaxNodes]Node
 	DecodeLut [HuffmanLutsize]*Node
@@ -118,6 +123,8 @@ func (h *Huffman) memZero() {
 	h.NumNodes = 0
 }
 
+// Reset reconstructs the codec from the passed frequencies, nil frequencies construct
+// the codec that the game uses. Reset is not safe for concurrent use.
 func (h *Huffman) Reset(frequencies []uint) {
 	// make sure to cleanout every thing
 	h.memZero()
@@ -155,6 +162,8 @@ func (h *Huffman) Reset(frequencies []uint) {
 
 }
 
+// Compress compresses inputSize bytes of input into output and returns the compressed size or -1
+// if output is too small. It is safe for concurrent use.
 func (h *Huffman) Compress(input []byte, inputSize int, output *[]byte, outputSize int) int {
 	if len(*output) < outputSize && cap(*output) >= outputSize {
 		*output = (*output)[:outputSize]
@@ -242,6 +251,8 @@ func (h *Huffman) Compress(input []byte, inputSize int, output *[]byte, outputSi
 	return pDst
 }
 
+// Decompress decompresses inputSize bytes of input into output and returns the decompressed size plus one
+// for the EOF symbol, or -1 if the data is invalid or output is too small. It is safe for concurrent use.
 func (h *Huffman) Decompress(input []byte, inputSize int, output *[]byte, outputSize int) int {
 	if len(*output) < outputSize && cap(*output) >= outputSize {
 		*output = (*output)[:outputSize]
@@ -338,3 +349,29 @@ func (h *Huffman) Decompress(input []byte, inputSize int, output *[]byte, output
 	// return the size of the decompressed buffer
 	return pDst + 1
 }
+
+// TryDecompress is a heuristic for data of which it is not known whether it is compressed,
+// e.g. chunks of corrupt or mixed recordings. It decompresses the data and compresses the result again.
+// Only if that reproduces the data exactly, the data is considered to be compressed and the decompressed data
+// is returned. Otherwise the data is returned as is. Raw data that by chance is a valid compressed stream is
+// decompressed nonetheless, so this must not be used if it is known whether the data is compressed.
+// ErrNoDataToUnpack is returned for empty data.
+func (h *Huffman) TryDecompress(data []byte) (out []byte, wasCompressed bool, err error) {
+	if len(data) == 0 {
+		return data, false, ErrNoDataToUnpack
+	}
+
+	// every symbol is encoded with at least one bit
+	decompressed := make([]byte, 0, len(data)*8)
+	if h.Decompress(data, len(data), &decompressed, cap(decompressed)) < 0 {
+		return data, false, nil
+	}
+
+	// the compressed data is at most as large as data, but one more byte is needed in order to detect larger results
+	recompressed := make([]byte, 0, len(data)+1)
+	size := h.Compress(decompressed, len(decompressed), &recompressed, cap(recompressed))
+	if size < 0 || !bytes.Equal(recompressed, data) {
+		return data, false, nil
+	}
+	return decompressed, true, nil
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"math/rand"
+	"sync"
 	"testing"
 )
 
@@ -145,3 +146,133 @@ func TestHuffman_Compress_Decompress(t *testing.T) {
 		})
 	}
 }
+
+func TestHuffman_Concurrent(t *testing.T) {
+	huffman := NewHuffman()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+
+			rnd := rand.New(rand.NewSource(seed))
+			for i := 0; i < 200; i++ {
+				input := make([]byte, rnd.Intn(1400)+1)
+				rnd.Read(input)
+
+				// rare symbols have long codes, random data might grow considerably
+				compressed := make([]byte, 0, len(input)*4)
+				if l := huffman.Compress(input, len(input), &compressed, cap(compressed)); l < 0 {
+					t.Error("Compress failed")
+					return
+				}
+
+				decompressed := make([]byte, 0, len(input)+1)
+				if l := huffman.Decompress(compressed, len(compressed), &decompressed, cap(decompressed)); l < 0 {
+					t.Error("Decompress failed")
+					return
+				}
+
+				if !bytes.Equal(input, decompressed) {
+					t.Errorf("Input:\n%v\nDecompressed:\n%v\n", input, decompressed)
+					return
+				}
+			}
+		}(int64(g))
+	}
+	wg.Wait()
+}
+
+func TestHuffman_TryDecompress(t *testing.T) {
+	huffman := NewHuffman()
+
+	message := []byte("Teeworlds is a free online multiplayer game, available for all major operating systems.")
+	compressed := make([]byte, 0, len(message)*2)
+	if huffman.Compress(message, len(message), &compressed, cap(compressed)) < 0 {
+		t.Fatal("Compress failed")
+	}
+
+	corrupt := append([]byte{}, compressed...)
+	// a flipped bit in the middle merely changes the decompressed symbols, but a damaged EOF symbol is detected
+	corrupt[len(corrupt)-1] ^= 0xff
+
+	tests := []struct {
+		name              string
+		data              []byte
+		want              []byte
+		wantWasCompressed bool
+	}{
+		{"compressed", compressed, message, true},
+		{"raw", message, message, false},
+		{"corrupt", corrupt, corrupt, false},
+		{"truncated", compressed[:len(compressed)/2], compressed[:len(compressed)/2], false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, wasCompressed, err := huffman.TryDecompress(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if wasCompressed != tt.wantWasCompressed {
+				t.Errorf("Huffman.TryDecompress() wasCompressed = %v, want %v", wasCompressed, tt.wantWasCompressed)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("Huffman.TryDecompress() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, _, err := huffman.TryDecompress(nil); err != ErrNoDataToUnpack {
+		t.Errorf("expected %v, got %v", ErrNoDataToUnpack, err)
+	}
+}
+
+// benchmarkSize is the size of the data that the codec benchmarks process per operation.
+const benchmarkSize = 1 << 20
+
+// newBenchmarkData returns benchmarkSize bytes that resemble network payloads,
+// small values and zeros are far more common than large values.
+func newBenchmarkData() []byte {
+	rnd := rand.New(rand.NewSource(1))
+	data := make([]byte, benchmarkSize)
+	for idx := range data {
+		data[idx] = byte(rnd.ExpFloat64() * 8)
+	}
+	return data
+}
+
+func BenchmarkHuffman_Compress(b *testing.B) {
+	huffman := NewHuffman()
+	data := newBenchmarkData()
+	compressed := make([]byte, 0, 2*len(data))
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if huffman.Compress(data, len(data), &compressed, cap(compressed)) < 0 {
+			b.Fatal("Compress failed")
+		}
+	}
+}
+
+func BenchmarkHuffman_Decompress(b *testing.B) {
+	huffman := NewHuffman()
+	data := newBenchmarkData()
+	compressed := make([]byte, 0, 2*len(data))
+	if huffman.Compress(data, len(data), &compressed, cap(compressed)) < 0 {
+		b.Fatal("Compress failed")
+	}
+	decompressed := make([]byte, 0, len(data))
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if huffman.Decompress(compressed, len(compressed), &decompressed, cap(decompressed)) != len(data)+1 {
+			b.Fatal("Decompress failed")
+		}
+	}
+}
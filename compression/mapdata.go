@@ -0,0 +1,40 @@
+package compression
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// DecompressMapData decompresses a zlib compressed data section of a map file.
+// The map file stores the uncompressed size of every data section, which is passed as
+// uncompressedSize. If the decompressed data does not have exactly that size,
+// an ErrMapDataSizeMismatch is returned.
+func DecompressMapData(compressed []byte, uncompressedSize int) ([]byte, error) {
+	if uncompressedSize < 0 {
+		return nil, fmt.Errorf("%w: invalid expected size %d", ErrMapDataSizeMismatch, uncompressedSize)
+	}
+
+	r, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	// read at most a single byte more than expected in order to detect
+	// oversized data without decompressing all of it.
+	data, err := ioutil.ReadAll(io.LimitReader(r, int64(uncompressedSize)+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) != uncompressedSize {
+		if len(data) > uncompressedSize {
+			return nil, fmt.Errorf("%w: expected %d bytes, got more", ErrMapDataSizeMismatch, uncompressedSize)
+		}
+		return nil, fmt.Errorf("%w: expected %d bytes, got %d", ErrMapDataSizeMismatch, uncompressedSize, len(data))
+	}
+	return data, nil
+}
@@ -0,0 +1,51 @@
+package compression
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// zlib compressed "teeworlds map data " repeated 4 times (76 bytes)
+var mapDataFixture = []byte{
+	0x78, 0x9c, 0x2b, 0x49, 0x4d, 0x2d, 0xcf, 0x2f, 0xca, 0x49, 0x29, 0x56, 0xc8, 0x4d, 0x2c, 0x50,
+	0x48, 0x49, 0x2c, 0x49, 0x54, 0x28, 0x21, 0x57, 0x08, 0x00, 0x53, 0x7a, 0x1c, 0x45,
+}
+
+func TestDecompressMapData(t *testing.T) {
+	want := bytes.Repeat([]byte("teeworlds map data "), 4)
+
+	type args struct {
+		compressed       []byte
+		uncompressedSize int
+	}
+	tests := []struct {
+		name         string
+		args         args
+		want         []byte
+		wantMismatch bool
+		wantErr      bool
+	}{
+		{"valid", args{mapDataFixture, len(want)}, want, false, false},
+		{"expected size too big", args{mapDataFixture, len(want) + 1}, nil, true, true},
+		{"expected size too small", args{mapDataFixture, len(want) - 1}, nil, true, true},
+		{"negative size", args{mapDataFixture, -1}, nil, true, true},
+		{"not zlib", args{[]byte{1, 2, 3, 4}, 4}, nil, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecompressMapData(tt.args.compressed, tt.args.uncompressedSize)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DecompressMapData() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if errors.Is(err, ErrMapDataSizeMismatch) != tt.wantMismatch {
+				t.Errorf("DecompressMapData() error = %v, want size mismatch %v", err, tt.wantMismatch)
+				return
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("DecompressMapData() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,5 +1,14 @@
 package compression
 
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
 // Packer compresses data
 type Packer struct {
 	Buffer []byte
@@ -31,7 +40,10 @@ func (p *Packer) Size() int {
 }
 
 // Add integer, bytes or string
-func (p *Packer) Add(data interface{}) {
+// Values that implement encoding.BinaryMarshaler are packed as their length followed
+// by their marshaled bytes and can be unpacked with Unpacker.NextBinary.
+// Returns ErrTypeNotSupported for any other type.
+func (p *Packer) Add(data interface{}) error {
 	p.init()
 
 	switch t := data.(type) {
@@ -47,19 +59,164 @@ func (p *Packer) Add(data interface{}) {
 	case []byte:
 		p.Buffer = append(p.Buffer, t...)
 
+	case encoding.BinaryMarshaler:
+		b, err := t.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		p.Add(len(b))
+		p.Buffer = append(p.Buffer, b...)
+
 	default:
-		panic(ErrTypeNotSupported)
+		return ErrTypeNotSupported
+	}
+	return nil
+}
+
+// MustAdd is like Add, but panics if the type is not supported.
+// It should only be used for values whose types are known at compile time,
+// where an error can only be caused by a programming mistake, e.g. in message constructors.
+func (p *Packer) MustAdd(data interface{}) {
+	if err := p.Add(data); err != nil {
+		panic(err)
+	}
+}
+
+// Describe re-decodes the packed data as a sequence of integers and strings and
+// returns a human readable dump like: int(1) string("hello") int(-3)
+// The packed data does not contain any type information, which is why this is only a guess:
+// a zero terminated sequence of at least two printable characters is considered to be a string,
+// everything else an integer. Data that cannot be decoded at all is dumped as hex bytes.
+// This is meant for debugging only.
+func (p *Packer) Describe() string {
+	fields := make([]string, 0, 8)
+	data := p.Buffer
+
+	for len(data) > 0 {
+		if s, ok := printableString(data); ok {
+			fields = append(fields, fmt.Sprintf("string(%q)", s))
+			data = data[len(s)+1:]
+			continue
+		}
+
+		v := VarInt{data}
+		i, err := v.Unpack()
+		if err != nil {
+			fields = append(fields, fmt.Sprintf("bytes(%x)", data))
+			break
+		}
+		fields = append(fields, fmt.Sprintf("int(%d)", i))
+		data = v.Bytes()
 	}
+
+	return strings.Join(fields, " ")
+}
+
+// printableString returns the zero terminated string at the beginning of data,
+// if it consists of at least two printable characters.
+func printableString(data []byte) (string, bool) {
+	end := -1
+	for idx, b := range data {
+		if b == 0 {
+			end = idx
+			break
+		}
+	}
+	if end < 2 {
+		return "", false
+	}
+
+	s := string(data[:end])
+	if !utf8.ValidString(s) {
+		return "", false
+	}
+	for _, r := range s {
+		if !unicode.IsPrint(r) {
+			return "", false
+		}
+	}
+	return s, true
+}
+
+// StringMode defines how NextStringMode post-processes unpacked strings.
+// Modes can be combined with a bitwise or.
+type StringMode int
+
+const (
+	// StringSanitize replaces all control characters except \r, \n and \t with whitespaces.
+	StringSanitize StringMode = 1 << iota
+
+	// StringSanitizeCC replaces all control characters with whitespaces.
+	// If combined with StringSanitize, StringSanitize takes precedence.
+	StringSanitizeCC
+
+	// StringSkipStartWhitespaces removes leading whitespaces, including unicode whitespaces.
+	StringSkipStartWhitespaces
+
+	// StringStrictUTF8 returns ErrInvalidUTF8 for strings that are not valid UTF-8,
+	// including strings that end with an incomplete multi-byte character.
+	StringStrictUTF8
+)
+
+// AddMessageID packs the message id that every message starts with.
+// The lowest bit of the packed id marks system messages.
+func (p *Packer) AddMessageID(id int, system bool) {
+	sys := 0
+	if system {
+		sys = 1
+	}
+	p.Add(id<<1 | sys)
+}
+
+// AddStringSlice packs the number of strings followed by every string.
+func (p *Packer) AddStringSlice(ss []string) {
+	p.Add(len(ss))
+	for _, s := range ss {
+		p.Add(s)
+	}
+}
+
+// AddUUID appends the 16 bytes of the uuid.
+func (p *Packer) AddUUID(uuid [16]byte) {
+	p.Add(uuid[:])
 }
 
-// Unpacker unpacks received messages
+// AddVector packs a 2D coordinate as two consecutive integers.
+func (p *Packer) AddVector(x, y int) {
+	p.Add(x)
+	p.Add(y)
+}
+
+// AddTick packs tick as its distance to the reference tick, e.g. the delta tick of a snapshot
+// that is relative to the snapshot's tick. A tick after the reference results in a negative distance.
+func (p *Packer) AddTick(tick, reference int) {
+	p.Add(reference - tick)
+}
+
+// Unpacker unpacks received messages.
+// Use NewUnpacker or a keyed literal like Unpacker{Buffer: b}.
 type Unpacker struct {
 	Buffer []byte
+
+	// Accumulate makes the first error sticky: once a Next call fails,
+	// every following Next call returns zero values and that same error.
+	// This allows to unpack many fields in a row and to check Err() once at the end.
+	Accumulate bool
+
+	err error
+}
+
+// NewUnpacker creates an unpacker for b. With accumulate, the first error is sticky, see Unpacker.Accumulate.
+// Unlike a struct literal, the constructor keeps compiling if further fields are added.
+func NewUnpacker(b []byte, accumulate bool) *Unpacker {
+	return &Unpacker{Buffer: b, Accumulate: accumulate}
 }
 
 // Reset resets the underlying byte slice to a new slice
+// and clears the recorded error.
 func (u *Unpacker) Reset(b []byte) {
 	u.Buffer = b
+	u.err = nil
 }
 
 // Size of the underlying buffer
@@ -67,49 +224,325 @@ func (u *Unpacker) Size() int {
 	return len(u.Buffer)
 }
 
-// NextInt unpacks the next integer
+// Err returns the first error that occurred while unpacking.
+func (u *Unpacker) Err() error {
+	return u.err
+}
+
+// failed returns true if a previous error should prevent any further unpacking.
+func (u *Unpacker) failed() bool {
+	return u.Accumulate && u.err != nil
+}
+
+// setErr records the first error that occurrs and returns err
+func (u *Unpacker) setErr(err error) error {
+	if u.err == nil {
+		u.err = err
+	}
+	return err
+}
+
+// NextInt unpacks the next integer.
+// If the integer cannot be unpacked, 0 is returned and the buffer is not advanced,
+// which leaves the remaining bytes to be inspected or unpacked otherwise.
 func (u *Unpacker) NextInt() (i int, err error) {
+	if u.failed() {
+		return 0, u.err
+	}
+
 	v := VarInt{u.Buffer}
 	i, err = v.Unpack()
+	if err != nil {
+		return 0, u.setErr(err)
+	}
 	u.Buffer = v.Bytes()
 	return
 }
 
-// NextString unpacks the next string from the message
-func (u *Unpacker) NextString() (s string, err error) {
-	if len(u.Buffer) == 0 {
-		err = ErrNoDataToUnpack
+// NextIntClamped unpacks the next integer and limits it to the range [min, max].
+// This should be used for counts that are used to allocate memory or to loop over elements.
+func (u *Unpacker) NextIntClamped(min, max int) (i int, err error) {
+	i, err = u.NextInt()
+	if err != nil {
 		return
 	}
 
-	foundSeparator := false
-	separatorPos := 0
-	for idx, b := range u.Buffer {
-		if b == 0 {
-			foundSeparator = true
-			separatorPos = idx
-			break
+	if i < min {
+		i = min
+	} else if i > max {
+		i = max
+	}
+	return
+}
+
+// NextEnum unpacks the next integer and returns an error wrapping ErrInvalidEnumValue
+// if it is not one of the valid values, e.g. an unknown team or weapon.
+func (u *Unpacker) NextEnum(valid ...int) (i int, err error) {
+	i, err = u.NextInt()
+	if err != nil {
+		return
+	}
+
+	for _, v := range valid {
+		if i == v {
+			return i, nil
 		}
 	}
+	return i, u.setErr(fmt.Errorf("%w: %d", ErrInvalidEnumValue, i))
+}
 
-	if !foundSeparator {
-		err = ErrNoStringToUnpack
+// NextTick unpacks a tick that has been packed as distance to the reference tick,
+// like the delta tick of a snapshot that is sent as distance to the snapshot's tick.
+func (u *Unpacker) NextTick(reference int) (tick int, err error) {
+	delta, err := u.NextInt()
+	if err != nil {
+		return 0, err
+	}
+	return reference - delta, nil
+}
+
+// NextMessageID unpacks the message id that every message starts with
+// as well as whether the message is a system message.
+func (u *Unpacker) NextMessageID() (id int, system bool, err error) {
+	msg, err := u.NextInt()
+	if err != nil {
 		return
 	}
+	return msg >> 1, msg&1 != 0, nil
+}
 
-	s = string(u.Buffer[:separatorPos])
+// NextMessageHeader unpacks the message id like NextMessageID does.
+// If the id is MessageIDExtended, the UUID that identifies the message type follows
+// and is unpacked as well. Otherwise uuid is zero.
+func (u *Unpacker) NextMessageHeader() (id int, system bool, uuid [16]byte, err error) {
+	id, system, err = u.NextMessageID()
+	if err != nil || id != MessageIDExtended {
+		return
+	}
+
+	uuid, err = u.NextUUID()
+	if err != nil {
+		return 0, false, uuid, err
+	}
+	return
+}
+
+// NextUUID unpacks the next 16 bytes as UUID.
+func (u *Unpacker) NextUUID() (uuid [16]byte, err error) {
+	b, err := u.NextBytesNoCopy(len(uuid))
+	if err != nil {
+		return
+	}
+	copy(uuid[:], b)
+	return
+}
+
+// NextVector unpacks a 2D coordinate that consists of two consecutive integers.
+func (u *Unpacker) NextVector() (x, y int, err error) {
+	x, err = u.NextInt()
+	if err != nil {
+		return 0, 0, err
+	}
+	y, err = u.NextInt()
+	if err != nil {
+		return 0, 0, err
+	}
+	return
+}
+
+// NextString unpacks the next string from the message.
+// Servers truncate strings to a maximum number of bytes, which may cut a multi-byte
+// character in half. Such an incomplete character at the end of the string is removed.
+func (u *Unpacker) NextString() (s string, err error) {
+	b, err := u.nextStringBytes()
+	if err != nil {
+		return "", err
+	}
+	return string(trimIncompleteRune(b)), nil
+}
+
+// nextStringBytes unpacks the bytes of the next string without the separator.
+func (u *Unpacker) nextStringBytes() (b []byte, err error) {
+	if u.failed() {
+		return nil, u.err
+	}
+
+	if len(u.Buffer) == 0 {
+		return nil, u.setErr(ErrNoDataToUnpack)
+	}
+
+	separatorPos := bytes.IndexByte(u.Buffer, 0)
+	if separatorPos < 0 {
+		return nil, u.setErr(ErrNoStringToUnpack)
+	}
+
+	b = u.Buffer[:separatorPos]
 	u.Buffer = u.Buffer[separatorPos+1:] // skip separator
+	return b, nil
+}
+
+// trimIncompleteRune removes an incomplete UTF-8 encoded character from the end of b.
+func trimIncompleteRune(b []byte) []byte {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				return b[:i]
+			}
+			break
+		}
+	}
+	return b
+}
+
+// NextStringSlice unpacks a number of strings followed by that many strings.
+// As every string takes at least one byte, a number of strings that exceeds the
+// remaining data is rejected with ErrInvalidCount before anything is allocated.
+func (u *Unpacker) NextStringSlice() (ss []string, err error) {
+	n, err := u.NextInt()
+	if err != nil {
+		return nil, err
+	}
+
+	if n < 0 || n > len(u.Buffer) {
+		return nil, u.setErr(ErrInvalidCount)
+	}
+
+	ss = make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		s, err := u.NextString()
+		if err != nil {
+			return nil, err
+		}
+		ss = append(ss, s)
+	}
+	return ss, nil
+}
+
+// NextStringMode unpacks the next string and post-processes it like the
+// reference implementation's CUnpacker::GetString(SanitizeType) does.
+func (u *Unpacker) NextStringMode(mode StringMode) (s string, err error) {
+	if mode&StringStrictUTF8 != 0 {
+		var b []byte
+		b, err = u.nextStringBytes()
+		if err != nil {
+			return
+		}
+		if !utf8.Valid(b) {
+			return "", u.setErr(ErrInvalidUTF8)
+		}
+		s = string(b)
+	} else {
+		s, err = u.NextString()
+		if err != nil {
+			return
+		}
+	}
+
+	if mode&StringSanitize != 0 {
+		s = sanitize(s, false)
+	} else if mode&StringSanitizeCC != 0 {
+		s = sanitize(s, true)
+	}
+
+	if mode&StringSkipStartWhitespaces != 0 {
+		s = skipWhitespaces(s)
+	}
 	return
 }
 
-// NextBytes returns the next size bytes.
+// sanitize replaces control characters with whitespaces.
+// \r, \n and \t are only replaced if controlCharacters is true.
+func sanitize(s string, controlCharacters bool) string {
+	b := []byte(s)
+	for idx, c := range b {
+		if c >= 32 {
+			continue
+		}
+		if !controlCharacters && (c == '\r' || c == '\n' || c == '\t') {
+			continue
+		}
+		b[idx] = ' '
+	}
+	return string(b)
+}
+
+// skipWhitespaces removes all leading unicode whitespaces
+func skipWhitespaces(s string) string {
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		if !isSpace(r) {
+			break
+		}
+		s = s[size:]
+	}
+	return s
+}
+
+// isSpace reports the same characters as whitespaces as the reference implementation's str_utf8_isspace
+func isSpace(r rune) bool {
+	return r <= 0x0020 || r == 0x0085 || r == 0x00A0 || r == 0x034F ||
+		r == 0x115F || r == 0x1160 || r == 0x1680 || r == 0x180E ||
+		(r >= 0x2000 && r <= 0x200F) || (r >= 0x2028 && r <= 0x202F) ||
+		(r >= 0x205F && r <= 0x2064) || (r >= 0x206A && r <= 0x206F) ||
+		r == 0x2800 || r == 0x3000 || r == 0x3164 ||
+		(r >= 0xFE00 && r <= 0xFE0F) || r == 0xFEFF || r == 0xFFA0 ||
+		(r >= 0xFFF0 && r <= 0xFFF8)
+}
+
+// NextBinary unpacks data that was packed by passing an encoding.BinaryMarshaler to Packer.Add,
+// the length prefixed bytes are passed to into's UnmarshalBinary.
+func (u *Unpacker) NextBinary(into encoding.BinaryUnmarshaler) error {
+	size, err := u.NextInt()
+	if err != nil {
+		return err
+	}
+
+	if size < 0 || size > len(u.Buffer) {
+		return u.setErr(ErrInvalidCount)
+	}
+
+	b, err := u.NextBytesNoCopy(size)
+	if err != nil {
+		return err
+	}
+	if err := into.UnmarshalBinary(b); err != nil {
+		return u.setErr(err)
+	}
+	return nil
+}
+
+// NextBytes returns a copy of the next size bytes.
 func (u *Unpacker) NextBytes(size int) (b []byte, err error) {
+	raw, err := u.NextBytesNoCopy(size)
+	if err != nil {
+		return nil, err
+	}
+
+	b = make([]byte, size)
+	copy(b, raw)
+	return
+}
+
+// NextBytesNoCopy returns the next size bytes without copying them.
+// The returned slice aliases the Unpacker's buffer: modifying it modifies the buffer
+// and modifying or reusing the buffer modifies the returned bytes.
+// The caller must neither modify the returned slice nor retain it past the lifetime of the buffer.
+func (u *Unpacker) NextBytesNoCopy(size int) (b []byte, err error) {
+	if u.failed() {
+		return nil, u.err
+	}
+
 	if len(u.Buffer) < size || size < 0 {
-		err = ErrNotEnoughDataToUnpack
+		err = u.setErr(ErrNotEnoughDataToUnpack)
 		return
 	}
 
-	b = u.Buffer[:size]
+	b = u.Buffer[:size:size]
 	u.Buffer = u.Buffer[size:]
 	return
 }
+
+// NextRaw is an alias of NextBytesNoCopy, the returned slice aliases the Unpacker's buffer.
+func (u *Unpacker) NextRaw(size int) (b []byte, err error) {
+	return u.NextBytesNoCopy(size)
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"math/rand"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -15,7 +16,7 @@ func TestPackerAndUnpacker(t *testing.T) {
 	invalidPacker.Add("5")
 	invalidPacker.Add(5)
 
-	invalidUnpacker := Unpacker{invalidPacker.Bytes()}
+	invalidUnpacker := Unpacker{Buffer: invalidPacker.Bytes()}
 
 	five, err := invalidUnpacker.NextString()
 	if five != "5" {
@@ -41,7 +42,7 @@ func TestPackerAndUnpacker(t *testing.T) {
 	p.Add(stringTest)
 	p.Add(bytesTest)
 
-	u := Unpacker{p.Bytes()}
+	u := Unpacker{Buffer: p.Bytes()}
 
 	i, err := u.NextInt()
 	if err != nil {
@@ -102,3 +103,525 @@ func TestPackerAndUnpacker(t *testing.T) {
 	}
 
 }
+
+func TestUnpacker_Accumulate(t *testing.T) {
+	var p Packer
+	p.Add(1)
+	p.Add("name")
+	p.Add(-1234567)
+
+	// cut the buffer in the middle of the last integer
+	data := p.Bytes()
+	data = data[:len(data)-1]
+
+	u := NewUnpacker(data, true)
+
+	i, _ := u.NextInt()
+	s, _ := u.NextString()
+	truncated, _ := u.NextInt()
+	afterError, _ := u.NextInt()
+	str, _ := u.NextString()
+	b, _ := u.NextBytes(1)
+
+	if i != 1 || s != "name" {
+		t.Fatalf("expected 1 and %q, got %d and %q", "name", i, s)
+	}
+
+	if truncated != 0 || afterError != 0 || str != "" || b != nil {
+		t.Fatalf("expected zero values after error, got %d %d %q %v", truncated, afterError, str, b)
+	}
+
+	if !errors.Is(u.Err(), ErrNotEnoughDataToUnpack) {
+		t.Fatalf("expected %v, got %v", ErrNotEnoughDataToUnpack, u.Err())
+	}
+
+	u.Reset(p.Bytes())
+	if u.Err() != nil {
+		t.Fatalf("expected Reset to clear the error, got %v", u.Err())
+	}
+}
+
+func TestUnpacker_NextIntError(t *testing.T) {
+	var p Packer
+	p.Add(-1234567)
+
+	// the last byte of the integer is missing
+	data := p.Bytes()
+	data = data[:len(data)-1]
+
+	u := NewUnpacker(data, false)
+	i, err := u.NextInt()
+	if !errors.Is(err, ErrNotEnoughDataToUnpack) {
+		t.Fatalf("expected %v, got %v", ErrNotEnoughDataToUnpack, err)
+	}
+	if i != 0 {
+		t.Errorf("NextInt() = %d, want 0 on error", i)
+	}
+	if !bytes.Equal(u.Buffer, data) {
+		t.Errorf("NextInt() advanced the buffer to %v, want it to stay at %v", u.Buffer, data)
+	}
+}
+
+func TestUnpacker_NextStringMode(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		mode  StringMode
+		want  string
+	}{
+		{"no mode", " \ta\x01b\n", 0, " \ta\x01b\n"},
+		{"sanitize", " \ta\x01b\r\n", StringSanitize, " \ta b\r\n"},
+		{"sanitize control characters", " \ta\x01b\r\n", StringSanitizeCC, "  a b  "},
+		{"sanitize takes precedence", "a\x01\tb", StringSanitize | StringSanitizeCC, "a \tb"},
+		{"skip whitespaces", " \t 　name ", StringSkipStartWhitespaces, "name "},
+		{"skip only whitespaces", " \t ", StringSkipStartWhitespaces, ""},
+		{"sanitize and skip", "\x01\x02 name\x03", StringSanitizeCC | StringSkipStartWhitespaces, "name "},
+		{"truncated rune", "nam\xc3", 0, "nam"},
+		{"sanitize truncated rune", "a\x01\xe2\x82", StringSanitize, "a "},
+		{"strict", "ümlaut", StringStrictUTF8, "ümlaut"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Packer
+			p.Add(tt.input)
+			p.Add(5)
+
+			u := Unpacker{Buffer: p.Bytes()}
+			got, err := u.NextStringMode(tt.mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Unpacker.NextStringMode() = %q, want %q", got, tt.want)
+			}
+
+			// the following data must still be unpackable
+			if i, err := u.NextInt(); err != nil || i != 5 {
+				t.Errorf("expected 5 after string, got %d, %v", i, err)
+			}
+		})
+	}
+}
+
+func TestUnpacker_NextStringTruncatedRune(t *testing.T) {
+	const name = "gg🙂"
+	for cut := 1; cut < len("🙂"); cut++ {
+		truncated := name[:len(name)-cut]
+
+		var p Packer
+		p.Add(truncated)
+		p.Add(truncated)
+
+		u := Unpacker{Buffer: p.Bytes()}
+		got, err := u.NextString()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != "gg" {
+			t.Errorf("Unpacker.NextString() = %q, want %q", got, "gg")
+		}
+
+		if _, err := u.NextStringMode(StringStrictUTF8); !errors.Is(err, ErrInvalidUTF8) {
+			t.Errorf("expected %v, got %v", ErrInvalidUTF8, err)
+		}
+	}
+
+	// invalid bytes that are not a truncated character are kept
+	var p Packer
+	p.Add("a\xffb")
+	u := Unpacker{Buffer: p.Bytes()}
+	if got, err := u.NextString(); err != nil || got != "a\xffb" {
+		t.Errorf("Unpacker.NextString() = %q, %v, want %q", got, err, "a\xffb")
+	}
+}
+
+func TestPacker_AddMessageID(t *testing.T) {
+	tests := []struct {
+		name   string
+		id     int
+		system bool
+		want   []byte
+	}{
+		{"game message", 1, false, []byte{0x02}},
+		{"system message", 1, true, []byte{0x03}},
+		{"higher id", 20, true, []byte{0x29}},
+		{"two byte id", 32, false, []byte{0x80, 0x01}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Packer
+			p.AddMessageID(tt.id, tt.system)
+			if !bytes.Equal(p.Bytes(), tt.want) {
+				t.Fatalf("Packer.AddMessageID() = %v, want %v", p.Bytes(), tt.want)
+			}
+
+			u := Unpacker{Buffer: p.Bytes()}
+			id, system, err := u.NextMessageID()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id != tt.id || system != tt.system {
+				t.Errorf("Unpacker.NextMessageID() = %d, %v, want %d, %v", id, system, tt.id, tt.system)
+			}
+		})
+	}
+}
+
+func TestPacker_AddVector(t *testing.T) {
+	vectors := [][2]int{{0, 0}, {-1, 1}, {1024, -2048}, {-123456, -654321}}
+
+	var p Packer
+	for _, v := range vectors {
+		p.AddVector(v[0], v[1])
+	}
+
+	u := Unpacker{Buffer: p.Bytes()}
+	for _, v := range vectors {
+		x, y, err := u.NextVector()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x != v[0] || y != v[1] {
+			t.Errorf("Unpacker.NextVector() = (%d, %d), want (%d, %d)", x, y, v[0], v[1])
+		}
+	}
+
+	if _, _, err := u.NextVector(); !errors.Is(err, ErrNoDataToUnpack) {
+		t.Errorf("expected %v, got %v", ErrNoDataToUnpack, err)
+	}
+}
+
+func TestPacker_Describe(t *testing.T) {
+	tests := []struct {
+		name string
+		pack func(p *Packer)
+		want string
+	}{
+		{"empty", func(p *Packer) {}, ""},
+		{"chat message", func(p *Packer) {
+			p.AddMessageID(3, false)
+			p.Add(0)
+			p.Add(2)
+			p.Add("hello world")
+		}, `int(6) int(0) int(2) string("hello world")`},
+		{"large integers", func(p *Packer) {
+			p.Add(123456)
+			p.Add("ab")
+			p.Add(-654321)
+		}, `int(123456) string("ab") int(-654321)`},
+		{"truncated integer", func(p *Packer) {
+			p.Add(1)
+			p.Add([]byte{0x80})
+		}, `int(1) bytes(80)`},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var p Packer
+			tt.pack(&p)
+			if got := p.Describe(); got != tt.want {
+				t.Errorf("Packer.Describe() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPacker_AddStringSlice(t *testing.T) {
+	tests := []struct {
+		name string
+		ss   []string
+	}{
+		{"empty slice", []string{}},
+		{"single string", []string{"kick player"}},
+		{"empty strings", []string{"", "restart", ""}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var p Packer
+			p.AddStringSlice(tt.ss)
+			p.Add(42)
+
+			u := Unpacker{Buffer: p.Bytes()}
+			got, err := u.NextStringSlice()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.ss) {
+				t.Errorf("Unpacker.NextStringSlice() = %q, want %q", got, tt.ss)
+			}
+
+			if i, err := u.NextInt(); err != nil || i != 42 {
+				t.Errorf("Unpacker.NextInt() = %d, %v, want %d, <nil>", i, err, 42)
+			}
+		})
+	}
+
+	var p Packer
+	p.Add(1 << 30)
+	p.Add("a")
+	u := Unpacker{Buffer: p.Bytes()}
+	if _, err := u.NextStringSlice(); !errors.Is(err, ErrInvalidCount) {
+		t.Errorf("expected %v, got %v", ErrInvalidCount, err)
+	}
+
+	p.Reset()
+	p.Add(-1)
+	u.Reset(p.Bytes())
+	if _, err := u.NextStringSlice(); !errors.Is(err, ErrInvalidCount) {
+		t.Errorf("expected %v, got %v", ErrInvalidCount, err)
+	}
+}
+
+func TestPacker_AddTick(t *testing.T) {
+	const reference = 5000
+	ticks := []int{reference, reference - 1, reference - 250, reference + 1, reference + 64, 0, -1}
+
+	var p Packer
+	for _, tick := range ticks {
+		p.AddTick(tick, reference)
+	}
+
+	u := Unpacker{Buffer: p.Bytes()}
+	for _, want := range ticks {
+		got, err := u.NextTick(reference)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Unpacker.NextTick() = %d, want %d", got, want)
+		}
+	}
+
+	if _, err := u.NextTick(reference); !errors.Is(err, ErrNoDataToUnpack) {
+		t.Errorf("expected %v, got %v", ErrNoDataToUnpack, err)
+	}
+}
+
+func TestUnpacker_NextIntClamped(t *testing.T) {
+	var p Packer
+	p.Add(-5)
+	p.Add(10)
+	p.Add(1 << 30)
+
+	u := Unpacker{Buffer: p.Bytes()}
+	for _, want := range []int{0, 10, 64} {
+		got, err := u.NextIntClamped(0, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Unpacker.NextIntClamped() = %d, want %d", got, want)
+		}
+	}
+
+	if _, err := u.NextIntClamped(0, 64); !errors.Is(err, ErrNoDataToUnpack) {
+		t.Errorf("expected %v, got %v", ErrNoDataToUnpack, err)
+	}
+}
+
+func TestUnpacker_NextEnum(t *testing.T) {
+	var p Packer
+	p.Add(-1)
+	p.Add(1)
+	p.Add(2)
+
+	// spectators, red and blue team
+	u := Unpacker{Buffer: p.Bytes()}
+	for _, want := range []int{-1, 1} {
+		got, err := u.NextEnum(-1, 0, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Unpacker.NextEnum() = %d, want %d", got, want)
+		}
+	}
+
+	if _, err := u.NextEnum(-1, 0, 1); !errors.Is(err, ErrInvalidEnumValue) {
+		t.Errorf("expected %v, got %v", ErrInvalidEnumValue, err)
+	}
+	if !errors.Is(u.Err(), ErrInvalidEnumValue) {
+		t.Errorf("expected recorded error %v, got %v", ErrInvalidEnumValue, u.Err())
+	}
+}
+
+func TestUnpacker_NextBytesNoCopy(t *testing.T) {
+	buffer := []byte{1, 2, 3, 4}
+
+	u := Unpacker{Buffer: buffer}
+	copied, err := u.NextBytes(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aliased, err := u.NextBytesNoCopy(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buffer[0], buffer[2] = 0xff, 0xff
+	if copied[0] != 1 {
+		t.Errorf("NextBytes() must not alias the buffer")
+	}
+	if aliased[0] != 0xff {
+		t.Errorf("NextBytesNoCopy() must alias the buffer")
+	}
+
+	if _, err := u.NextBytesNoCopy(1); !errors.Is(err, ErrNotEnoughDataToUnpack) {
+		t.Errorf("expected %v, got %v", ErrNotEnoughDataToUnpack, err)
+	}
+
+	u = Unpacker{Buffer: buffer}
+	raw, err := u.NextRaw(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buffer[0] = 0xfe
+	if raw[0] != 0xfe {
+		t.Errorf("NextRaw() must alias the buffer")
+	}
+}
+
+func benchmarkUnpackerBytes(b *testing.B, next func(u *Unpacker, size int) ([]byte, error)) {
+	var p Packer
+	for i := 0; i < 64; i++ {
+		p.Add(64)
+		p.Add(make([]byte, 64))
+	}
+	data := p.Bytes()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		u := Unpacker{Buffer: data}
+		for u.Size() > 0 {
+			size, _ := u.NextInt()
+			if _, err := next(&u, size); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkUnpacker_NextBytes(b *testing.B) {
+	benchmarkUnpackerBytes(b, (*Unpacker).NextBytes)
+}
+
+func BenchmarkUnpacker_NextBytesNoCopy(b *testing.B) {
+	benchmarkUnpackerBytes(b, (*Unpacker).NextBytesNoCopy)
+}
+
+func TestPacker_MustAdd(t *testing.T) {
+	var p Packer
+	p.MustAdd(1)
+	p.MustAdd("a")
+	p.MustAdd([]byte{2})
+
+	if !bytes.Equal(p.Bytes(), []byte{1, 'a', 0, 2}) {
+		t.Errorf("Packer.MustAdd() = %v, want %v", p.Bytes(), []byte{1, 'a', 0, 2})
+	}
+
+	if err := p.Add(1.5); !errors.Is(err, ErrTypeNotSupported) {
+		t.Errorf("expected %v, got %v", ErrTypeNotSupported, err)
+	}
+
+	defer func() {
+		if r := recover(); r != ErrTypeNotSupported {
+			t.Errorf("expected Packer.MustAdd() to panic with %v, got %v", ErrTypeNotSupported, r)
+		}
+	}()
+	p.MustAdd(1.5)
+}
+
+func BenchmarkPacker_Add(b *testing.B) {
+	data := newBenchmarkData()
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var p Packer
+		for _, value := range data {
+			if err := p.Add(int(value)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkUnpacker_NextInt(b *testing.B) {
+	data := newBenchmarkData()
+	var p Packer
+	for _, value := range data {
+		p.Add(int(value))
+	}
+	packed := p.Bytes()
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		u := Unpacker{Buffer: packed}
+		for range data {
+			if _, err := u.NextInt(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// testColor is a custom field type that is packed as three bytes.
+type testColor struct {
+	R, G, B byte
+}
+
+func (c testColor) MarshalBinary() ([]byte, error) {
+	return []byte{c.R, c.G, c.B}, nil
+}
+
+func (c *testColor) UnmarshalBinary(data []byte) error {
+	if len(data) != 3 {
+		return ErrInvalidCount
+	}
+	c.R, c.G, c.B = data[0], data[1], data[2]
+	return nil
+}
+
+func TestPacker_AddBinaryMarshaler(t *testing.T) {
+	want := testColor{R: 255, G: 128, B: 0}
+
+	var p Packer
+	p.MustAdd(1)
+	p.MustAdd(want)
+	p.MustAdd("after")
+
+	if !bytes.Equal(p.Bytes(), []byte{1, 3, 255, 128, 0, 'a', 'f', 't', 'e', 'r', 0}) {
+		t.Errorf("Packer.Add() = %v", p.Bytes())
+	}
+
+	u := Unpacker{Buffer: p.Bytes()}
+	if _, err := u.NextInt(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got testColor
+	if err := u.NextBinary(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("Unpacker.NextBinary() = %+v, want %+v", got, want)
+	}
+
+	s, err := u.NextString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != "after" {
+		t.Errorf("Unpacker.NextString() = %q, want %q", s, "after")
+	}
+
+	u = Unpacker{Buffer: []byte{10, 1, 2}}
+	if err := u.NextBinary(&got); !errors.Is(err, ErrInvalidCount) {
+		t.Errorf("expected %v, got %v", ErrInvalidCount, err)
+	}
+}
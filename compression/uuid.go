@@ -0,0 +1,71 @@
+package compression
+
+import (
+	"crypto/md5"
+	"sync"
+)
+
+// MessageIDExtended is the message id that signals that the actual message type
+// is identified by the UUID that follows the message id.
+const MessageIDExtended = 0
+
+// uuidNamespace is the namespace every extension UUID is derived from.
+var uuidNamespace = [16]byte{
+	0xe0, 0x5d, 0xda, 0xaa, 0xc4, 0xe6, 0x4c, 0xfb,
+	0xb6, 0x42, 0x5d, 0x48, 0xe8, 0x0c, 0x00, 0x29,
+}
+
+var (
+	extensionsMu sync.RWMutex
+	extensions   = make(map[[16]byte]string)
+)
+
+func init() {
+	for _, name := range []string{
+		"what-is@ddnet.tw",
+		"it-is@ddnet.tw",
+		"i-dont-know@ddnet.tw",
+		"rcon-type@ddnet.tw",
+		"map-details@ddnet.tw",
+		"capabilities@ddnet.tw",
+		"clientver@ddnet.tw",
+		"ping@ddnet.tw",
+		"pong@ddnet.tw",
+		"checksum-request@ddnet.tw",
+		"checksum-response@ddnet.tw",
+		"checksum-error@ddnet.tw",
+	} {
+		RegisterExtension(name)
+	}
+}
+
+// CalculateUUID derives the UUID of an extension from its name, e.g. "ping@ddnet.tw".
+// This is a version 3 UUID like the reference implementation's CalculateUuid creates.
+func CalculateUUID(name string) (uuid [16]byte) {
+	h := md5.New()
+	h.Write(uuidNamespace[:])
+	h.Write([]byte(name))
+	copy(uuid[:], h.Sum(nil))
+
+	uuid[6] = uuid[6]&0x0f | 0x30 // version 3
+	uuid[8] = uuid[8]&0x3f | 0x80 // RFC 4122 variant
+	return
+}
+
+// RegisterExtension adds the extension name to the known extensions and returns its UUID.
+func RegisterExtension(name string) [16]byte {
+	uuid := CalculateUUID(name)
+
+	extensionsMu.Lock()
+	defer extensionsMu.Unlock()
+	extensions[uuid] = name
+	return uuid
+}
+
+// ExtensionName returns the name of a known extension.
+func ExtensionName(uuid [16]byte) (name string, ok bool) {
+	extensionsMu.RLock()
+	defer extensionsMu.RUnlock()
+	name, ok = extensions[uuid]
+	return
+}
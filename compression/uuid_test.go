@@ -0,0 +1,65 @@
+package compression
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCalculateUUID(t *testing.T) {
+	uuid := CalculateUUID("what-is@ddnet.tw")
+
+	if uuid[6]>>4 != 3 {
+		t.Errorf("expected version 3, got %d", uuid[6]>>4)
+	}
+	if uuid[8]>>6 != 2 {
+		t.Errorf("expected RFC 4122 variant, got %b", uuid[8]>>6)
+	}
+	if uuid == CalculateUUID("it-is@ddnet.tw") {
+		t.Error("different names must result in different UUIDs")
+	}
+}
+
+func TestUnpacker_NextMessageHeader(t *testing.T) {
+	ping := CalculateUUID("ping@ddnet.tw")
+
+	var p Packer
+	p.AddMessageID(MessageIDExtended, true)
+	p.AddUUID(ping)
+	p.Add(42)
+	p.AddMessageID(3, false)
+
+	u := Unpacker{Buffer: p.Bytes()}
+	id, system, uuid, err := u.NextMessageHeader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != MessageIDExtended || !system || uuid != ping {
+		t.Fatalf("Unpacker.NextMessageHeader() = %d, %v, %x, want %d, %v, %x", id, system, uuid, MessageIDExtended, true, ping)
+	}
+
+	name, ok := ExtensionName(uuid)
+	if !ok || name != "ping@ddnet.tw" {
+		t.Errorf("ExtensionName() = %q, %v, want %q, %v", name, ok, "ping@ddnet.tw", true)
+	}
+
+	payload, err := u.NextInt()
+	if err != nil || payload != 42 {
+		t.Errorf("Unpacker.NextInt() = %d, %v, want %d, <nil>", payload, err, 42)
+	}
+
+	// regular message ids are not followed by a uuid
+	id, system, uuid, err = u.NextMessageHeader()
+	if err != nil || id != 3 || system || uuid != [16]byte{} {
+		t.Errorf("Unpacker.NextMessageHeader() = %d, %v, %x, %v, want %d, %v, zero uuid, <nil>", id, system, uuid, err, 3, false)
+	}
+
+	// truncated uuid
+	u.Reset([]byte{0x01, 0xaa, 0xbb})
+	if _, _, _, err = u.NextMessageHeader(); !errors.Is(err, ErrNotEnoughDataToUnpack) {
+		t.Errorf("expected %v, got %v", ErrNotEnoughDataToUnpack, err)
+	}
+
+	if _, ok := ExtensionName([16]byte{1}); ok {
+		t.Error("unknown uuid must not be found")
+	}
+}
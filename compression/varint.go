@@ -1,6 +1,7 @@
 package compression
 
 import (
+	"bytes"
 	"math"
 	"unsafe"
 )
@@ -84,6 +85,10 @@ func (v *VarInt) Unpack() (value int, err error) {
 			break
 		}
 		index++
+		if index >= len(data) {
+			// extended bit is set, but the buffer ends here
+			return 0, ErrNotEnoughDataToUnpack
+		}
 		value |= int(data[index]&0b01111111) << (6 + 7*i)
 	}
 
@@ -138,3 +143,37 @@ func (v *VarInt) Pack(value int) {
 	data = data[:index] // ignore unused 'space'
 	v.Compressed = append(v.Compressed, data...)
 }
+
+// VarIntSize returns the number of bytes value occupies when packed.
+func VarIntSize(value int) int {
+	if value < 0 {
+		value = ^value
+	}
+
+	size := 1
+	value >>= 6 // first byte contains 6 bits of data
+	for value != 0 {
+		size++
+		value >>= 7 // every following byte contains 7 bits of data
+	}
+	return size
+}
+
+// PackedStringSize returns the number of bytes s occupies when packed,
+// which is its length plus the string separator.
+func PackedStringSize(s string) int {
+	return len(s) + 1
+}
+
+// Equal returns true if both VarInts contain the same bytes.
+// This is byte equality, not value equality: different encodings of the same
+// value, e.g. a non canonical encoding with a superfluous extension byte, are not equal.
+func (v *VarInt) Equal(other VarInt) bool {
+	return EqualPacked(v.Compressed, other.Compressed)
+}
+
+// EqualPacked compares packed data without unpacking it.
+// Like VarInt.Equal this is byte equality, not value equality.
+func EqualPacked(a, b []byte) bool {
+	return bytes.Equal(a, b)
+}
@@ -212,6 +212,7 @@ func TestVarInt_Unpack(t *testing.T) {
 		{"default constructed", fields{nil}, 0, true},
 		{"32", fields{[]byte{0b00100000}}, 32, false},
 		{"5 byte, 604508192", fields{[]byte{0b10100000, 0b11000000, 0b11000000, 0b11000000, 0b00000100}}, 604508192, false},
+		{"truncated extended byte", fields{[]byte{0b10100000, 0b11000000}}, 0, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -260,3 +261,102 @@ func TestVarInt_Grow(t *testing.T) {
 		})
 	}
 }
+
+func TestVarInt_Equal(t *testing.T) {
+	var a, b VarInt
+	a.Pack(1234)
+	a.Pack(-1)
+	b.Pack(1234)
+	b.Pack(-1)
+
+	if !a.Equal(b) {
+		t.Errorf("expected %v to be equal to %v", a.Bytes(), b.Bytes())
+	}
+
+	b.Pack(0)
+	if a.Equal(b) {
+		t.Errorf("expected %v not to be equal to %v", a.Bytes(), b.Bytes())
+	}
+
+	// both encodings unpack to 0
+	canonical := []byte{0x00}
+	extended := []byte{0x80, 0x00}
+	for _, data := range [][]byte{canonical, extended} {
+		v := NewVarIntFrom(data)
+		if value, err := v.Unpack(); err != nil || value != 0 {
+			t.Fatalf("expected %v to unpack to 0, got %d, %v", data, value, err)
+		}
+	}
+
+	if EqualPacked(canonical, extended) {
+		t.Errorf("expected different encodings of the same value not to be equal")
+	}
+	if !EqualPacked(canonical, []byte{0x00}) {
+		t.Errorf("expected equal encodings to be equal")
+	}
+}
+
+func TestVarIntSize(t *testing.T) {
+	values := []int{
+		0, 1, -1,
+		63, 64, -64, -65,
+		1<<13 - 1, 1 << 13,
+		1<<20 - 1, 1 << 20, -(1 << 20), -(1 << 20) - 1,
+		1<<27 - 1, 1 << 27,
+		math.MaxInt32, math.MinInt32,
+	}
+
+	for _, value := range values {
+		var v VarInt
+		v.Pack(value)
+		if got := VarIntSize(value); got != v.Size() {
+			t.Errorf("VarIntSize(%d) = %d, want %d", value, got, v.Size())
+		}
+	}
+}
+
+func TestPackedStringSize(t *testing.T) {
+	for _, s := range []string{"", "a", "hello world", "ünïcödé"} {
+		var p Packer
+		p.Add(s)
+		if got := PackedStringSize(s); got != p.Size() {
+			t.Errorf("PackedStringSize(%q) = %d, want %d", s, got, p.Size())
+		}
+	}
+}
+
+func BenchmarkVarInt_Pack(b *testing.B) {
+	data := newBenchmarkData()
+	v := VarInt{Compressed: make([]byte, 0, maxBytesInVarInt*len(data))}
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v.Compressed = v.Compressed[:0]
+		for _, value := range data {
+			v.Pack(int(value))
+		}
+	}
+}
+
+func BenchmarkVarInt_Unpack(b *testing.B) {
+	data := newBenchmarkData()
+	v := VarInt{Compressed: make([]byte, 0, maxBytesInVarInt*len(data))}
+	for _, value := range data {
+		v.Pack(int(value))
+	}
+	packed := v.Compressed
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v.Compressed = packed
+		for range data {
+			if _, err := v.Unpack(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Package benchguard compares the output of "go test -bench" with a stored baseline
+// in order to detect performance regressions.
+package benchguard
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrNoBenchmarks is returned if the parsed output does not contain any benchmark results.
+	ErrNoBenchmarks = errors.New("no benchmark results found")
+
+	// gomaxprocsSuffix is appended to the benchmark names, e.g. BenchmarkFoo-8
+	gomaxprocsSuffix = regexp.MustCompile(`-\d+$`)
+)
+
+// Result is the measurement of a single benchmark.
+type Result struct {
+	Name     string
+	NsPerOp  float64
+	MBPerSec float64 // zero if the benchmark does not report its throughput
+}
+
+// Parse parses the output of "go test -bench". The GOMAXPROCS suffix is removed from the benchmark names,
+// so that results of machines with a different number of CPUs can be compared.
+// If a benchmark occurs multiple times, e.g. because of -count, the medians of its measurements are used,
+// which is why a few runs that are disturbed by other processes do not result in a regression.
+func Parse(r io.Reader) (map[string]Result, error) {
+	runs := make(map[string][]Result)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+
+		result := Result{Name: gomaxprocsSuffix.ReplaceAllString(fields[0], "")}
+
+		// fields[1] is the number of iterations, followed by value unit pairs
+		for idx := 2; idx+1 < len(fields); idx += 2 {
+			value, err := strconv.ParseFloat(fields[idx], 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid value %q: %w", result.Name, fields[idx], err)
+			}
+
+			switch fields[idx+1] {
+			case "ns/op":
+				result.NsPerOp = value
+			case "MB/s":
+				result.MBPerSec = value
+			}
+		}
+		runs[result.Name] = append(runs[result.Name], result)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(runs) == 0 {
+		return nil, ErrNoBenchmarks
+	}
+
+	results := make(map[string]Result, len(runs))
+	for name, rs := range runs {
+		nsPerOp := make([]float64, 0, len(rs))
+		mbPerSec := make([]float64, 0, len(rs))
+		for _, r := range rs {
+			nsPerOp = append(nsPerOp, r.NsPerOp)
+			mbPerSec = append(mbPerSec, r.MBPerSec)
+		}
+		results[name] = Result{
+			Name:     name,
+			NsPerOp:  median(nsPerOp),
+			MBPerSec: median(mbPerSec),
+		}
+	}
+	return results, nil
+}
+
+// median returns the median of values, which must not be empty.
+func median(values []float64) float64 {
+	sort.Float64s(values)
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
+
+// Regression is a benchmark that became slower than allowed.
+type Regression struct {
+	Baseline Result
+	Current  Result
+
+	// Slowdown is the relative loss of speed, e.g. 0.5 if the benchmark takes 50% longer
+	// or its throughput is 50% lower.
+	Slowdown float64
+}
+
+// String returns a human readable description of the regression.
+func (r Regression) String() string {
+	if r.Baseline.MBPerSec > 0 && r.Current.MBPerSec > 0 {
+		return fmt.Sprintf("%s: %.2f MB/s -> %.2f MB/s (%.1f%% slower)",
+			r.Baseline.Name, r.Baseline.MBPerSec, r.Current.MBPerSec, r.Slowdown*100)
+	}
+	return fmt.Sprintf("%s: %.0f ns/op -> %.0f ns/op (%.1f%% slower)",
+		r.Baseline.Name, r.Baseline.NsPerOp, r.Current.NsPerOp, r.Slowdown*100)
+}
+
+// Compare returns the benchmarks that are more than threshold slower than their baseline, e.g. a threshold of 0.2
+// allows 20% slowdown. The throughput is compared if both results report it, otherwise the time per operation.
+// Benchmarks that are missing in either of the result sets are ignored. The regressions are sorted by name.
+func Compare(baseline, current map[string]Result, threshold float64) []Regression {
+	regressions := make([]Regression, 0)
+	for name, base := range baseline {
+		cur, ok := current[name]
+		if !ok {
+			continue
+		}
+
+		slowdown := 0.0
+		switch {
+		case base.MBPerSec > 0 && cur.MBPerSec > 0:
+			slowdown = base.MBPerSec/cur.MBPerSec - 1
+		case base.NsPerOp > 0:
+			slowdown = cur.NsPerOp/base.NsPerOp - 1
+		}
+
+		if slowdown > threshold {
+			regressions = append(regressions, Regression{
+				Baseline: base,
+				Current:  cur,
+				Slowdown: slowdown,
+			})
+		}
+	}
+
+	sort.Slice(regressions, func(i, j int) bool {
+		return regressions[i].Baseline.Name < regressions[j].Baseline.Name
+	})
+	return regressions
+}
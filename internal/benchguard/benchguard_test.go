@@ -0,0 +1,102 @@
+package benchguard
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const benchmarkOutput = `goos: linux
+goarch: amd64
+pkg: github.com/jxsl13/twapi/compression
+BenchmarkHuffman_Compress-8   	     100	  10000000 ns/op	 104.86 MB/s	       0 B/op	       0 allocs/op
+BenchmarkUnpacker_NextBytes-8 	  200000	      5000 ns/op	    4144 B/op	      65 allocs/op
+PASS
+ok  	github.com/jxsl13/twapi/compression	3.014s
+`
+
+func TestParse(t *testing.T) {
+	results, err := Parse(strings.NewReader(benchmarkOutput))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]Result{
+		"BenchmarkHuffman_Compress":   {Name: "BenchmarkHuffman_Compress", NsPerOp: 10000000, MBPerSec: 104.86},
+		"BenchmarkUnpacker_NextBytes": {Name: "BenchmarkUnpacker_NextBytes", NsPerOp: 5000},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("Parse() returned %d results, want %d", len(results), len(want))
+	}
+	for name, result := range want {
+		if results[name] != result {
+			t.Errorf("Parse()[%s] = %+v, want %+v", name, results[name], result)
+		}
+	}
+
+	if _, err := Parse(strings.NewReader("PASS\n")); !errors.Is(err, ErrNoBenchmarks) {
+		t.Errorf("expected %v, got %v", ErrNoBenchmarks, err)
+	}
+}
+
+func TestParseMedian(t *testing.T) {
+	// the second run was disturbed by another process
+	const output = `BenchmarkVarInt_Pack-2   	     100	  10000000 ns/op	 100.00 MB/s
+BenchmarkVarInt_Pack-2   	      50	  30000000 ns/op	  33.33 MB/s
+BenchmarkVarInt_Pack-2   	     100	  11000000 ns/op	  90.91 MB/s
+BenchmarkUnpacker_NextInt-2	     100	   1000 ns/op
+BenchmarkUnpacker_NextInt-2	     100	   3000 ns/op
+`
+	results, err := Parse(strings.NewReader(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]Result{
+		"BenchmarkVarInt_Pack":      {Name: "BenchmarkVarInt_Pack", NsPerOp: 11000000, MBPerSec: 90.91},
+		"BenchmarkUnpacker_NextInt": {Name: "BenchmarkUnpacker_NextInt", NsPerOp: 2000},
+	}
+	for name, result := range want {
+		if results[name] != result {
+			t.Errorf("Parse()[%s] = %+v, want %+v", name, results[name], result)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	baseline := map[string]Result{
+		"BenchmarkThroughput": {Name: "BenchmarkThroughput", NsPerOp: 1000, MBPerSec: 100},
+		"BenchmarkTime":       {Name: "BenchmarkTime", NsPerOp: 1000},
+		"BenchmarkFaster":     {Name: "BenchmarkFaster", NsPerOp: 1000},
+		"BenchmarkRemoved":    {Name: "BenchmarkRemoved", NsPerOp: 1000},
+	}
+	current := map[string]Result{
+		"BenchmarkThroughput": {Name: "BenchmarkThroughput", NsPerOp: 2000, MBPerSec: 50},
+		"BenchmarkTime":       {Name: "BenchmarkTime", NsPerOp: 1100},
+		"BenchmarkFaster":     {Name: "BenchmarkFaster", NsPerOp: 500},
+	}
+
+	tests := []struct {
+		name      string
+		threshold float64
+		want      []string
+	}{
+		{"strict", 0.05, []string{"BenchmarkThroughput", "BenchmarkTime"}},
+		{"tolerant", 0.2, []string{"BenchmarkThroughput"}},
+		{"lenient", 1.5, []string{}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			regressions := Compare(baseline, current, tt.threshold)
+			if len(regressions) != len(tt.want) {
+				t.Fatalf("Compare() returned %v, want %v", regressions, tt.want)
+			}
+			for idx, r := range regressions {
+				if r.Baseline.Name != tt.want[idx] {
+					t.Errorf("Compare()[%d] = %s, want %s", idx, r.Baseline.Name, tt.want[idx])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,380 @@
+// Package mockserver emulates Teeworlds 0.7 master servers and game servers on the loopback interface.
+// It is the implementation of browser/testutil.MockServer and of the fixtures of the browser tests,
+// which is why it must not import package browser: the servers are configured with packed responses.
+// 0.6 info requests, e.g. the extended info requests of DDNet, are passed to a handler, see SetLegacyInfoHandler.
+package mockserver
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// DefaultToken is the token that a Server hands out to its clients, unless it is changed with SetToken.
+// It never changes on its own, which keeps the exchanged packets deterministic.
+const DefaultToken = 0x12345678
+
+const (
+	netPacketFlagControl   = 1
+	netPacketFlagConnless  = 8
+	netPacketVersion       = 1
+	netControlMessageToken = 5
+
+	tokenResponseSize = 12
+	tokenPrefixSize   = 9
+	legacyHeaderSize  = 6
+	maxBufferSize     = 1500
+
+	// MaxServersPerPacket is the number of servers that master servers send in a single server list packet.
+	MaxServersPerPacket = 75
+)
+
+// headers of the connectionless requests and responses
+var (
+	RequestServerListHeader  = []byte("\xff\xff\xff\xffreq2")
+	SendServerListHeader     = []byte("\xff\xff\xff\xfflis2")
+	RequestServerCountHeader = []byte("\xff\xff\xff\xffcou2")
+	RequestInfoHeader        = []byte("\xff\xff\xff\xffgie3")
+	SendInfoHeader           = []byte("\xff\xff\xff\xffinf3")
+)
+
+var (
+	// ErrServerClosed is returned by Start if the server has already been stopped.
+	ErrServerClosed = errors.New("mock server closed")
+)
+
+// Request is the kind of a connectionless request that a Server answers.
+type Request int
+
+const (
+	// RequestServerList is the request of a master server's server list.
+	RequestServerList Request = iota
+	// RequestServerCount is the request of the number of servers registered at a master server.
+	RequestServerCount
+	// RequestInfo is the request of a game server's server info.
+	RequestInfo
+)
+
+// String returns the name of the request.
+func (r Request) String() string {
+	switch r {
+	case RequestServerList:
+		return "serverlist"
+	case RequestServerCount:
+		return "servercount"
+	case RequestInfo:
+		return "serverinfo"
+	default:
+		return "unknown"
+	}
+}
+
+// Server answers token requests and responds to requests that carry its token with the
+// queued responses, falling back to the configured server list and server info.
+// All methods are safe for concurrent use, including calls from the function of SetBeforeResponse.
+type Server struct {
+	conn *net.UDPConn
+	wg   sync.WaitGroup
+
+	mu             sync.Mutex
+	started        bool
+	closed         bool
+	token          int
+	servers        []*net.UDPAddr
+	strayServers   []*net.UDPAddr
+	info           []byte
+	delay          time.Duration
+	beforeResponse func(addr *net.UDPAddr)
+	legacyInfo     func(request []byte, addr *net.UDPAddr)
+	queued         map[Request][][][]byte
+	requests       map[Request]int
+}
+
+// New creates a server that listens on a random port of the loopback interface.
+// It does not respond to any requests until Start is called.
+func New() (*Server, error) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		conn:     conn,
+		token:    DefaultToken,
+		queued:   make(map[Request][][][]byte),
+		requests: make(map[Request]int),
+	}, nil
+}
+
+// Addr returns the address the server is listening on.
+func (s *Server) Addr() *net.UDPAddr {
+	return s.conn.LocalAddr().(*net.UDPAddr)
+}
+
+// Start starts serving requests in the background.
+// Calling Start on a running server does nothing.
+func (s *Server) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrServerClosed
+	} else if s.started {
+		return nil
+	}
+	s.started = true
+
+	s.wg.Add(1)
+	go s.serve()
+	return nil
+}
+
+// Stop closes the server and waits for it to shut down.
+func (s *Server) Stop() error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+
+	err := s.conn.Close()
+	s.wg.Wait()
+	return err
+}
+
+// Token returns the token that the server hands out.
+func (s *Server) Token() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token
+}
+
+// SetToken changes the token that the server hands out and accepts, e.g. in order to reject
+// the token of previous requests.
+func (s *Server) SetToken(token int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+}
+
+// SetServerList sets the list that is sent in response to server list requests,
+// unless a response has been queued. The list is split into packets of at most
+// MaxServersPerPacket servers, like master servers do. A nil list does not respond.
+func (s *Server) SetServerList(servers []*net.UDPAddr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.servers = servers
+}
+
+// SetStrayServerList sets a list whose first packet is sent with a wrong client token
+// before every server list packet, like the late responses of previous requests.
+func (s *Server) SetStrayServerList(servers []*net.UDPAddr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.strayServers = servers
+}
+
+// SetServerInfo sets the packed server info that is sent after the echoed extra token
+// in response to server info requests, unless a response has been queued. Nil does not respond.
+func (s *Server) SetServerInfo(data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.info = data
+}
+
+// SetDelay delays the responses to every request, e.g. to emulate a slow server or a long distance.
+func (s *Server) SetDelay(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delay = d
+}
+
+// SetBeforeResponse sets a function that is called with the client's address right before
+// a request that carries the server's token is answered.
+func (s *Server) SetBeforeResponse(f func(addr *net.UDPAddr)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.beforeResponse = f
+}
+
+// SetLegacyInfoHandler sets a function that is called with the 0.6 info requests, including the extended
+// info requests of DDNet, which the server does not answer on its own. The function responds with WriteTo,
+// request is only valid during the call. 0.6 requests are not recognized unless a function is set.
+func (s *Server) SetLegacyInfoHandler(f func(request []byte, addr *net.UDPAddr)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.legacyInfo = f
+}
+
+// QueueResponse queues a response to the next request of the passed kind.
+// Each payload is sent as a separate packet after the token prefix, which is why a payload
+// starts with the response header, e.g. "\xff\xff\xff\xfflis2". Queued responses are used
+// in the order they were queued. Queuing a response without any payloads drops the request.
+func (s *Server) QueueResponse(r Request, payloads ...[]byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queued[r] = append(s.queued[r], payloads)
+}
+
+// Requests returns the number of received requests of the passed kind that carried a valid token.
+func (s *Server) Requests(r Request) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[r]
+}
+
+// WriteTo sends a raw packet to addr, e.g. a spoofed or malformed response.
+func (s *Server) WriteTo(packet []byte, addr *net.UDPAddr) error {
+	_, err := s.conn.WriteToUDP(packet, addr)
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	buf := make([]byte, maxBufferSize)
+	for {
+		n, addr, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		for _, packet := range s.handle(buf[:n], addr) {
+			s.conn.WriteToUDP(packet, addr)
+		}
+	}
+}
+
+// handle returns the packets that are sent in response to the request.
+func (s *Server) handle(request []byte, addr *net.UDPAddr) [][]byte {
+	s.mu.Lock()
+	delay, token, beforeResponse, legacyInfo := s.delay, s.token, s.beforeResponse, s.legacyInfo
+	s.mu.Unlock()
+
+	time.Sleep(delay)
+
+	// 0.6 requests have a header of 6 bytes without the tokens of 0.7
+	if legacyInfo != nil && len(request) >= legacyHeaderSize && bytes.HasPrefix(request[legacyHeaderSize:], RequestInfoHeader) {
+		legacyInfo(request, addr)
+		return nil
+	}
+
+	if len(request) < tokenPrefixSize {
+		return nil
+	}
+	flags := request[0] >> 2
+
+	switch {
+	case flags&netPacketFlagControl != 0 && len(request) >= tokenResponseSize && request[7] == netControlMessageToken:
+		return [][]byte{PackTokenResponse(unpackInt(request[8:12]), token)}
+
+	case flags&netPacketFlagConnless != 0:
+		if unpackInt(request[1:5]) != token {
+			// unknown token, real servers drop such packets
+			return nil
+		}
+		clientToken := unpackInt(request[5:9])
+		payload := request[tokenPrefixSize:]
+
+		if beforeResponse != nil {
+			beforeResponse(addr)
+		}
+
+		var r Request
+		switch {
+		case bytes.HasPrefix(payload, RequestServerListHeader):
+			r = RequestServerList
+		case bytes.HasPrefix(payload, RequestServerCountHeader):
+			r = RequestServerCount
+		case bytes.HasPrefix(payload, RequestInfoHeader):
+			r = RequestInfo
+		default:
+			return nil
+		}
+
+		payloads, stray := s.response(r, payload)
+		prefix := PackTokenPrefix(clientToken, token)
+		packets := make([][]byte, 0, 2*len(payloads))
+		for _, p := range payloads {
+			if stray != nil {
+				packets = append(packets, append(PackTokenPrefix(clientToken+1, token), stray...))
+			}
+			packets = append(packets, append(append([]byte{}, prefix...), p...))
+		}
+		return packets
+	}
+	return nil
+}
+
+// response returns the payloads that are sent in response to a request of kind r and the stray payload
+// that precedes every server list payload, if any.
+func (s *Server) response(r Request, request []byte) (payloads [][]byte, stray []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests[r]++
+	if queue := s.queued[r]; len(queue) > 0 {
+		s.queued[r] = queue[1:]
+		return queue[0], nil
+	}
+
+	switch {
+	case r == RequestServerList && s.servers != nil:
+		if s.strayServers != nil {
+			stray = PackServerList(s.strayServers)[0]
+		}
+		return PackServerList(s.servers), stray
+	case r == RequestInfo && s.info != nil:
+		// the extra token of the request is echoed as it is
+		extraToken := request[len(RequestInfoHeader):]
+		return [][]byte{append(append(append([]byte{}, SendInfoHeader...), extraToken...), s.info...)}, nil
+	}
+	return nil, nil
+}
+
+// PackServerList splits the server list into server list payloads of at most MaxServersPerPacket servers.
+// An empty list results in a single payload without servers.
+func PackServerList(servers []*net.UDPAddr) [][]byte {
+	payloads := make([][]byte, 0, len(servers)/MaxServersPerPacket+1)
+	for len(servers) > 0 || len(payloads) == 0 {
+		size := len(servers)
+		if size > MaxServersPerPacket {
+			size = MaxServersPerPacket
+		}
+
+		payload := append([]byte{}, SendServerListHeader...)
+		for _, srv := range servers[:size] {
+			payload = append(payload, srv.IP.To16()...)
+			payload = append(payload, byte(srv.Port>>8), byte(srv.Port))
+		}
+		payloads = append(payloads, payload)
+		servers = servers[size:]
+	}
+	return payloads
+}
+
+// PackTokenResponse creates the response to a token request, which hands out serverToken.
+func PackTokenResponse(clientToken, serverToken int) []byte {
+	b := make([]byte, tokenResponseSize)
+	b[0] = netPacketFlagControl << 2
+	copy(b[3:7], packInt(clientToken))
+	b[7] = netControlMessageToken
+	copy(b[8:12], packInt(serverToken))
+	return b
+}
+
+// PackTokenPrefix creates the prefix of connectionless packets,
+// which carries the recipient's token followed by the sender's token.
+func PackTokenPrefix(recipientToken, senderToken int) []byte {
+	b := make([]byte, 0, tokenPrefixSize)
+	b = append(b, netPacketFlagConnless<<2|netPacketVersion)
+	b = append(b, packInt(recipientToken)...)
+	return append(b, packInt(senderToken)...)
+}
+
+func packInt(i int) []byte {
+	return []byte{byte(i >> 24), byte(i >> 16), byte(i >> 8), byte(i)}
+}
+
+func unpackInt(b []byte) int {
+	return int(b[0])<<24 | int(b[1])<<16 | int(b[2])<<8 | int(b[3])
+}
@@ -1 +1,73 @@
+// Package twapi provides access to the Teeworlds server browser, the external console
+// and parts of the network protocol. This package itself reports which of these features
+// the library supports, so that callers can degrade gracefully if a feature is missing.
 package twapi
+
+import "github.com/jxsl13/twapi/browser"
+
+// ProtocolVersion is a version of the Teeworlds network protocol, e.g. "0.7".
+type ProtocolVersion string
+
+const (
+	// Protocol06 is the network protocol of Teeworlds 0.6 and DDNet, whose server infos, kill messages
+	// and scoreboards are decoded.
+	Protocol06 ProtocolVersion = "0.6"
+
+	// Protocol07 is the network protocol of Teeworlds 0.7, which is used by the server browser and the client.
+	Protocol07 ProtocolVersion = "0.7"
+)
+
+// Features reports which features are available in this version of the library.
+type Features struct {
+	// ServerBrowser is true if server lists can be fetched from the UDP master servers
+	// and game servers can be queried for their server info (package browser).
+	ServerBrowser bool
+
+	// LANDiscovery is true if game servers can be discovered in the local network (browser.DiscoverLAN).
+	LANDiscovery bool
+
+	// HTTPMaster is true if server lists can be fetched from the HTTP master servers of DDNet.
+	HTTPMaster bool
+
+	// Econ is true if the external console of game servers is supported (package econ).
+	Econ bool
+
+	// Demo is true if demo files can be read.
+	Demo bool
+
+	// DDNetExtendedInfo is true if the extended server infos of DDNet servers, which list up to 64 players
+	// in multiple packets, are supported (browser.GetServerInfoExtended).
+	DDNetExtendedInfo bool
+}
+
+// infoParsers contains the server info parsers of the supported protocol versions in the order of
+// the versions, which is why a version is only reported if its server infos can be parsed.
+var infoParsers = []struct {
+	version ProtocolVersion
+	parse   func(serverResponse []byte, address string) (browser.ServerInfo, error)
+}{
+	{Protocol06, browser.ParseLegacyServerInfo},
+	{Protocol07, browser.ParseServerInfo},
+}
+
+// SupportedProtocols returns the network protocol versions that are supported by this library.
+func SupportedProtocols() []ProtocolVersion {
+	versions := make([]ProtocolVersion, 0, len(infoParsers))
+	for _, p := range infoParsers {
+		versions = append(versions, p.version)
+	}
+	return versions
+}
+
+// Capabilities returns the features that are available in this version of the library.
+func Capabilities() Features {
+	return Features{
+		ServerBrowser: true,
+		LANDiscovery:  true,
+		HTTPMaster:    false,
+		Econ:          true,
+		Demo:          false,
+
+		DDNetExtendedInfo: true,
+	}
+}
@@ -0,0 +1,76 @@
+package twapi
+
+import (
+	"testing"
+
+	"github.com/jxsl13/twapi/browser"
+	"github.com/jxsl13/twapi/client/network"
+	"github.com/jxsl13/twapi/econ"
+)
+
+// the advertised features must be compiled in, which is why this test does not compile if they are removed.
+var (
+	_ = browser.ListServersWithInfo
+	_ = browser.DiscoverLAN
+	_ = browser.GetServerInfoExtended
+	_ = econ.DialTo
+	_ = network.NetMaxPacketsize
+)
+
+func TestCapabilities(t *testing.T) {
+	want := Features{
+		ServerBrowser:     true,
+		LANDiscovery:      true,
+		Econ:              true,
+		DDNetExtendedInfo: true,
+	}
+	if got := Capabilities(); got != want {
+		t.Errorf("Capabilities() = %+v, want %+v", got, want)
+	}
+}
+
+// packInfoResponse creates a server info response of the passed protocol version.
+func packInfoResponse(t *testing.T, version ProtocolVersion, name string) []byte {
+	switch version {
+	case Protocol06:
+		return []byte("\xff\xff\xff\xff\xff\xff\xff\xff\xff\xffinf3" +
+			"0\x000.6.4\x00" + name + "\x00dm1\x00DM\x000\x000\x0016\x000\x0016\x00")
+	case Protocol07:
+		info := browser.ServerInfo{Version: "0.7.5", Name: name}
+		data, err := info.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// token prefix, header and the echoed extra token
+		response := append(make([]byte, 9), "\xff\xff\xff\xffinf3\x00"...)
+		return append(response, data...)
+	default:
+		t.Fatalf("no server info response of protocol version %s", version)
+		return nil
+	}
+}
+
+func TestSupportedProtocols(t *testing.T) {
+	protocols := SupportedProtocols()
+	if len(protocols) != len(infoParsers) {
+		t.Fatalf("SupportedProtocols() = %v, want %d versions", protocols, len(infoParsers))
+	}
+
+	// every reported version can parse its server infos
+	for idx, version := range protocols {
+		info, err := infoParsers[idx].parse(packInfoResponse(t, version, "server"), "127.0.0.1:8303")
+		if err != nil || info.Name != "server" {
+			t.Errorf("parsing a %s server info = %q, %v", version, info.Name, err)
+		}
+	}
+
+	for _, want := range []ProtocolVersion{Protocol06, Protocol07} {
+		supported := false
+		for _, version := range protocols {
+			supported = supported || version == want
+		}
+		if !supported {
+			t.Errorf("SupportedProtocols() = %v, want %s to be supported", protocols, want)
+		}
+	}
+}